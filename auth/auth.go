@@ -42,6 +42,7 @@ const (
 	FederatedAzure   = AuthType("azure")
 	FederatedGcp     = AuthType("gcp")
 	Oidc             = AuthType("oidc")
+	Jwt              = AuthType("jwt")
 )
 
 // authTypeToGrantType maps authentication type to grant type which will be sent to DSV.
@@ -55,6 +56,7 @@ var authTypeToGrantType = map[AuthType]string{
 	FederatedAzure:   "azure",
 	FederatedGcp:     "gcp",
 	Oidc:             "oidc",
+	Jwt:              "jwt",
 }
 
 // authTypeToCachePrefix maps authentication type to cache key prefix.
@@ -69,6 +71,7 @@ var authTypeToCachePrefix = map[AuthType]string{
 	FederatedAzure:   "token-azure-",
 	FederatedGcp:     "token-gcp-",
 	Oidc:             "token-oidc-",
+	Jwt:              "token-jwt-",
 }
 
 func getTokenCacheKey(a AuthType, tenant string, profile string) string {
@@ -156,44 +159,26 @@ func (a *authenticator) WipeCachedTokens() error {
 
 func (a *authenticator) getToken(at AuthType, cacheKey string) (*TokenResponse, *errors.ApiError) {
 	if cacheKey != "" {
-		tr := &TokenResponse{}
-		err := a.store.Get(cacheKey, tr)
-		if err != nil {
-			return nil, errors.New(err).Grow("Failed to read token from cache.")
+		// Tokens read from files or the environment rotate, so with --auth-jwt-reread the current
+		// one is sent instead of refreshing the cached access token.
+		canRefresh := at != Jwt || !viper.GetBool(cst.AuthJwtReread)
+		locker, canLock := a.store.(store.Locker)
+		if tr, err := a.getCachedToken(cacheKey, !canLock && canRefresh); err != nil || tr != nil {
+			return tr, err
 		}
 
-		if !tr.IsNil() {
-			// lifetime defines how many seconds passed since token was fetched.
-			lifetime := int64(time.Now().UTC().Sub(tr.Granted).Seconds())
-
-			// Add some space for actions.
-			lifetime = lifetime + leewaySecondsTokenExp
-
-			// If access token is still valid, return it.
-			if (lifetime - tr.ExpiresIn) <= 0 {
-				return tr, nil
+		if canLock {
+			// Parallel processes with an expired token must not refresh or reauthenticate at the same
+			// time, since a refresh token can be used only once. The first process fetches a new token
+			// and the others wait for it and pick it up from the cache.
+			unlock, err := locker.Lock(cacheKey)
+			if err != nil {
+				return nil, errors.New(err).Grow("Failed to wait for another process authenticating.")
 			}
+			defer unlock()
 
-			// If refresh token is present and still valid, use it to get a new token.
-			if tr.RefreshToken != "" && ((lifetime - refreshTokenLifeSeconds) <= 0) {
-				log.Print("Token expired but valid refresh token found. Attempting to refresh.")
-
-				data := &requestBody{
-					GrantType:    authTypeToGrantType[Refresh],
-					RefreshToken: tr.RefreshToken,
-				}
-
-				if tr, err := a.fetchTokenVault(Refresh, data); err != nil {
-					log.Printf("Refresh authentication failed: %s", err.Error())
-				} else {
-					log.Printf("Refresh authentication succeeded.")
-					if err := a.store.Store(cacheKey, tr); err != nil {
-						return nil, errors.New(err).Grow("Failed caching token")
-					}
-					return tr, nil
-				}
-			} else {
-				log.Printf("Refresh token expired. Attempting to reauthenticate.")
+			if tr, err := a.getCachedToken(cacheKey, canRefresh); err != nil || tr != nil {
+				return tr, err
 			}
 		}
 	}
@@ -224,6 +209,57 @@ func (a *authenticator) getToken(at AuthType, cacheKey string) (*TokenResponse,
 	return tr, nil
 }
 
+// getCachedToken returns the cached token if its access token is still valid. If refresh is true and
+// the access token has expired it tries to get a new token using the cached refresh token.
+// It returns nil if there is no usable token.
+func (a *authenticator) getCachedToken(cacheKey string, refresh bool) (*TokenResponse, *errors.ApiError) {
+	tr := &TokenResponse{}
+	err := a.store.Get(cacheKey, tr)
+	if err != nil {
+		return nil, errors.New(err).Grow("Failed to read token from cache.")
+	}
+	if tr.IsNil() {
+		return nil, nil
+	}
+
+	// lifetime defines how many seconds passed since token was fetched.
+	lifetime := int64(time.Now().UTC().Sub(tr.Granted).Seconds())
+
+	// Add some space for actions.
+	lifetime = lifetime + leewaySecondsTokenExp
+
+	// If access token is still valid, return it.
+	if (lifetime - tr.ExpiresIn) <= 0 {
+		return tr, nil
+	}
+	if !refresh {
+		return nil, nil
+	}
+
+	// If refresh token is present and still valid, use it to get a new token.
+	if tr.RefreshToken != "" && ((lifetime - refreshTokenLifeSeconds) <= 0) {
+		log.Print("Token expired but valid refresh token found. Attempting to refresh.")
+
+		data := &requestBody{
+			GrantType:    authTypeToGrantType[Refresh],
+			RefreshToken: tr.RefreshToken,
+		}
+
+		if tr, err := a.fetchTokenVault(Refresh, data); err != nil {
+			log.Printf("Refresh authentication failed: %s", err.Error())
+		} else {
+			log.Printf("Refresh authentication succeeded.")
+			if err := a.store.Store(cacheKey, tr); err != nil {
+				return nil, errors.New(err).Grow("Failed caching token")
+			}
+			return tr, nil
+		}
+	} else {
+		log.Printf("Refresh token expired. Attempting to reauthenticate.")
+	}
+	return nil, nil
+}
+
 type requestBody struct {
 	GrantType          string `json:"grant_type"`
 	Username           string `json:"username,omitempty"`
@@ -281,6 +317,9 @@ func (a *authenticator) newRequestBody(at AuthType) (*requestBody, error) {
 		gcpAuthType := viper.GetString(cst.GcpAuthType)
 		data, stdErr = buildGcpParams(token, gcpAuthType)
 
+	case Jwt:
+		data, stdErr = buildJwtParams()
+
 	default:
 		stdErr = fmt.Errorf("unexpected authentication type %q", at)
 	}
@@ -315,6 +354,20 @@ func (r *TokenResponse) IsNil() bool {
 	return r.Token == "" && r.RefreshToken == ""
 }
 
+// ExpiresAt returns the time when the access token expires.
+func (r *TokenResponse) ExpiresAt() time.Time {
+	return r.Granted.Add(time.Duration(r.ExpiresIn) * time.Second)
+}
+
+// RefreshExpiresAt returns the time after which the refresh token is not used anymore. It returns
+// the zero time if there is no refresh token.
+func (r *TokenResponse) RefreshExpiresAt() time.Time {
+	if r.RefreshToken == "" {
+		return time.Time{}
+	}
+	return r.Granted.Add(refreshTokenLifeSeconds * time.Second)
+}
+
 func (r *requestBody) validate(at AuthType) error {
 	ref := reflect.Indirect(reflect.ValueOf(r))
 	for _, k := range paramSpecDict[at] {
@@ -349,4 +402,8 @@ var paramSpecDict = map[AuthType][]paramSpec{
 	},
 	FederatedAzure: {{PropName: "JwtToken", ArgName: "jwt"}},
 	FederatedGcp:   {{PropName: "JwtToken", ArgName: "jwt"}},
+	Jwt: {
+		{PropName: "Jwt", ArgName: cst.AuthJwtFile},
+		{PropName: "Provider", ArgName: cst.AuthProvider},
+	},
 }
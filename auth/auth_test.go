@@ -209,6 +209,43 @@ func TestGetToken_CachedRefreshToken(t *testing.T) {
 	}
 }
 
+func TestGetToken_RefreshedByAnotherProcess(t *testing.T) {
+	viper.Reset()
+	defer viper.Reset()
+	viper.Set(cst.Profile, "profilename")
+	viper.Set(cst.Tenant, "tenantname")
+
+	cacheKey := "token-password-tenantname-profilename"
+	st := store.NewFileStore(t.TempDir())
+	err := st.Store(cacheKey, auth.TokenResponse{
+		Token:        "aaa-token-bbb",
+		RefreshToken: "aaa-refreshtoken-bbb",
+		Granted:      time.Now().AddDate(0, 0, -1),
+		ExpiresIn:    3600,
+	})
+	assert.NoError(t, err)
+
+	// Another process is refreshing the token.
+	unlock, err := st.(store.Locker).Lock(cacheKey)
+	assert.NoError(t, err)
+	go func() {
+		time.Sleep(200 * time.Millisecond)
+		_ = st.Store(cacheKey, auth.TokenResponse{
+			Token:        "aaa-new-token-bbb",
+			RefreshToken: "aaa-new-refreshtoken-bbb",
+			Granted:      time.Now(),
+			ExpiresIn:    3600,
+		})
+		unlock()
+	}()
+
+	httpClient := &fake.FakeClient{}
+	tr, apiErr := auth.NewAuthenticator(st, httpClient).GetToken()
+	assert.Nil(t, apiErr)
+	assert.Equal(t, "aaa-new-token-bbb", tr.Token)
+	assert.Equal(t, 0, httpClient.DoRequestOutCallCount())
+}
+
 // TODO:  need to refactor the code and rewrite
 //
 //nolint:perfsprint //errors.go needs work to implement here
@@ -612,3 +649,92 @@ func registerResponse(url string, status int, method string, jsonResponse map[st
 		},
 	)
 }
+
+func TestTokenResponseExpiry(t *testing.T) {
+	granted := time.Date(2026, 1, 2, 10, 0, 0, 0, time.UTC)
+	tr := &auth.TokenResponse{Token: "token", ExpiresIn: 3600, Granted: granted}
+	assert.Equal(t, granted.Add(time.Hour), tr.ExpiresAt())
+	assert.True(t, tr.RefreshExpiresAt().IsZero())
+
+	tr.RefreshToken = "refresh"
+	assert.Equal(t, granted.Add(720*time.Hour), tr.RefreshExpiresAt())
+}
+
+func TestParseClaimsFromToken(t *testing.T) {
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"sub": "users:alice",
+		"iss": "https://mocktenant.secretsvaultcloud.com/",
+		"exp": 1767348000,
+	}).SignedString([]byte("key"))
+	assert.NoError(t, err)
+
+	claims, err := auth.ParseClaimsFromToken(token)
+	assert.NoError(t, err)
+	assert.Equal(t, "users:alice", claims["sub"])
+	assert.Equal(t, "https://mocktenant.secretsvaultcloud.com/", claims["iss"])
+	assert.Equal(t, float64(1767348000), claims["exp"])
+
+	_, err = auth.ParseClaimsFromToken("not-a-token")
+	assert.Error(t, err)
+}
+
+func TestGetToken_Jwt(t *testing.T) {
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	assert.NoError(t, os.WriteFile(tokenFile, []byte("k8s-token\n"), 0o600))
+
+	testCases := []struct {
+		name   string
+		reread bool
+		body   string
+	}{
+		{name: "refresh token used", body: `{"grant_type":"refresh_token","refresh_token":"aaa-refreshtoken-bbb"}`},
+		{name: "token read again", reread: true, body: `{"grant_type":"jwt","provider":"k8s-prod","jwt":"k8s-token"}`},
+	}
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			viper.Reset()
+			defer viper.Reset()
+			viper.Set(cst.Profile, "profilename")
+			viper.Set(cst.Tenant, "tenantname")
+			viper.Set(cst.AuthType, "jwt")
+			viper.Set(cst.AuthProvider, "k8s-prod")
+			viper.Set(cst.AuthJwtFile, tokenFile)
+			viper.Set(cst.AuthJwtReread, tt.reread)
+
+			var usedCacheKey string
+			st := &fake.FakeStore{}
+			st.GetStub = func(s string, out any) error {
+				usedCacheKey = s
+				tr := out.(*auth.TokenResponse)
+				*tr = auth.TokenResponse{
+					Token:        "aaa-token-bbb",
+					RefreshToken: "aaa-refreshtoken-bbb",
+					Granted:      time.Now().AddDate(0, 0, -1),
+					ExpiresIn:    3600,
+				}
+				return nil
+			}
+
+			var body []byte
+			httpClient := &fake.FakeClient{}
+			httpClient.DoRequestOutStub = func(method, uri string, in, out interface{}) *errors.ApiError {
+				body, _ = json.Marshal(in)
+				*out.(*auth.TokenResponse) = auth.TokenResponse{Token: "aaa-new-token-bbb", Granted: time.Now(), ExpiresIn: 3600}
+				return nil
+			}
+
+			tr, apiErr := auth.NewAuthenticator(st, httpClient).GetToken()
+			assert.Nil(t, apiErr)
+			assert.Equal(t, "aaa-new-token-bbb", tr.Token)
+			assert.Equal(t, "token-jwt-tenantname-profilename", usedCacheKey)
+			assert.JSONEq(t, tt.body, string(body))
+		})
+	}
+
+	viper.Reset()
+	defer viper.Reset()
+	viper.Set(cst.AuthType, "jwt")
+	viper.Set(cst.AuthJwtFile, tokenFile)
+	_, apiErr := getAuthenticator(t).GetToken()
+	assert.ErrorContains(t, apiErr, "--auth.provider must be set")
+}
@@ -28,3 +28,13 @@ func ParseSubjectFromToken(accessToken string) (string, error) {
 	}
 	return claims.Subject, nil
 }
+
+// ParseClaimsFromToken returns all claims of the access token. The signature is not verified.
+func ParseClaimsFromToken(accessToken string) (map[string]interface{}, error) {
+	claims := jwt.MapClaims{}
+	parser := jwt.Parser{}
+	if _, _, err := parser.ParseUnverified(accessToken, claims); err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
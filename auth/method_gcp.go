@@ -10,6 +10,7 @@ import (
 	"net/http"
 
 	cst "github.com/DelineaXPM/dsv-cli/constants"
+	"github.com/DelineaXPM/dsv-cli/internal/httpclient"
 	"github.com/DelineaXPM/dsv-cli/paths"
 
 	"github.com/spf13/viper"
@@ -66,12 +67,12 @@ func (c *GcpClient) GetJwtToken(authType string) (string, error) {
 			serviceAcctName = gcpDefaultServiceAccName
 		}
 		tokenRequestURL := fmt.Sprintf(metadataIdentityTemplate, serviceAcctName, audience)
-		client := &http.Client{}
 		if req, err := http.NewRequest(http.MethodGet, tokenRequestURL, nil); err != nil {
 			errPrimary = err
 		} else {
 			req.Header.Add("Metadata-Flavor", "Google")
-			if resp, err := client.Do(req); err != nil {
+			// The metadata server is link-local, it must not be reached through a proxy.
+			if resp, err := httpclient.NewDirect().Do(req); err != nil {
 				errPrimary = err
 			} else {
 				return ParseMetadataIdentityResponse(resp)
@@ -84,7 +85,11 @@ func (c *GcpClient) GetJwtToken(authType string) (string, error) {
 		if errPrimary != nil {
 			log.Print("Failed auth with auth.gcp.type='gce'. Trying with auth.gcp.type='iam'")
 		}
-		ctx := context.Background()
+		client, err := httpclient.NewExternal()
+		if err != nil {
+			return "", err
+		}
+		ctx := context.WithValue(context.Background(), oauth2.HTTPClient, client)
 		scopes := []string{iam.CloudPlatformScope}
 		creds, err := google.FindDefaultCredentials(ctx, scopes...)
 		if err != nil || creds == nil {
@@ -0,0 +1,123 @@
+package auth
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	cst "github.com/DelineaXPM/dsv-cli/constants"
+	"github.com/DelineaXPM/dsv-cli/internal/httpclient"
+
+	"github.com/spf13/viper"
+)
+
+const (
+	gitlabTokenEnv    = "CI_JOB_JWT"
+	githubTokenURLEnv = "ACTIONS_ID_TOKEN_REQUEST_URL"
+	githubTokenEnv    = "ACTIONS_ID_TOKEN_REQUEST_TOKEN"
+)
+
+// kubernetesTokenFile is where Kubernetes mounts the service account token of a pod.
+var kubernetesTokenFile = "/var/run/secrets/kubernetes.io/serviceaccount/token" // trunk-ignore(golangci-lint/gochecknoglobals)
+
+func buildJwtParams() (*requestBody, error) {
+	token, err := readJwt()
+	if err != nil {
+		return nil, err
+	}
+	data := &requestBody{
+		GrantType: authTypeToGrantType[Jwt],
+		Provider:  viper.GetString(cst.AuthProvider),
+		Jwt:       token,
+	}
+	return data, nil
+}
+
+// readJwt reads the token from the file set with --auth-jwt-file or from the environment variable set
+// with --auth-jwt-env. Without either it uses the token of a GitLab CI job, requests a token in
+// GitHub Actions or reads the service account token in a Kubernetes pod.
+func readJwt() (string, error) {
+	if file := viper.GetString(cst.AuthJwtFile); file != "" {
+		return readJwtFile(file)
+	}
+	if env := viper.GetString(cst.AuthJwtEnv); env != "" {
+		token := strings.TrimSpace(os.Getenv(env))
+		if token == "" {
+			return "", fmt.Errorf("environment variable %s is not set", env)
+		}
+		return token, nil
+	}
+	if token := strings.TrimSpace(os.Getenv(gitlabTokenEnv)); token != "" {
+		return token, nil
+	}
+	if tokenURL := os.Getenv(githubTokenURLEnv); tokenURL != "" {
+		return getGithubActionsJwt(tokenURL, os.Getenv(githubTokenEnv))
+	}
+	if _, err := os.Stat(kubernetesTokenFile); err == nil {
+		return readJwtFile(kubernetesTokenFile)
+	}
+	return "", fmt.Errorf("--%s or --%s must be set",
+		strings.ReplaceAll(cst.AuthJwtFile, ".", "-"), strings.ReplaceAll(cst.AuthJwtEnv, ".", "-"))
+}
+
+func readJwtFile(file string) (string, error) {
+	b, err := os.ReadFile(file)
+	if err != nil {
+		return "", fmt.Errorf("read token file: %w", err)
+	}
+	token := strings.TrimSpace(string(b))
+	if token == "" {
+		return "", fmt.Errorf("token file %s is empty", file)
+	}
+	return token, nil
+}
+
+// getGithubActionsJwt requests an OIDC token for the tenant from GitHub Actions.
+// The workflow must have the "id-token: write" permission.
+func getGithubActionsJwt(tokenURL string, requestToken string) (string, error) {
+	u, err := url.Parse(tokenURL)
+	if err != nil {
+		return "", fmt.Errorf("invalid %s: %w", githubTokenURLEnv, err)
+	}
+	q := u.Query()
+	q.Set("audience", GetAudience())
+	u.RawQuery = q.Encode()
+
+	req, err := http.NewRequest(http.MethodGet, u.String(), nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Authorization", "Bearer "+requestToken)
+
+	client, err := httpclient.NewExternal()
+	if err != nil {
+		return "", err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("request GitHub Actions token: %w", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("read GitHub Actions token: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("request GitHub Actions token: %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+
+	var token struct {
+		Value string `json:"value"`
+	}
+	if err := json.Unmarshal(body, &token); err != nil {
+		return "", fmt.Errorf("parse GitHub Actions token: %w", err)
+	}
+	if token.Value == "" {
+		return "", fmt.Errorf("GitHub Actions returned an empty token")
+	}
+	return token.Value, nil
+}
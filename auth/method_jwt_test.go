@@ -0,0 +1,87 @@
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	cst "github.com/DelineaXPM/dsv-cli/constants"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadJwt(t *testing.T) {
+	dir := t.TempDir()
+	tokenFile := filepath.Join(dir, "token")
+	assert.NoError(t, os.WriteFile(tokenFile, []byte("file-token\n"), 0o600))
+	emptyFile := filepath.Join(dir, "empty")
+	assert.NoError(t, os.WriteFile(emptyFile, nil, 0o600))
+
+	github := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer request-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		assert.Equal(t, "https://mytenant.secretsvaultcloud.com", r.URL.Query().Get("audience"))
+		_, _ = w.Write([]byte(`{"value":"github-token"}`))
+	}))
+	defer github.Close()
+
+	defer func(file string) { kubernetesTokenFile = file }(kubernetesTokenFile)
+
+	testCases := []struct {
+		name    string
+		flags   map[string]string
+		env     map[string]string
+		k8sFile string
+		want    string
+		err     string
+	}{
+		{name: "file", flags: map[string]string{cst.AuthJwtFile: tokenFile}, env: map[string]string{"MY_JWT": "env-token"}, want: "file-token"},
+		{name: "empty file", flags: map[string]string{cst.AuthJwtFile: emptyFile}, err: "is empty"},
+		{name: "missing file", flags: map[string]string{cst.AuthJwtFile: filepath.Join(dir, "missing")}, err: "read token file"},
+		{name: "env", flags: map[string]string{cst.AuthJwtEnv: "MY_JWT"}, env: map[string]string{"MY_JWT": " env-token "}, want: "env-token"},
+		{name: "unset env", flags: map[string]string{cst.AuthJwtEnv: "MY_JWT"}, err: "environment variable MY_JWT is not set"},
+		{name: "gitlab", env: map[string]string{gitlabTokenEnv: "gitlab-token"}, k8sFile: tokenFile, want: "gitlab-token"},
+		{
+			name: "github",
+			env:  map[string]string{githubTokenURLEnv: github.URL + "/token?api-version=2.0", githubTokenEnv: "request-token"},
+			want: "github-token",
+		},
+		{
+			name: "github unauthorized",
+			env:  map[string]string{githubTokenURLEnv: github.URL, githubTokenEnv: "other"},
+			err:  "401 Unauthorized",
+		},
+		{name: "kubernetes", k8sFile: tokenFile, want: "file-token"},
+		{name: "no token", err: "--auth-jwt-file or --auth-jwt-env must be set"},
+	}
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			viper.Reset()
+			defer viper.Reset()
+			viper.Set(cst.Tenant, "mytenant")
+			for k, v := range tt.flags {
+				viper.Set(k, v)
+			}
+			for _, k := range []string{"MY_JWT", gitlabTokenEnv, githubTokenURLEnv, githubTokenEnv} {
+				t.Setenv(k, tt.env[k])
+			}
+			kubernetesTokenFile = filepath.Join(dir, "missing")
+			if tt.k8sFile != "" {
+				kubernetesTokenFile = tt.k8sFile
+			}
+
+			token, err := readJwt()
+			if tt.err != "" {
+				assert.ErrorContains(t, err, tt.err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, token)
+		})
+	}
+}
@@ -0,0 +1,200 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/DelineaXPM/dsv-cli/auth"
+	cst "github.com/DelineaXPM/dsv-cli/constants"
+	"github.com/DelineaXPM/dsv-cli/errors"
+	"github.com/DelineaXPM/dsv-cli/internal/agent"
+	"github.com/DelineaXPM/dsv-cli/internal/predictor"
+	"github.com/DelineaXPM/dsv-cli/internal/store"
+	"github.com/DelineaXPM/dsv-cli/paths"
+	"github.com/DelineaXPM/dsv-cli/vaultcli"
+
+	"github.com/mitchellh/cli"
+	"github.com/spf13/viper"
+)
+
+const (
+	// agentRefreshMargin is how long before expiration the agent refreshes its token. It is shorter
+	// than the leeway of the authenticator so that a cached token is considered expired at that time.
+	agentRefreshMargin = 5 * time.Second
+	// agentRetryDelay is how long the agent waits before the next attempt if the token refresh failed.
+	agentRetryDelay = 30 * time.Second
+)
+
+func GetAgentCmd() (cli.Command, error) {
+	return NewCommand(CommandArgs{
+		Path:         []string{cst.NounAgent},
+		SynopsisText: "Manage the local agent",
+		HelpText:     fmt.Sprintf("Execute an action on the local agent which serves %s reads over a Unix socket", cst.NounSecret),
+		NoConfigRead: true,
+		NoPreAuth:    true,
+	})
+}
+
+func GetAgentStartCmd() (cli.Command, error) {
+	return NewCommand(CommandArgs{
+		Path:         []string{cst.NounAgent, cst.Start},
+		SynopsisText: fmt.Sprintf("%s %s [--%s <path>]", cst.NounAgent, cst.Start, cst.Socket),
+		HelpText: fmt.Sprintf(`Start the local agent in the foreground
+
+The agent authenticates once, keeps its token refreshed in the background and serves %[1]s reads
+on a Unix socket accessible only by the current user. Secrets are cached in memory according to
+the cache.strategy and cache.age settings of the profile.
+
+Commands which read %[1]ss (%[1]s read, %[1]s describe and run) transparently use the agent when
+the %[2]s environment variable is set to the path of the socket. Such commands skip
+authentication, all other commands work as usual. The agent serves only reads for the tenant
+and the profile it was started with and rejects all others.
+
+The agent stops on SIGINT or SIGTERM.
+
+Usage:
+   • %[3]s %[4]s --%[5]s /tmp/dsv-agent.sock &
+     export %[2]s=/tmp/dsv-agent.sock
+     dsv %[1]s read %[6]s
+`, cst.NounSecret, cst.AgentSocketEnv, cst.NounAgent, cst.Start, cst.Socket, cst.ExamplePath),
+		FlagsPredictor: []*predictor.Params{
+			{Name: cst.Socket, Usage: fmt.Sprintf("Path to the Unix socket [default:$%s or %s in the CLI directory]", cst.AgentSocketEnv, cst.AgentSocketFile)},
+		},
+		NoPreAuth: true,
+		RunFuncE:  handleAgentStartCmd,
+	})
+}
+
+func handleAgentStartCmd(vcli vaultcli.CLI, args []string) error {
+	socket, err := agentSocketPath()
+	if err != nil {
+		return err
+	}
+	// The agent talks to DSV directly even if it is started from a shell which uses an agent.
+	os.Unsetenv(cst.AgentSocketEnv)
+
+	session := &agentSession{authenticator: vcli.Authenticator()}
+	refreshIn, apiErr := session.authenticate()
+	if apiErr != nil {
+		return apiErr
+	}
+
+	cacheAge := time.Duration(viper.GetInt(cst.CacheAge)) * time.Minute
+	base, err := url.Parse(paths.CreateURI("", nil))
+	if err != nil {
+		return err
+	}
+	identity := agent.Identity{Host: base.Host, Profile: viper.GetString(cst.Profile)}
+	handler := agent.NewServer(session.fetchFunc(vcli), identity, viper.GetString(cst.CacheStrategy), cacheAge)
+
+	ln, err := agent.Listen(socket)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", socket, err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go session.keepFresh(ctx, refreshIn)
+
+	server := &http.Server{Handler: handler, ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = server.Shutdown(shutdownCtx)
+	}()
+
+	fmt.Fprintf(os.Stderr, "Agent is listening on %s. To use it run:\n\texport %s=%s\n", socket, cst.AgentSocketEnv, socket)
+	if err := server.Serve(ln); err != nil && err != http.ErrServerClosed {
+		return err
+	}
+	fmt.Fprintln(os.Stderr, "Agent stopped.")
+	return nil
+}
+
+// agentSocketPath returns the path of the agent socket from the flag, the environment or the default location.
+func agentSocketPath() (string, error) {
+	if socket := viper.GetString(cst.Socket); socket != "" {
+		return filepath.Abs(socket)
+	}
+	if socket := os.Getenv(cst.AgentSocketEnv); socket != "" {
+		return filepath.Abs(socket)
+	}
+	dir, err := store.GetDefaultPath()
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, cst.AgentSocketFile), nil
+}
+
+// agentSession holds the token of the agent. Requests to DSV share the read lock,
+// the token is replaced under the write lock.
+type agentSession struct {
+	authenticator auth.Authenticator
+	mu            sync.RWMutex
+}
+
+// authenticate gets a token and returns the time after which it should be refreshed.
+func (s *agentSession) authenticate() (time.Duration, *errors.ApiError) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tr, apiErr := s.authenticator.GetToken()
+	if apiErr != nil {
+		return 0, apiErr
+	}
+	if tr == nil || tr.Token == "" {
+		return 0, errors.NewS("Failed to authenticate: empty token")
+	}
+	viper.Set(cst.NounToken, tr.Token)
+	return agentRefreshDelay(tr, time.Now()), nil
+}
+
+// keepFresh refreshes the token until the context is canceled.
+func (s *agentSession) keepFresh(ctx context.Context, refreshIn time.Duration) {
+	timer := time.NewTimer(refreshIn)
+	defer timer.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-timer.C:
+		}
+
+		d, apiErr := s.authenticate()
+		if apiErr != nil {
+			fmt.Fprintf(os.Stderr, "Failed to refresh token, retrying in %s: %v\n", agentRetryDelay, apiErr)
+			d = agentRetryDelay
+		}
+		timer.Reset(d)
+	}
+}
+
+func (s *agentSession) fetchFunc(vcli vaultcli.CLI) agent.FetchFunc {
+	return func(path string) ([]byte, *errors.ApiError) {
+		s.mu.RLock()
+		defer s.mu.RUnlock()
+		return vcli.HTTPClient().DoRequest(http.MethodGet, paths.CreateURI(path, nil), nil)
+	}
+}
+
+// agentRefreshDelay returns the time after which the token should be refreshed.
+func agentRefreshDelay(tr *auth.TokenResponse, now time.Time) time.Duration {
+	expires := tr.Granted.Add(time.Duration(tr.ExpiresIn) * time.Second)
+	d := expires.Sub(now) - agentRefreshMargin
+	if d < agentRefreshMargin {
+		d = agentRefreshMargin
+	}
+	return d
+}
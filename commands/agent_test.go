@@ -0,0 +1,77 @@
+package cmd
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/DelineaXPM/dsv-cli/auth"
+	cst "github.com/DelineaXPM/dsv-cli/constants"
+	"github.com/DelineaXPM/dsv-cli/errors"
+	"github.com/DelineaXPM/dsv-cli/tests/fake"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetAgentCmd(t *testing.T) {
+	_, err := GetAgentCmd()
+	assert.Nil(t, err)
+}
+
+func TestGetAgentStartCmd(t *testing.T) {
+	_, err := GetAgentStartCmd()
+	assert.Nil(t, err)
+}
+
+func TestAgentRefreshDelay(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	tr := &auth.TokenResponse{Granted: now.Add(-10 * time.Minute), ExpiresIn: 3600}
+	assert.Equal(t, 50*time.Minute-agentRefreshMargin, agentRefreshDelay(tr, now))
+
+	tr = &auth.TokenResponse{Granted: now.Add(-time.Hour), ExpiresIn: 3600}
+	assert.Equal(t, agentRefreshMargin, agentRefreshDelay(tr, now), "expired token must not cause a busy loop")
+}
+
+func TestAgentSocketPath(t *testing.T) {
+	viper.Reset()
+	defer viper.Reset()
+	dir := t.TempDir()
+
+	t.Setenv(cst.AgentSocketEnv, filepath.Join(dir, "env.sock"))
+	socket, err := agentSocketPath()
+	assert.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, "env.sock"), socket)
+
+	viper.Set(cst.Socket, filepath.Join(dir, "flag.sock"))
+	socket, err = agentSocketPath()
+	assert.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, "flag.sock"), socket)
+}
+
+func TestAgentSession(t *testing.T) {
+	viper.Reset()
+	defer viper.Reset()
+
+	tokens := []*auth.TokenResponse{
+		{Token: "first", ExpiresIn: 3600, Granted: time.Now()},
+		{Token: ""},
+	}
+	authenticator := &fake.FakeAuthenticator{}
+	authenticator.GetTokenStub = func() (*auth.TokenResponse, *errors.ApiError) {
+		tr := tokens[0]
+		tokens = tokens[1:]
+		return tr, nil
+	}
+	session := &agentSession{authenticator: authenticator}
+
+	d, apiErr := session.authenticate()
+	assert.Nil(t, apiErr)
+	assert.Equal(t, "first", viper.GetString(cst.NounToken))
+	assert.True(t, d > 59*time.Minute)
+
+	_, apiErr = session.authenticate()
+	assert.NotNil(t, apiErr)
+	assert.Equal(t, "first", viper.GetString(cst.NounToken), "token must be kept if refresh failed")
+}
@@ -0,0 +1,810 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	cst "github.com/DelineaXPM/dsv-cli/constants"
+	"github.com/DelineaXPM/dsv-cli/errors"
+	"github.com/DelineaXPM/dsv-cli/internal/predictor"
+	"github.com/DelineaXPM/dsv-cli/paths"
+	"github.com/DelineaXPM/dsv-cli/utils"
+	"github.com/DelineaXPM/dsv-cli/vaultcli"
+
+	"github.com/AlecAivazis/survey/v2"
+	"github.com/mitchellh/cli"
+	"github.com/spf13/viper"
+	"golang.org/x/term"
+	"gopkg.in/yaml.v3"
+)
+
+const applyDesiredStateHelp = `The file contains one or more YAML documents separated by "---". Each document describes one resource:
+   kind:  Secret | Policy | Role | Group | Client | Pool | Engine | Siem | AuthProvider
+   name:  name of the resource (for Secret and Policy "path" can be used instead)
+   state: present (default) | absent
+   spec:  desired fields of the resource, as accepted by the API
+
+Only fields listed in the spec are compared with the live state. Secret data and group members are compared exactly.
+For a Client the name is used as the description of the client and spec.role is required.
+
+Example:
+   kind: Secret
+   path: prod/db
+   spec:
+     description: Production database
+     data:
+       password: s3cr3t
+   ---
+   kind: Group
+   name: developers
+   spec:
+     members: [alice, bob]
+   ---
+   kind: Pool
+   name: legacy-pool
+   state: absent
+`
+
+func GetApplyCmd() (cli.Command, error) {
+	return NewCommand(CommandArgs{
+		Path:         []string{cst.Apply},
+		SynopsisText: fmt.Sprintf("%s (<file> | --file <file>) [--auto-approve]", cst.Apply),
+		HelpText: fmt.Sprintf(`Create, update and delete resources to match the desired state described in a file
+
+%s
+Resources are changed in the following order: %s. Deletes are done last, in reverse order.
+If the plan contains errors nothing is changed.
+
+The plan is printed first and the changes are made once they are confirmed. Use --auto-approve
+to skip the confirmation, which is required when the input is not a terminal. After the changes
+are made the plan is printed again with the result of every change.
+
+Usage:
+   • %[3]s desired.yaml
+   • %[3]s --file desired.yaml --auto-approve
+`, applyDesiredStateHelp, strings.Join(applyKindOrder, ", "), cst.Apply),
+		FlagsPredictor: []*predictor.Params{
+			{Name: cst.File, Usage: "Path to the file with the desired state (required)", Predictor: predictor.NewPrefixFilePredictor("*")},
+			{Name: cst.AutoApprove, Usage: "Make the changes without asking for confirmation", ValueType: "bool"},
+		},
+		MinNumberArgs: 1,
+		RunFunc:       handleApplyCmd,
+	})
+}
+
+func GetPlanCmd() (cli.Command, error) {
+	return NewCommand(CommandArgs{
+		Path:         []string{cst.Plan},
+		SynopsisText: fmt.Sprintf("%s (<file> | --file <file>)", cst.Plan),
+		HelpText: fmt.Sprintf(`Show changes required to match the desired state described in a file
+
+%s
+Exit status is 0 if there are no changes, 2 if the live state drifted from the desired state and 1 on errors.
+
+Usage:
+   • %[2]s desired.yaml
+   • %[2]s --file desired.yaml
+`, applyDesiredStateHelp, cst.Plan),
+		FlagsPredictor: []*predictor.Params{
+			{Name: cst.File, Usage: "Path to the file with the desired state (required)", Predictor: predictor.NewPrefixFilePredictor("*")},
+		},
+		MinNumberArgs: 1,
+		RunFunc:       handlePlanCmd,
+	})
+}
+
+func handlePlanCmd(vcli vaultcli.CLI, args []string) int {
+	docs, err := readApplyDocuments(args)
+	if err != nil {
+		vcli.Out().Fail(err)
+		return 1
+	}
+
+	plan := applyPlan(vcli, docs)
+	vcli.Out().WriteResponse(plan.report(), nil)
+	switch {
+	case plan.failed():
+		return 1
+	case plan.drifted():
+		return 2
+	default:
+		return 0
+	}
+}
+
+func handleApplyCmd(vcli vaultcli.CLI, args []string) int {
+	docs, err := readApplyDocuments(args)
+	if err != nil {
+		vcli.Out().Fail(err)
+		return 1
+	}
+
+	plan := applyPlan(vcli, docs)
+	if plan.failed() {
+		vcli.Out().WriteResponse(plan.report(), errors.NewS("error: the plan contains errors, nothing was changed"))
+		return 1
+	}
+
+	vcli.Out().WriteResponse(plan.report(), nil)
+	if !plan.drifted() {
+		return 0
+	}
+	if !viper.GetBool(cst.AutoApprove) {
+		if !term.IsTerminal(int(os.Stdin.Fd())) {
+			vcli.Out().FailF("error: nothing was changed, use --%s to apply the plan without confirmation", cst.AutoApprove)
+			return 1
+		}
+		var yes bool
+		confirmPrompt := &survey.Confirm{Message: "Do you want to make these changes?", Default: false}
+		if survErr := survey.AskOne(confirmPrompt, &yes); survErr != nil {
+			vcli.Out().WriteResponse(nil, errors.New(survErr))
+			return utils.GetExecStatus(survErr)
+		}
+		if !yes {
+			vcli.Out().WriteResponse([]byte("Exiting."), nil)
+			return 0
+		}
+	}
+
+	plan.apply(vcli)
+	vcli.Out().WriteResponse(plan.report(), nil)
+	if plan.failed() {
+		return 1
+	}
+	return 0
+}
+
+func readApplyDocuments(args []string) ([]*applyDocument, error) {
+	file := viper.GetString(cst.File)
+	if file == "" && len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		file = args[0]
+	}
+	if file == "" {
+		return nil, errors.NewF("error: must specify --%s", cst.File)
+	}
+	raw, err := os.ReadFile(file)
+	if err != nil {
+		return nil, errors.New(err).Grow("Failed to read the desired state")
+	}
+	return parseApplyDocuments(raw)
+}
+
+const (
+	applyActionCreate = "create"
+	applyActionUpdate = "update"
+	applyActionDelete = "delete"
+	applyActionNone   = "none"
+)
+
+type applyDocument struct {
+	Kind  string                 `yaml:"kind"`
+	Name  string                 `yaml:"name"`
+	Path  string                 `yaml:"path"`
+	State string                 `yaml:"state"`
+	Spec  map[string]interface{} `yaml:"spec"`
+}
+
+func (d *applyDocument) id() string {
+	if d.Name != "" {
+		return d.Name
+	}
+	return d.Path
+}
+
+func (d *applyDocument) absent() bool {
+	return d.State == "absent"
+}
+
+func parseApplyDocuments(raw []byte) ([]*applyDocument, error) {
+	var docs []*applyDocument
+	seen := make(map[string]bool)
+	dec := yaml.NewDecoder(bytes.NewReader(raw))
+	for i := 1; ; i++ {
+		doc := &applyDocument{}
+		err := dec.Decode(doc)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, errors.New(err).Grow(fmt.Sprintf("Failed to parse document %d", i))
+		}
+		if doc.Kind == "" && doc.id() == "" && len(doc.Spec) == 0 {
+			// Empty document.
+			continue
+		}
+
+		if _, ok := applyKinds[doc.Kind]; !ok {
+			return nil, errors.NewF("error: document %d: unsupported kind %q, supported kinds are: %s",
+				i, doc.Kind, strings.Join(applyKindOrder, ", "))
+		}
+		if doc.id() == "" {
+			return nil, errors.NewF("error: document %d: %s must have a name", i, doc.Kind)
+		}
+		if doc.State != "" && doc.State != "present" && doc.State != "absent" {
+			return nil, errors.NewF("error: document %d: state must be either present or absent", i)
+		}
+		if doc.Kind == "Client" && applySpecString(doc.Spec, "role") == "" {
+			return nil, errors.NewF("error: document %d: Client must have spec.role", i)
+		}
+
+		// Round trip through JSON to get the same types as in API responses.
+		if err := applyConvert(doc.Spec, &doc.Spec); err != nil {
+			return nil, errors.New(err).Grow(fmt.Sprintf("Failed to parse spec in document %d", i))
+		}
+
+		key := doc.Kind + " " + doc.id()
+		if seen[key] {
+			return nil, errors.NewF("error: document %d: %s %q is described more than once", i, doc.Kind, doc.id())
+		}
+		seen[key] = true
+		docs = append(docs, doc)
+	}
+	if len(docs) == 0 {
+		return nil, errors.NewS("error: no resources found in the desired state")
+	}
+	return docs, nil
+}
+
+type applyChange struct {
+	Kind     string          `json:"kind"`
+	Name     string          `json:"name"`
+	Action   string          `json:"action"`
+	Fields   []string        `json:"fields,omitempty"`
+	Error    string          `json:"error,omitempty"`
+	Response json.RawMessage `json:"response,omitempty"`
+
+	doc  *applyDocument
+	live map[string]interface{}
+}
+
+type applyPlanResult struct {
+	changes []*applyChange
+}
+
+func applyPlan(vcli vaultcli.CLI, docs []*applyDocument) *applyPlanResult {
+	plan := &applyPlanResult{}
+	for _, doc := range docs {
+		kind := applyKinds[doc.Kind]
+		ch := &applyChange{Kind: doc.Kind, Name: doc.id(), Action: applyActionNone, doc: doc}
+		plan.changes = append(plan.changes, ch)
+
+		live, apiErr := kind.read(vcli, doc)
+		if apiErr != nil {
+			ch.Error = apiErr.Error()
+			continue
+		}
+		ch.live = live
+
+		switch {
+		case doc.absent() && live == nil:
+		case doc.absent():
+			ch.Action = applyActionDelete
+		case live == nil:
+			ch.Action = applyActionCreate
+		default:
+			ch.Fields = applyDiff(kind, doc.Spec, live)
+			if len(ch.Fields) == 0 {
+				break
+			}
+			ch.Action = applyActionUpdate
+			if kind.update == nil {
+				ch.Error = fmt.Sprintf("%s cannot be updated, delete it first", doc.Kind)
+			} else if immutable := applyIntersect(ch.Fields, kind.immutable); len(immutable) > 0 {
+				ch.Error = fmt.Sprintf("%s cannot be changed, delete the %s first", strings.Join(immutable, ", "), doc.Kind)
+			}
+		}
+	}
+
+	// Create and update in dependency order, delete in reverse order after everything else.
+	sort.SliceStable(plan.changes, func(i, j int) bool {
+		a, b := plan.changes[i], plan.changes[j]
+		aDel, bDel := a.Action == applyActionDelete, b.Action == applyActionDelete
+		if aDel != bDel {
+			return bDel
+		}
+		if aDel {
+			return applyKindRank(a.Kind) > applyKindRank(b.Kind)
+		}
+		return applyKindRank(a.Kind) < applyKindRank(b.Kind)
+	})
+	return plan
+}
+
+func (p *applyPlanResult) apply(vcli vaultcli.CLI) {
+	for _, ch := range p.changes {
+		kind := applyKinds[ch.Kind]
+		var resp []byte
+		var apiErr *errors.ApiError
+		switch ch.Action {
+		case applyActionCreate:
+			resp, apiErr = kind.create(vcli, ch.doc)
+		case applyActionUpdate:
+			resp, apiErr = kind.update(vcli, ch.doc, ch.live)
+		case applyActionDelete:
+			resp, apiErr = kind.delete(vcli, ch.doc, ch.live)
+		default:
+			continue
+		}
+		if apiErr != nil {
+			ch.Error = apiErr.Error()
+		} else if kind.showResponse && json.Valid(resp) {
+			ch.Response = resp
+		}
+	}
+}
+
+func (p *applyPlanResult) drifted() bool {
+	for _, ch := range p.changes {
+		if ch.Action != applyActionNone {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *applyPlanResult) failed() bool {
+	for _, ch := range p.changes {
+		if ch.Error != "" {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *applyPlanResult) report() []byte {
+	summary := map[string]int{applyActionCreate: 0, applyActionUpdate: 0, applyActionDelete: 0, "unchanged": 0, "errors": 0}
+	changes := make([]*applyChange, 0, len(p.changes))
+	for _, ch := range p.changes {
+		if ch.Error != "" {
+			summary["errors"]++
+		}
+		if ch.Action == applyActionNone {
+			summary["unchanged"]++
+			if ch.Error == "" {
+				continue
+			}
+		} else {
+			summary[ch.Action]++
+		}
+		changes = append(changes, ch)
+	}
+	data, _ := json.Marshal(map[string]interface{}{
+		"changes": changes,
+		"summary": summary,
+	})
+	return data
+}
+
+// applyDiff returns sorted names of fields from the desired spec which differ from the live state.
+func applyDiff(kind *applyKind, spec map[string]interface{}, live map[string]interface{}) []string {
+	if kind.normalize != nil {
+		spec, live = kind.normalize(spec), kind.normalize(live)
+	}
+	var fields []string
+	for key, want := range spec {
+		if applyContains(kind.writeOnly, key) {
+			continue
+		}
+		got := live[key]
+		var equal bool
+		if applyContains(kind.exact, key) {
+			equal = reflect.DeepEqual(want, got)
+		} else {
+			equal = applySubset(want, got)
+		}
+		if !equal {
+			fields = append(fields, key)
+		}
+	}
+	sort.Strings(fields)
+	return fields
+}
+
+// applySubset reports whether every value in want is present in got.
+// Objects may have additional keys in got, arrays must have the same length.
+func applySubset(want, got interface{}) bool {
+	switch w := want.(type) {
+	case map[string]interface{}:
+		g, ok := got.(map[string]interface{})
+		if !ok {
+			return len(w) == 0 && got == nil
+		}
+		for k, v := range w {
+			if !applySubset(v, g[k]) {
+				return false
+			}
+		}
+		return true
+	case []interface{}:
+		g, ok := got.([]interface{})
+		if !ok {
+			return len(w) == 0 && got == nil
+		}
+		if len(w) != len(g) {
+			return false
+		}
+		for i := range w {
+			if !applySubset(w[i], g[i]) {
+				return false
+			}
+		}
+		return true
+	case nil:
+		return got == nil
+	default:
+		return reflect.DeepEqual(want, got)
+	}
+}
+
+// Kinds:
+
+type applyKind struct {
+	// read returns nil without an error if the resource does not exist.
+	read   func(vcli vaultcli.CLI, doc *applyDocument) (map[string]interface{}, *errors.ApiError)
+	create func(vcli vaultcli.CLI, doc *applyDocument) ([]byte, *errors.ApiError)
+	// update is nil if the resource cannot be updated.
+	update func(vcli vaultcli.CLI, doc *applyDocument, live map[string]interface{}) ([]byte, *errors.ApiError)
+	delete func(vcli vaultcli.CLI, doc *applyDocument, live map[string]interface{}) ([]byte, *errors.ApiError)
+	// normalize converts both the spec and the live state before comparison.
+	normalize func(m map[string]interface{}) map[string]interface{}
+
+	exact        []string // fields compared exactly rather than as a subset
+	immutable    []string // fields which cannot be updated
+	writeOnly    []string // fields which are not returned by the API
+	showResponse bool     // include the API response in the report, e.g. to show generated credentials
+}
+
+// applyKindOrder defines the order in which resources are created and updated.
+var applyKindOrder = []string{"Pool", "Engine", "Siem", "AuthProvider", "Role", "Group", "Policy", "Secret", "Client"}
+
+func applyKindRank(kind string) int {
+	for i, k := range applyKindOrder {
+		if k == kind {
+			return i
+		}
+	}
+	return len(applyKindOrder)
+}
+
+var applyKinds = map[string]*applyKind{
+	"Secret": {
+		read: func(vcli vaultcli.CLI, doc *applyDocument) (map[string]interface{}, *errors.ApiError) {
+			return applyReadResult(getSecretFromServer(vcli, cst.NounSecret, doc.id(), "", false, ""))
+		},
+		create: func(vcli vaultcli.CLI, doc *applyDocument) ([]byte, *errors.ApiError) {
+			return applySecretUpsert(vcli, cst.Create, doc)
+		},
+		update: func(vcli vaultcli.CLI, doc *applyDocument, _ map[string]interface{}) ([]byte, *errors.ApiError) {
+			return applySecretUpsert(vcli, cst.Update, doc)
+		},
+		delete: func(vcli vaultcli.CLI, doc *applyDocument, _ map[string]interface{}) ([]byte, *errors.ApiError) {
+			query := map[string]string{"force": strconv.FormatBool(false)}
+			uri, apiErr := paths.GetResourceURIFromResourcePath(cst.NounSecrets, doc.id(), "", "", query)
+			if apiErr != nil {
+				return nil, apiErr
+			}
+			return vcli.HTTPClient().DoRequest(http.MethodDelete, uri, nil)
+		},
+		exact: []string{"data"},
+	},
+	"Policy": {
+		read: func(vcli vaultcli.CLI, doc *applyDocument) (map[string]interface{}, *errors.ApiError) {
+			return applyReadResult(policyRead(vcli, paths.ProcessResource(doc.id())))
+		},
+		create: func(vcli vaultcli.CLI, doc *applyDocument) ([]byte, *errors.ApiError) {
+			policy, err := json.Marshal(doc.Spec)
+			if err != nil {
+				return nil, errors.New(err)
+			}
+			return policyCreate(vcli, &policyCreateRequest{Path: doc.id(), Policy: string(policy), Serialization: cst.Json})
+		},
+		update: func(vcli vaultcli.CLI, doc *applyDocument, _ map[string]interface{}) ([]byte, *errors.ApiError) {
+			policy, err := json.Marshal(doc.Spec)
+			if err != nil {
+				return nil, errors.New(err)
+			}
+			return policyUpdate(vcli, paths.ProcessResource(doc.id()), &policyUpdateRequest{Policy: string(policy), Serialization: cst.Json})
+		},
+		delete: func(vcli vaultcli.CLI, doc *applyDocument, _ map[string]interface{}) ([]byte, *errors.ApiError) {
+			return policyDelete(vcli, paths.ProcessResource(doc.id()), false)
+		},
+	},
+	"Role": {
+		read: func(vcli vaultcli.CLI, doc *applyDocument) (map[string]interface{}, *errors.ApiError) {
+			return applyReadResult(roleRead(vcli, doc.id()))
+		},
+		create: func(vcli vaultcli.CLI, doc *applyDocument) ([]byte, *errors.ApiError) {
+			body := &roleCreateRequest{}
+			if err := applyConvert(doc.Spec, body); err != nil {
+				return nil, errors.New(err)
+			}
+			body.Name = doc.id()
+			return roleCreate(vcli, body)
+		},
+		update: func(vcli vaultcli.CLI, doc *applyDocument, _ map[string]interface{}) ([]byte, *errors.ApiError) {
+			return roleUpdate(vcli, doc.id(), applySpecString(doc.Spec, "description"))
+		},
+		delete: func(vcli vaultcli.CLI, doc *applyDocument, _ map[string]interface{}) ([]byte, *errors.ApiError) {
+			return roleDelete(vcli, doc.id(), false)
+		},
+		immutable: []string{"provider", "externalId"},
+	},
+	"Group": {
+		read: func(vcli vaultcli.CLI, doc *applyDocument) (map[string]interface{}, *errors.ApiError) {
+			return applyReadResult(groupRead(vcli, paths.ProcessResource(doc.id())))
+		},
+		create: func(vcli vaultcli.CLI, doc *applyDocument) ([]byte, *errors.ApiError) {
+			return groupCreate(vcli, doc.id(), applyGroupMembers(doc.Spec["members"]))
+		},
+		update: func(vcli vaultcli.CLI, doc *applyDocument, live map[string]interface{}) ([]byte, *errors.ApiError) {
+			name := paths.ProcessResource(doc.id())
+			want := applyGroupMembers(doc.Spec["members"])
+			got := applyGroupMembers(live["members"])
+			var resp []byte
+			if add := applyDifference(want, got); len(add) > 0 {
+				var apiErr *errors.ApiError
+				if resp, apiErr = groupAddMembers(vcli, name, add); apiErr != nil {
+					return nil, apiErr
+				}
+			}
+			if del := applyDifference(got, want); len(del) > 0 {
+				var apiErr *errors.ApiError
+				if resp, apiErr = groupDelMembers(vcli, name, del); apiErr != nil {
+					return nil, apiErr
+				}
+			}
+			return resp, nil
+		},
+		delete: func(vcli vaultcli.CLI, doc *applyDocument, _ map[string]interface{}) ([]byte, *errors.ApiError) {
+			return groupDelete(vcli, paths.ProcessResource(doc.id()), false)
+		},
+		normalize: func(m map[string]interface{}) map[string]interface{} {
+			if members, ok := m["members"]; ok {
+				out := make(map[string]interface{}, len(m))
+				for k, v := range m {
+					out[k] = v
+				}
+				names := []interface{}{}
+				for _, name := range applyGroupMembers(members) {
+					names = append(names, name)
+				}
+				out["members"] = names
+				return out
+			}
+			return m
+		},
+		exact: []string{"members"},
+	},
+	"Client": {
+		read: func(vcli vaultcli.CLI, doc *applyDocument) (map[string]interface{}, *errors.ApiError) {
+			data, apiErr := clientSearch(vcli, &clientSearchParams{role: applySpecString(doc.Spec, "role"), limit: "100"})
+			if apiErr != nil {
+				return nil, apiErr
+			}
+			resp := struct {
+				Data []map[string]interface{} `json:"data"`
+			}{}
+			if err := json.Unmarshal(data, &resp); err != nil {
+				return nil, errors.New(err)
+			}
+			for _, client := range resp.Data {
+				if client["description"] == doc.id() {
+					return client, nil
+				}
+			}
+			return nil, nil
+		},
+		create: func(vcli vaultcli.CLI, doc *applyDocument) ([]byte, *errors.ApiError) {
+			body := &clientCreateRequest{}
+			if err := applyConvert(doc.Spec, body); err != nil {
+				return nil, errors.New(err)
+			}
+			body.Description = doc.id()
+			return clientCreate(vcli, body)
+		},
+		delete: func(vcli vaultcli.CLI, doc *applyDocument, live map[string]interface{}) ([]byte, *errors.ApiError) {
+			clientID, _ := live["clientId"].(string)
+			return clientDelete(vcli, clientID, false)
+		},
+		writeOnly:    []string{"url", "urlTTL", "ttl", "usesLimit"},
+		showResponse: true,
+	},
+	"Pool": {
+		read: func(vcli vaultcli.CLI, doc *applyDocument) (map[string]interface{}, *errors.ApiError) {
+			return applyReadResult(poolRead(vcli, doc.id()))
+		},
+		create: func(vcli vaultcli.CLI, doc *applyDocument) ([]byte, *errors.ApiError) {
+			return poolCreate(vcli, doc.id())
+		},
+		delete: func(vcli vaultcli.CLI, doc *applyDocument, _ map[string]interface{}) ([]byte, *errors.ApiError) {
+			return poolDelete(vcli, doc.id())
+		},
+	},
+	"Engine": {
+		read: func(vcli vaultcli.CLI, doc *applyDocument) (map[string]interface{}, *errors.ApiError) {
+			return applyReadResult(engineRead(vcli, doc.id()))
+		},
+		create: func(vcli vaultcli.CLI, doc *applyDocument) ([]byte, *errors.ApiError) {
+			return engineCreate(vcli, doc.id(), applySpecString(doc.Spec, "poolName"))
+		},
+		delete: func(vcli vaultcli.CLI, doc *applyDocument, _ map[string]interface{}) ([]byte, *errors.ApiError) {
+			return engineDelete(vcli, doc.id())
+		},
+	},
+	"Siem": {
+		read: func(vcli vaultcli.CLI, doc *applyDocument) (map[string]interface{}, *errors.ApiError) {
+			return applyReadResult(siemRead(vcli, doc.id()))
+		},
+		create: func(vcli vaultcli.CLI, doc *applyDocument) ([]byte, *errors.ApiError) {
+			body := &siemCreateRequest{}
+			if err := applyConvert(doc.Spec, body); err != nil {
+				return nil, errors.New(err)
+			}
+			body.Name = doc.id()
+			return siemCreate(vcli, body)
+		},
+		update: func(vcli vaultcli.CLI, doc *applyDocument, live map[string]interface{}) ([]byte, *errors.ApiError) {
+			// The whole SIEM endpoint is replaced, so start from the live state.
+			body := &siemUpdateRequest{}
+			if err := applyConvert(live, body); err != nil {
+				return nil, errors.New(err)
+			}
+			if err := applyConvert(doc.Spec, body); err != nil {
+				return nil, errors.New(err)
+			}
+			return siemUpdate(vcli, doc.id(), body)
+		},
+		delete: func(vcli vaultcli.CLI, doc *applyDocument, _ map[string]interface{}) ([]byte, *errors.ApiError) {
+			return siemDelete(vcli, doc.id())
+		},
+		writeOnly: []string{"auth"},
+	},
+	"AuthProvider": {
+		read: func(vcli vaultcli.CLI, doc *applyDocument) (map[string]interface{}, *errors.ApiError) {
+			return applyReadResult(authProviderRead(vcli, paths.ProcessResource(doc.id())))
+		},
+		create: func(vcli vaultcli.CLI, doc *applyDocument) ([]byte, *errors.ApiError) {
+			body := &authProviderCreateRequest{}
+			if err := applyConvert(doc.Spec, body); err != nil {
+				return nil, errors.New(err)
+			}
+			body.Name = doc.id()
+			return authProviderCreate(vcli, body)
+		},
+		update: func(vcli vaultcli.CLI, doc *applyDocument, live map[string]interface{}) ([]byte, *errors.ApiError) {
+			body := &authProviderUpdateRequest{}
+			if err := applyConvert(live, body); err != nil {
+				return nil, errors.New(err)
+			}
+			if err := applyConvert(doc.Spec, body); err != nil {
+				return nil, errors.New(err)
+			}
+			return authProviderUpdate(vcli, paths.ProcessResource(doc.id()), body)
+		},
+		delete: func(vcli vaultcli.CLI, doc *applyDocument, _ map[string]interface{}) ([]byte, *errors.ApiError) {
+			return authProviderDelete(vcli, paths.ProcessResource(doc.id()), false)
+		},
+		normalize: func(m map[string]interface{}) map[string]interface{} {
+			// Credentials of an authentication provider are not returned by the API.
+			props, ok := m["properties"].(map[string]interface{})
+			if !ok {
+				return m
+			}
+			out := make(map[string]interface{}, len(m))
+			for k, v := range m {
+				out[k] = v
+			}
+			cleaned := make(map[string]interface{}, len(props))
+			for k, v := range props {
+				if k != "clientSecret" && k != "privateKey" {
+					cleaned[k] = v
+				}
+			}
+			out["properties"] = cleaned
+			return out
+		},
+		immutable: []string{"type"},
+	},
+}
+
+// Helpers:
+
+// applyReadResult converts a response of a read request to a map. Missing resources are reported as nil.
+func applyReadResult(data []byte, apiErr *errors.ApiError) (map[string]interface{}, *errors.ApiError) {
+	if apiErr != nil {
+		if resp := apiErr.HttpResponse(); resp != nil && resp.StatusCode == http.StatusNotFound {
+			return nil, nil
+		}
+		return nil, apiErr
+	}
+	live := make(map[string]interface{})
+	if err := json.Unmarshal(data, &live); err != nil {
+		return nil, errors.New(err).Grow("Failed to parse the live state")
+	}
+	return live, nil
+}
+
+func applySecretUpsert(vcli vaultcli.CLI, action string, doc *applyDocument) ([]byte, *errors.ApiError) {
+	uri, apiErr := paths.GetResourceURIFromResourcePath(cst.NounSecrets, doc.id(), "", "", nil)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	body := &secretUpsertBody{Overwrite: true}
+	body.Data, _ = doc.Spec["data"].(map[string]interface{})
+	body.Attributes, _ = doc.Spec["attributes"].(map[string]interface{})
+	body.Description = applySpecString(doc.Spec, "description")
+	return secretUpsert(vcli, action, uri, body)
+}
+
+// applyConvert copies fields from one value to another by encoding it to JSON and back.
+func applyConvert(from interface{}, to interface{}) error {
+	data, err := json.Marshal(from)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, to)
+}
+
+func applySpecString(spec map[string]interface{}, key string) string {
+	s, _ := spec[key].(string)
+	return s
+}
+
+// applyGroupMembers returns sorted names of group members. Members can be listed as names or as objects with a name.
+func applyGroupMembers(v interface{}) []string {
+	list, _ := v.([]interface{})
+	names := make([]string, 0, len(list))
+	for _, m := range list {
+		switch member := m.(type) {
+		case string:
+			names = append(names, member)
+		case map[string]interface{}:
+			for _, key := range []string{"name", "memberName", "username"} {
+				if name, ok := member[key].(string); ok && name != "" {
+					names = append(names, name)
+					break
+				}
+			}
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// applyDifference returns elements of a which are not in b.
+func applyDifference(a, b []string) []string {
+	var diff []string
+	for _, s := range a {
+		if !applyContains(b, s) {
+			diff = append(diff, s)
+		}
+	}
+	return diff
+}
+
+func applyIntersect(a, b []string) []string {
+	var common []string
+	for _, s := range a {
+		if applyContains(b, s) {
+			common = append(common, s)
+		}
+	}
+	return common
+}
+
+func applyContains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,264 @@
+package cmd
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	cst "github.com/DelineaXPM/dsv-cli/constants"
+	"github.com/DelineaXPM/dsv-cli/errors"
+	"github.com/DelineaXPM/dsv-cli/tests/fake"
+	"github.com/DelineaXPM/dsv-cli/vaultcli"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetApplyCmd(t *testing.T) {
+	_, err := GetApplyCmd()
+	assert.Nil(t, err)
+}
+
+func TestGetPlanCmd(t *testing.T) {
+	_, err := GetPlanCmd()
+	assert.Nil(t, err)
+}
+
+func TestParseApplyDocuments(t *testing.T) {
+	docs, err := parseApplyDocuments([]byte(`
+kind: Secret
+path: prod/db
+spec:
+  data:
+    port: 5432
+---
+---
+kind: Pool
+name: legacy
+state: absent
+`))
+	assert.NoError(t, err)
+	if assert.Len(t, docs, 2) {
+		assert.Equal(t, "prod/db", docs[0].id())
+		assert.Equal(t, map[string]interface{}{"port": float64(5432)}, docs[0].Spec["data"])
+		assert.True(t, docs[1].absent())
+	}
+
+	testCases := []struct {
+		name string
+		raw  string
+		err  string
+	}{
+		{"empty", "---\n", "no resources found"},
+		{"unknown kind", "kind: Foo\nname: a\n", `unsupported kind "Foo"`},
+		{"no name", "kind: Role\n", "Role must have a name"},
+		{"bad state", "kind: Role\nname: a\nstate: gone\n", "state must be"},
+		{"client without role", "kind: Client\nname: ci\n", "spec.role"},
+		{"duplicate", "kind: Role\nname: a\n---\nkind: Role\nname: a\n", "more than once"},
+		{"invalid yaml", "kind: [\n", "Failed to parse document 1"},
+	}
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseApplyDocuments([]byte(tt.raw))
+			if assert.Error(t, err) {
+				assert.Contains(t, err.Error(), tt.err)
+			}
+		})
+	}
+}
+
+func TestApplyDiff(t *testing.T) {
+	live := map[string]interface{}{
+		"description": "db",
+		"attributes":  map[string]interface{}{"ttl": float64(60), "owner": "ops"},
+		"data":        map[string]interface{}{"password": "p@ss", "extra": "x"},
+		"members":     []interface{}{map[string]interface{}{"name": "bob"}, "alice"},
+		"auth":        nil,
+	}
+
+	kind := &applyKind{exact: []string{"data"}, writeOnly: []string{"auth"}}
+	assert.Empty(t, applyDiff(kind, map[string]interface{}{
+		"description": "db",
+		"attributes":  map[string]interface{}{"ttl": float64(60)},
+		"auth":        "token",
+	}, live))
+	assert.Equal(t, []string{"attributes", "data"}, applyDiff(kind, map[string]interface{}{
+		"attributes": map[string]interface{}{"ttl": float64(30)},
+		"data":       map[string]interface{}{"password": "p@ss"},
+	}, live))
+
+	group := applyKinds["Group"]
+	assert.Empty(t, applyDiff(group, map[string]interface{}{"members": []interface{}{"bob", "alice"}}, live))
+	assert.Equal(t, []string{"members"}, applyDiff(group, map[string]interface{}{"members": []interface{}{"bob"}}, live))
+}
+
+// applyTestServer is a fake API which stores resources by URI path.
+type applyTestServer struct {
+	resources map[string]string
+	requests  []string
+}
+
+func (s *applyTestServer) client() *fake.FakeClient {
+	httpClient := &fake.FakeClient{}
+	httpClient.DoRequestStub = func(method string, uri string, body interface{}) ([]byte, *errors.ApiError) {
+		path := uri[strings.Index(uri, "/v1/")+3:]
+		if i := strings.Index(path, "?"); i >= 0 {
+			path = path[:i]
+		}
+		if method != http.MethodGet {
+			b, _ := json.Marshal(body)
+			s.requests = append(s.requests, method+" "+path+" "+string(b))
+			return []byte(`{}`), nil
+		}
+		if resp, ok := s.resources[path]; ok {
+			return []byte(resp), nil
+		}
+		return nil, errors.NewS("not found").WithResponse(&http.Response{StatusCode: http.StatusNotFound, Status: "404 Not Found"})
+	}
+	return httpClient
+}
+
+const applyTestState = `
+kind: Secret
+path: prod/db
+spec:
+  data:
+    password: new
+---
+kind: Secret
+path: prod/api
+spec:
+  data:
+    token: abc
+---
+kind: Role
+name: app
+spec:
+  description: Application
+---
+kind: Pool
+name: legacy
+state: absent
+---
+kind: Group
+name: devs
+spec:
+  members: [alice, bob]
+`
+
+func newApplyTestCLI(t *testing.T, s *applyTestServer, out *[]byte, outErr **errors.ApiError) vaultcli.CLI {
+	t.Helper()
+	outClient := &fake.FakeOutClient{}
+	outClient.WriteResponseStub = func(data []byte, apiError *errors.ApiError) {
+		*out = data
+		*outErr = apiError
+	}
+	outClient.FailStub = func(err error) { *outErr = errors.New(err) }
+	outClient.FailFStub = func(format string, args ...interface{}) { *outErr = errors.NewF(format, args...) }
+
+	vcli, err := vaultcli.NewWithOpts(
+		vaultcli.WithHTTPClient(s.client()),
+		vaultcli.WithOutClient(outClient),
+	)
+	if err != nil {
+		t.Fatalf("Unexpected error during vaultCLI init: %v", err)
+	}
+	return vcli
+}
+
+func TestHandlePlanCmd(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "desired.yaml")
+	assert.NoError(t, os.WriteFile(file, []byte(applyTestState), 0o600))
+
+	s := &applyTestServer{resources: map[string]string{
+		"/secrets/prod/db": `{"path":"prod:db","data":{"password":"old"}}`,
+		"/roles/app":       `{"name":"app","description":"Application","provider":"thy-one"}`,
+		"/pools/legacy":    `{"name":"legacy"}`,
+		"/groups/devs":     `{"groupName":"devs","members":[{"name":"bob"},{"name":"alice"}]}`,
+	}}
+	var out []byte
+	var outErr *errors.ApiError
+	vcli := newApplyTestCLI(t, s, &out, &outErr)
+
+	viper.Reset()
+	viper.Set(cst.Tenant, "tenant")
+	code := handlePlanCmd(vcli, []string{file})
+	assert.Equal(t, 2, code)
+	assert.Nil(t, outErr)
+	assert.Empty(t, s.requests, "plan must not change anything")
+
+	report := struct {
+		Changes []*applyChange `json:"changes"`
+		Summary map[string]int `json:"summary"`
+	}{}
+	assert.NoError(t, json.Unmarshal(out, &report))
+	assert.Equal(t, map[string]int{"create": 1, "update": 1, "delete": 1, "unchanged": 2, "errors": 0}, report.Summary)
+	if assert.Len(t, report.Changes, 3) {
+		assert.Equal(t, []string{"update", "create", "delete"},
+			[]string{report.Changes[0].Action, report.Changes[1].Action, report.Changes[2].Action})
+		assert.Equal(t, []string{"data"}, report.Changes[0].Fields)
+		assert.Equal(t, "prod/api", report.Changes[1].Name)
+		assert.Equal(t, "Pool", report.Changes[2].Kind)
+	}
+
+	// No drift.
+	assert.NoError(t, os.WriteFile(file, []byte("kind: Role\nname: app\nspec:\n  description: Application\n"), 0o600))
+	code = handlePlanCmd(vcli, []string{file})
+	assert.Equal(t, 0, code)
+
+	// Immutable field.
+	assert.NoError(t, os.WriteFile(file, []byte("kind: Role\nname: app\nspec:\n  provider: aws\n"), 0o600))
+	code = handlePlanCmd(vcli, []string{file})
+	assert.Equal(t, 1, code)
+	assert.Contains(t, string(out), "provider cannot be changed")
+}
+
+func TestHandleApplyCmd(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "desired.yaml")
+	assert.NoError(t, os.WriteFile(file, []byte(applyTestState), 0o600))
+
+	s := &applyTestServer{resources: map[string]string{
+		"/secrets/prod/db": `{"path":"prod:db","data":{"password":"old"}}`,
+		"/roles/app":       `{"name":"app","description":"Application"}`,
+		"/pools/legacy":    `{"name":"legacy"}`,
+		"/groups/devs":     `{"groupName":"devs","members":[{"name":"bob"},{"name":"carol"}]}`,
+	}}
+	var out []byte
+	var outErr *errors.ApiError
+	vcli := newApplyTestCLI(t, s, &out, &outErr)
+
+	viper.Reset()
+	viper.Set(cst.Tenant, "tenant")
+
+	// Without --auto-approve the plan is printed and nothing is changed if it cannot be confirmed.
+	code := handleApplyCmd(vcli, []string{file})
+	assert.Equal(t, 1, code)
+	assert.ErrorContains(t, outErr, "use --auto-approve")
+	assert.Contains(t, string(out), `"summary"`)
+	assert.Empty(t, s.requests)
+
+	outErr = nil
+	viper.Set(cst.AutoApprove, true)
+	code = handleApplyCmd(vcli, []string{file})
+	assert.Equal(t, 0, code)
+	assert.Nil(t, outErr)
+	assert.Equal(t, []string{
+		`POST /groups/devs/members {"memberNames":["alice"]}`,
+		`DELETE /groups/devs/members {"memberNames":["carol"]}`,
+		`PUT /secrets/prod/db {"Data":{"password":"new"},"Description":"","Attributes":null,"Overwrite":true}`,
+		`POST /secrets/prod/api {"Data":{"token":"abc"},"Description":"","Attributes":null,"Overwrite":true}`,
+		`DELETE /pools/legacy null`,
+	}, s.requests)
+
+	// Nothing is changed if the plan has errors.
+	s.requests = nil
+	assert.NoError(t, os.WriteFile(file, []byte("kind: Secret\npath: prod/api\nspec:\n  data: {a: b}\n---\nkind: Engine\nname: e1\nspec:\n  poolName: p\n"), 0o600))
+	s.resources["/engines/e1"] = `{"name":"e1","poolName":"q"}`
+	code = handleApplyCmd(vcli, []string{file})
+	assert.Equal(t, 1, code)
+	assert.NotNil(t, outErr)
+	assert.Empty(t, s.requests)
+}
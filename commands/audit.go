@@ -6,6 +6,8 @@ import (
 	"time"
 
 	cst "github.com/DelineaXPM/dsv-cli/constants"
+	"github.com/DelineaXPM/dsv-cli/errors"
+	"github.com/DelineaXPM/dsv-cli/format"
 	"github.com/DelineaXPM/dsv-cli/internal/predictor"
 	"github.com/DelineaXPM/dsv-cli/paths"
 	"github.com/DelineaXPM/dsv-cli/vaultcli"
@@ -25,16 +27,16 @@ Usage:
    • audit --startdate 2020-01-21 --enddate 2020-01-22 --limit 10
    • audit --startdate 2020-01-21 --actions POST --path secrets
 `,
-		FlagsPredictor: []*predictor.Params{
+		FlagsPredictor: append([]*predictor.Params{
 			{Name: cst.StartDate, Shorthand: "s", Usage: "Start date from which to fetch audit data (required)"},
 			{Name: cst.EndDate, Usage: "End date to which to fetch audit data (optional)"},
 			{Name: cst.Limit, Shorthand: "l", Usage: cst.LimitHelpMessage},
-			{Name: cst.Cursor, Usage: cst.CursorHelpMessage},
 			{Name: cst.Path, Usage: "Path (optional)"},
 			{Name: cst.NounPrincipal, Usage: "Principal name (optional)"},
 			{Name: cst.DataAction, Usage: "Action performed (POST, GET, PUT, PATCH or DELETE) (optional)"},
 			{Name: cst.Sort, Usage: "Change result sorting order (asc|desc) [default: desc] when search field is specified (optional)"},
-		},
+		}, paginationFlags()...),
+		Encodings:     format.ListEncodings,
 		MinNumberArgs: 1,
 		RunFuncE:      handleAuditSearch,
 	})
@@ -92,18 +94,19 @@ func handleAuditSearch(vcli vaultcli.CLI, args []string) error {
 	if limit := viper.GetString(cst.Limit); limit != "" {
 		queryParams[cst.Limit] = limit
 	}
-	if cursor := viper.GetString(cst.Cursor); cursor != "" {
-		queryParams[cst.Cursor] = cursor
-	}
 	if sort := viper.GetString(cst.Sort); sort != "" {
 		queryParams[cst.Sort] = sort
 	}
-	uri := paths.CreateURI("audit", queryParams)
-	data, err := vcli.HTTPClient().DoRequest(http.MethodGet, uri, nil)
-	if err != nil {
-		return err
+	apiErr := writePages(vcli, viper.GetString(cst.Cursor), func(cursor string) ([]byte, *errors.ApiError) {
+		delete(queryParams, cst.Cursor)
+		if cursor != "" {
+			queryParams[cst.Cursor] = cursor
+		}
+		uri := paths.CreateURI("audit", queryParams)
+		return vcli.HTTPClient().DoRequest(http.MethodGet, uri, nil)
+	})
+	if apiErr != nil {
+		return apiErr
 	}
-
-	vcli.Out().WriteResponse(data, nil)
 	return nil
 }
@@ -11,6 +11,7 @@ import (
 
 	cst "github.com/DelineaXPM/dsv-cli/constants"
 	"github.com/DelineaXPM/dsv-cli/errors"
+	"github.com/DelineaXPM/dsv-cli/format"
 	"github.com/DelineaXPM/dsv-cli/internal/predictor"
 	"github.com/DelineaXPM/dsv-cli/paths"
 	"github.com/DelineaXPM/dsv-cli/utils"
@@ -103,25 +104,33 @@ Usage:
 func GetAuthProviderCreateCmd() (cli.Command, error) {
 	return NewCommand(CommandArgs{
 		Path:         []string{cst.Config, cst.NounAuthProvider, cst.Create},
-		SynopsisText: fmt.Sprintf("%s %s %s (<name> | --name|-n) (--type) ((--data|-d) | --aws-account-id | --azure-tenant-id | --gcp-project-id)", cst.NounConfig, cst.NounAuthProvider, cst.Create),
+		SynopsisText: fmt.Sprintf("%s %s %s (<name> | --name|-n) (--type) ((--data|-d) | --aws-account-id | --azure-tenant-id | --gcp-project-id | --jwt-issuer)", cst.NounConfig, cst.NounAuthProvider, cst.Create),
 		HelpText: `Add an authentication provider
 
 Usage:
    • config auth-provider create aws-dev --aws-account-id 11652944433808  --type aws
    • config auth-provider create --name azure-prod --azure-tenant-id 164543 --type azure
    • config auth-provider create --name GCP-prod --gcp-project-id test-proj --type gcp
+   • config auth-provider create --name k8s-prod --jwt-issuer https://kubernetes.default.svc --jwt-audience https://mytenant.secretsvaultcloud.com --type jwt
    • config auth-provider create --data @/tmp/data.json
 
 GCP GCE metadata auth provider can be created in the command line, but a GCP Service Account must be done using a file.
 See the Authentication:GCP portion of the documentation.
+
+A jwt auth provider trusts tokens of an OIDC issuer, e.g. a Kubernetes cluster, GitHub Actions or GitLab CI.
+Clients authenticate with it using the jwt auth type (--auth-type jwt --auth-provider <name>).
 `,
 		FlagsPredictor: []*predictor.Params{
 			{Name: cst.Data, Shorthand: "d", Usage: fmt.Sprintf("%s to be stored in an auth provider. Prefix with '@' to denote filepath", strings.Title(cst.Data)), Predictor: predictor.NewPrefixFilePredictor("*")},
-			{Name: cst.DataType, Usage: "Auth provider type (azure,aws,gcp,thycoticone)", Predictor: predictor.AuthProviderTypePredictor{}},
+			{Name: cst.DataType, Usage: "Auth provider type (azure,aws,gcp,thycoticone,jwt)", Predictor: predictor.AuthProviderTypePredictor{}},
 			{Name: cst.DataName, Shorthand: "n", Usage: "Auth provider friendly name"},
 			{Name: cst.DataTenantID, Usage: "Azure Tenant ID"},
 			{Name: cst.DataAccountID, Usage: "AWS Account ID"},
 			{Name: cst.DataProjectID, Usage: "GCP Project ID"},
+			{Name: cst.DataIssuer, Usage: "JWT issuer (iss claim)"},
+			{Name: cst.DataAudience, Usage: "JWT audience (aud claim)"},
+			{Name: cst.DataJwksURI, Usage: "JWKS URI with the keys of the issuer [default: discovered from the issuer]"},
+			{Name: cst.DataUsernameClaim, Usage: "JWT claim with the username [default: sub]"},
 			{Name: cst.ThyOneAuthClientBaseUri, Usage: "Thycotic One base URI"},
 			{Name: cst.ThyOneAuthClientID, Usage: "Thycotic One client ID"},
 			{Name: cst.ThyOneAuthClientSecret, Usage: "Thycotic One client secret"},
@@ -146,11 +155,15 @@ Usage:
 `,
 		FlagsPredictor: []*predictor.Params{
 			{Name: cst.Data, Shorthand: "d", Usage: fmt.Sprintf("%s to be stored in an auth provider. Prefix with '@' to denote filepath", strings.Title(cst.Data)), Predictor: predictor.NewPrefixFilePredictor("*")},
-			{Name: cst.DataType, Usage: "Auth provider type (azure,aws,gcp,thycoticone)", Predictor: predictor.AuthProviderTypePredictor{}},
+			{Name: cst.DataType, Usage: "Auth provider type (azure,aws,gcp,thycoticone,jwt)", Predictor: predictor.AuthProviderTypePredictor{}},
 			{Name: cst.DataName, Shorthand: "n", Usage: "Auth provider friendly name"},
 			{Name: cst.DataTenantID, Usage: "Azure Tenant ID"},
 			{Name: cst.DataAccountID, Usage: "AWS Account ID"},
 			{Name: cst.DataProjectID, Usage: "GCP Project ID"},
+			{Name: cst.DataIssuer, Usage: "JWT issuer (iss claim)"},
+			{Name: cst.DataAudience, Usage: "JWT audience (aud claim)"},
+			{Name: cst.DataJwksURI, Usage: "JWKS URI with the keys of the issuer [default: discovered from the issuer]"},
+			{Name: cst.DataUsernameClaim, Usage: "JWT claim with the username [default: sub]"},
 			{Name: cst.ThyOneAuthClientBaseUri, Usage: "Thycotic One base URI"},
 			{Name: cst.ThyOneAuthClientID, Usage: "Thycotic One client ID"},
 			{Name: cst.ThyOneAuthClientSecret, Usage: "Thycotic One client secret"},
@@ -208,12 +221,12 @@ Usage:
    • config auth-provider search aws-dev
    • config auth-provider search --query aws-dev
 `,
-		FlagsPredictor: []*predictor.Params{
+		FlagsPredictor: append([]*predictor.Params{
 			{Name: cst.Query, Shorthand: "q", Usage: fmt.Sprintf("Filter %s of items to fetch (required)", strings.Title(cst.Query))},
 			{Name: cst.Limit, Shorthand: "l", Usage: cst.LimitHelpMessage},
-			{Name: cst.Cursor, Usage: cst.CursorHelpMessage},
-		},
-		RunFunc: handleAuthProviderSearchCmd,
+		}, paginationFlags()...),
+		Encodings: format.ListEncodings,
+		RunFunc:   handleAuthProviderSearchCmd,
 	})
 }
 
@@ -266,12 +279,16 @@ func handleAuthProviderCreate(vcli vaultcli.CLI, args []string) int {
 			Name: getAuthProviderName(args),
 			Type: viper.GetString(cst.DataType),
 			Properties: AuthProviderProperties{
-				AccountID:    viper.GetString(cst.DataAccountID),
-				TenantID:     viper.GetString(cst.DataTenantID),
-				ProjectID:    viper.GetString(cst.DataProjectID),
-				BaseURI:      viper.GetString(cst.ThyOneAuthClientBaseUri),
-				ClientID:     viper.GetString(cst.ThyOneAuthClientID),
-				ClientSecret: viper.GetString(cst.ThyOneAuthClientSecret),
+				AccountID:     viper.GetString(cst.DataAccountID),
+				TenantID:      viper.GetString(cst.DataTenantID),
+				ProjectID:     viper.GetString(cst.DataProjectID),
+				BaseURI:       viper.GetString(cst.ThyOneAuthClientBaseUri),
+				ClientID:      viper.GetString(cst.ThyOneAuthClientID),
+				ClientSecret:  viper.GetString(cst.ThyOneAuthClientSecret),
+				Issuer:        viper.GetString(cst.DataIssuer),
+				Audience:      viper.GetString(cst.DataAudience),
+				JwksURI:       viper.GetString(cst.DataJwksURI),
+				UsernameClaim: viper.GetString(cst.DataUsernameClaim),
 			},
 		}
 	}
@@ -309,12 +326,16 @@ func handleAuthProviderUpdate(vcli vaultcli.CLI, args []string) int {
 		model = authProviderUpdateRequest{
 			Type: viper.GetString(cst.DataType),
 			Properties: AuthProviderProperties{
-				AccountID:    viper.GetString(cst.DataAccountID),
-				TenantID:     viper.GetString(cst.DataTenantID),
-				ProjectID:    viper.GetString(cst.DataProjectID),
-				BaseURI:      viper.GetString(cst.ThyOneAuthClientBaseUri),
-				ClientID:     viper.GetString(cst.ThyOneAuthClientID),
-				ClientSecret: viper.GetString(cst.ThyOneAuthClientSecret),
+				AccountID:     viper.GetString(cst.DataAccountID),
+				TenantID:      viper.GetString(cst.DataTenantID),
+				ProjectID:     viper.GetString(cst.DataProjectID),
+				BaseURI:       viper.GetString(cst.ThyOneAuthClientBaseUri),
+				ClientID:      viper.GetString(cst.ThyOneAuthClientID),
+				ClientSecret:  viper.GetString(cst.ThyOneAuthClientSecret),
+				Issuer:        viper.GetString(cst.DataIssuer),
+				Audience:      viper.GetString(cst.DataAudience),
+				JwksURI:       viper.GetString(cst.DataJwksURI),
+				UsernameClaim: viper.GetString(cst.DataUsernameClaim),
 			},
 		}
 	}
@@ -368,9 +389,14 @@ func handleAuthProviderSearchCmd(vcli vaultcli.CLI, args []string) int {
 		query = args[0]
 	}
 
-	data, apiErr := authProviderSearch(vcli,
-		&authProviderSearchParams{query: query, limit: limit, cursor: cursor})
-	vcli.Out().WriteResponse(data, apiErr)
+	params := &authProviderSearchParams{query: query, limit: limit}
+	apiErr := writePages(vcli, cursor, func(cursor string) ([]byte, *errors.ApiError) {
+		params.cursor = cursor
+		return authProviderSearch(vcli, params)
+	})
+	if apiErr != nil {
+		vcli.Out().WriteResponse(nil, apiErr)
+	}
 	return utils.GetExecStatus(apiErr)
 }
 
@@ -424,7 +450,7 @@ func handleAuthProviderCreateWizard(vcli vaultcli.CLI) int {
 			Name: "Type",
 			Prompt: &survey.Select{
 				Message: "Auth provider type:",
-				Options: []string{"AWS", "Azure", "GCP", "Thycotic One"},
+				Options: []string{"AWS", "Azure", "GCP", "Thycotic One", "JWT"},
 			},
 		},
 	}
@@ -446,6 +472,8 @@ func handleAuthProviderCreateWizard(vcli vaultcli.CLI) int {
 		props, err = authProviderGCPWizard()
 	case "Thycotic One":
 		props, err = authProviderThycoticOneWizard()
+	case "JWT":
+		props, err = authProviderJwtWizard()
 	default:
 		return 1 // Unsupported auth provider type. Should be unreachable.
 	}
@@ -455,8 +483,11 @@ func handleAuthProviderCreateWizard(vcli vaultcli.CLI) int {
 		return utils.GetExecStatus(err)
 	}
 
-	if provider.Type == "Thycotic One" {
+	switch provider.Type {
+	case "Thycotic One":
 		provider.Type = cst.ThyOne
+	case "JWT":
+		provider.Type = cst.JwtProvider
 	}
 	provider.Properties = *props
 
@@ -505,6 +536,8 @@ func handleAuthProviderUpdateWizard(vcli vaultcli.CLI) int {
 		props, err = authProviderGCPWizard()
 	case cst.ThyOne:
 		props, err = authProviderThycoticOneWizard()
+	case cst.JwtProvider:
+		props, err = authProviderJwtWizard()
 	default:
 		err = fmt.Errorf("Unsupported auth provider type: %s", respData.Type)
 	}
@@ -614,6 +647,49 @@ func authProviderThycoticOneWizard() (*AuthProviderProperties, error) {
 	}, nil
 }
 
+func authProviderJwtWizard() (*AuthProviderProperties, error) {
+	qs := []*survey.Question{
+		{
+			Name:      "Issuer",
+			Prompt:    &survey.Input{Message: "Issuer (iss claim):"},
+			Validate:  vaultcli.SurveyRequired,
+			Transform: vaultcli.SurveyTrimSpace,
+		},
+		{
+			Name:      "Audience",
+			Prompt:    &survey.Input{Message: "Audience (aud claim):"},
+			Transform: vaultcli.SurveyTrimSpace,
+		},
+		{
+			Name:      "JwksURI",
+			Prompt:    &survey.Input{Message: "JWKS URI (leave empty to discover it from the issuer):"},
+			Transform: vaultcli.SurveyTrimSpace,
+		},
+		{
+			Name:      "UsernameClaim",
+			Prompt:    &survey.Input{Message: "Username claim:", Default: "sub"},
+			Transform: vaultcli.SurveyTrimSpace,
+		},
+	}
+	answers := struct {
+		Issuer        string
+		Audience      string
+		JwksURI       string
+		UsernameClaim string
+	}{}
+	survErr := survey.Ask(qs, &answers)
+	if survErr != nil {
+		return nil, survErr
+	}
+
+	return &AuthProviderProperties{
+		Issuer:        answers.Issuer,
+		Audience:      answers.Audience,
+		JwksURI:       answers.JwksURI,
+		UsernameClaim: answers.UsernameClaim,
+	}, nil
+}
+
 // Helpers:
 
 func getAuthProviderName(args []string) string {
@@ -664,6 +740,9 @@ type AuthProviderProperties struct {
 	Type             string `json:"type,omitempty"`
 	BaseURI          string `json:"baseUri,omitempty"`
 	UsernameClaim    string `json:"usernameClaim,omitempty"`
+	Issuer           string `json:"issuer,omitempty"`
+	Audience         string `json:"audience,omitempty"`
+	JwksURI          string `json:"jwksUri,omitempty"`
 	SendWelcomeEmail *bool  `json:"sendWelcomeEmail,omitempty"`
 }
 
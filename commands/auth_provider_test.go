@@ -1,6 +1,7 @@
 package cmd
 
 import (
+	"encoding/json"
 	"net/http"
 	"testing"
 
@@ -546,3 +547,27 @@ func TestHandleAuthProviderRollbackCmd(t *testing.T) {
 		})
 	}
 }
+
+func TestHandleAuthProviderCreateJwt(t *testing.T) {
+	viper.Reset()
+	defer viper.Reset()
+	viper.Set(cst.DataType, cst.JwtProvider)
+	viper.Set(cst.DataIssuer, "https://kubernetes.default.svc")
+	viper.Set(cst.DataAudience, "https://mytenant.secretsvaultcloud.com")
+	viper.Set(cst.DataUsernameClaim, "sub")
+
+	var body interface{}
+	httpClient := &fake.FakeClient{}
+	httpClient.DoRequestStub = func(method string, uri string, b interface{}) ([]byte, *errors.ApiError) {
+		body = b
+		return []byte("api response"), nil
+	}
+	vcli, err := vaultcli.NewWithOpts(vaultcli.WithHTTPClient(httpClient), vaultcli.WithOutClient(&fake.FakeOutClient{}))
+	assert.NoError(t, err)
+
+	assert.Equal(t, 0, handleAuthProviderCreate(vcli, []string{"k8s-prod"}))
+	b, err := json.Marshal(body)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"name":"k8s-prod","type":"jwt","properties":{
+		"issuer":"https://kubernetes.default.svc","audience":"https://mytenant.secretsvaultcloud.com","usernameClaim":"sub"}}`, string(b))
+}
@@ -8,6 +8,7 @@ import (
 	"log"
 	"os"
 	"runtime"
+	"slices"
 	"sort"
 	"strconv"
 	"strings"
@@ -17,6 +18,8 @@ import (
 	"github.com/DelineaXPM/dsv-cli/auth"
 	cst "github.com/DelineaXPM/dsv-cli/constants"
 	"github.com/DelineaXPM/dsv-cli/errors"
+	"github.com/DelineaXPM/dsv-cli/format"
+	"github.com/DelineaXPM/dsv-cli/internal/httpclient"
 	"github.com/DelineaXPM/dsv-cli/internal/predictor"
 	"github.com/DelineaXPM/dsv-cli/utils"
 	"github.com/DelineaXPM/dsv-cli/vaultcli"
@@ -39,15 +42,19 @@ func BasePredictorWrappers() []*predictor.Params {
 		{Name: cst.Profile, Usage: "Configuration Profile [default:default]", Global: true},
 		{Name: cst.Tenant, Shorthand: "t", Usage: "Tenant used for auth", Global: true},
 		{Name: cst.DomainName, Usage: "Domain used for auth", Global: true},
-		{Name: cst.Encoding, Shorthand: "e", Usage: "Output encoding (json|yaml) [default:json]", Global: true, Predictor: predictor.EncodingTypePredictor{}},
+		{Name: cst.Encoding, Shorthand: "e", Usage: "Output encoding (json|yaml), some commands support more [default:json]", Global: true, Predictor: predictor.EncodingTypePredictor{}},
 		{Name: cst.Beautify, Shorthand: "b", Usage: "Should beautify output", Global: true, ValueType: "bool", Hidden: true},
 		{Name: cst.Plain, Usage: "Should not beautify output", Global: true, ValueType: "bool"},
 		{Name: cst.Verbose, Shorthand: "v", Usage: "Verbose output [default:false]", Global: true, ValueType: "bool"},
 		{Name: cst.Config, Shorthand: "c", Usage: fmt.Sprintf("Config file path [default:%s%s.dsv.yml]", homePath, string(os.PathSeparator)), Global: true},
-		{Name: cst.Filter, Shorthand: "f", Usage: "Filter in jq (stedolan.github.io/jq)", Global: true},
+		{Name: cst.Filter, Shorthand: "f", Usage: "Filter in jq (jqlang.github.io/jq), use @<file> to read the filter from a file", Global: true},
+		{Name: cst.RawOutput, Usage: "Print strings returned by the filter without quotes", Global: true, ValueType: "bool"},
+		{Name: cst.Template, Usage: "Go template applied to the output (pkg.go.dev/text/template), use @<file> to read the template from a file", Global: true},
 		{Name: cst.Output, Shorthand: "o", Usage: "Output destination (stdout|clip|file:<fname>) [default:stdout]", Global: true, Predictor: predictor.OutputTypePredictor{}},
+		{Name: cst.NetworkTimeout, Usage: fmt.Sprintf("Timeout of a single HTTP request in seconds or as a duration, e.g. 90s [default:%s]", httpclient.DefaultTimeout), Global: true},
+		{Name: cst.NetworkRetries, Usage: fmt.Sprintf("Number of retries of idempotent HTTP requests on connection errors, 429 and 5xx responses [default:%d]", httpclient.DefaultRetries), Global: true},
 
-		{Name: cst.AuthType, Shorthand: "a", Usage: "Auth Type (" + strings.Join([]string{string(auth.Password), string(auth.ClientCredential), string(auth.FederatedAws), string(auth.FederatedAzure), string(auth.FederatedGcp)}, "|") + ")", Global: true, Predictor: predictor.AuthTypePredictor{}},
+		{Name: cst.AuthType, Shorthand: "a", Usage: "Auth Type (" + strings.Join([]string{string(auth.Password), string(auth.ClientCredential), string(auth.FederatedAws), string(auth.FederatedAzure), string(auth.FederatedGcp), string(auth.Jwt)}, "|") + ")", Global: true, Predictor: predictor.AuthTypePredictor{}},
 		{Name: cst.AwsProfile, Usage: "AWS profile", Global: true},
 		{Name: cst.Username, Shorthand: "u", Usage: "User", Global: true},
 		{Name: cst.Password, Shorthand: "p", Usage: "Password", Global: true},
@@ -57,6 +64,9 @@ func BasePredictorWrappers() []*predictor.Params {
 		{Name: cst.GcpServiceAccount, Usage: "GCP Service Account Name", Global: true},
 		{Name: cst.GcpProject, Usage: "GCP Project", Global: true},
 		{Name: cst.GcpToken, Usage: "GCP OIDC Token", Global: true},
+		{Name: cst.AuthJwtFile, Usage: "Path to a file with the token for the jwt auth type, e.g. a Kubernetes service account token", Global: true},
+		{Name: cst.AuthJwtEnv, Usage: "Environment variable with the token for the jwt auth type, e.g. CI_JOB_JWT", Global: true},
+		{Name: cst.AuthJwtReread, Usage: "Read the token again instead of using the refresh token when the access token expires", Global: true, ValueType: "bool"},
 	}
 }
 
@@ -72,6 +82,14 @@ type CommandArgs struct {
 	NoConfigRead   bool
 	NoPreAuth      bool
 	MinNumberArgs  int
+
+	// ServedByAgent is set for commands which only read secrets. They skip authentication
+	// if the local agent is used (DSV_AGENT_SOCK is set) since the agent uses its own token.
+	ServedByAgent bool
+
+	// Encodings lists output encodings registered in the format package which the command
+	// supports in addition to JSON and YAML.
+	Encodings []string
 }
 
 func NewCommand(args CommandArgs) (cli.Command, error) {
@@ -88,6 +106,8 @@ func NewCommand(args CommandArgs) (cli.Command, error) {
 		synopsisText:   args.SynopsisText,
 		noConfigRead:   args.NoConfigRead,
 		noPreAuth:      args.NoPreAuth,
+		servedByAgent:  args.ServedByAgent,
+		encodings:      args.Encodings,
 		minNumberArgs:  args.MinNumberArgs,
 		argsPredictor:  args.ArgsPredictor,
 		flagsPredictor: make(map[string]*predictor.Wrapper),
@@ -101,6 +121,14 @@ func NewCommand(args CommandArgs) (cli.Command, error) {
 		w := predictor.New(v)
 		cmd.flagsPredictor[w.FriendlyName] = w
 	}
+	if slices.Contains(args.Encodings, cst.Table) {
+		w := predictor.New(&predictor.Params{
+			Name:      cst.Columns,
+			Usage:     "Columns of table and csv output, can be repeated or comma-separated, e.g. path,version,attributes.env (optional)",
+			ValueType: "list",
+		})
+		cmd.flagsPredictor[w.FriendlyName] = w
+	}
 
 	return cmd, nil
 }
@@ -116,6 +144,8 @@ type baseCommand struct {
 	flagsPredictor map[string]*predictor.Wrapper
 	noConfigRead   bool
 	noPreAuth      bool
+	servedByAgent  bool
+	encodings      []string
 	minNumberArgs  int
 }
 
@@ -168,6 +198,10 @@ func (c *baseCommand) Run(args []string) int {
 		encoding = strings.ToLower(encoding)
 	}
 	viper.Set(cst.Encoding, encoding)
+	if format.GetEncoder(encoding) != nil && !slices.Contains(c.encodings, encoding) {
+		vcli.Out().FailF("Output encoding %q is not supported by this command.", encoding)
+		return 1
+	}
 
 	// The --plain flag overrides the --beautify flag.
 	beautify := !viper.GetBool(cst.Plain)
@@ -184,7 +218,7 @@ func (c *baseCommand) Run(args []string) int {
 		}
 	}
 
-	if !c.noPreAuth {
+	if !c.noPreAuth && !(c.servedByAgent && os.Getenv(cst.AgentSocketEnv) != "") {
 		tokenResponse, err := vcli.Authenticator().GetToken()
 		if err != nil || tokenResponse == nil || tokenResponse.Token == "" {
 			vcli.Out().WriteResponse(nil, err)
@@ -365,3 +399,16 @@ func wrapError(in func(vaultcli.CLI, []string) error) func(vaultcli.CLI, []strin
 		return 0
 	}
 }
+
+// outputFilePath converts the value of the global --out flag to a file path for commands
+// which write files themselves. An empty path means stdout.
+func outputFilePath(out string) (string, error) {
+	switch {
+	case out == "" || out == format.OutToStdout:
+		return "", nil
+	case out == format.OutToClip:
+		return "", errors.NewS("error: writing to the clipboard is not supported by this command")
+	default:
+		return strings.TrimPrefix(out, format.OutToFilePrefix), nil
+	}
+}
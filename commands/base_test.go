@@ -10,3 +10,24 @@ func TestNewCommand(t *testing.T) {
 	_, err := NewCommand(CommandArgs{})
 	assert.Error(t, err)
 }
+
+func TestOutputFilePath(t *testing.T) {
+	p, err := outputFilePath("")
+	assert.NoError(t, err)
+	assert.Equal(t, "", p)
+
+	p, err = outputFilePath("stdout")
+	assert.NoError(t, err)
+	assert.Equal(t, "", p)
+
+	p, err = outputFilePath("file:/tmp/app.conf")
+	assert.NoError(t, err)
+	assert.Equal(t, "/tmp/app.conf", p)
+
+	p, err = outputFilePath("app.conf")
+	assert.NoError(t, err)
+	assert.Equal(t, "app.conf", p)
+
+	_, err = outputFilePath("clip")
+	assert.Error(t, err)
+}
@@ -0,0 +1,453 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	cst "github.com/DelineaXPM/dsv-cli/constants"
+	"github.com/DelineaXPM/dsv-cli/errors"
+	"github.com/DelineaXPM/dsv-cli/internal/predictor"
+	"github.com/DelineaXPM/dsv-cli/internal/store"
+	"github.com/DelineaXPM/dsv-cli/vaultcli"
+
+	"github.com/AlecAivazis/survey/v2"
+	"github.com/mitchellh/cli"
+	"github.com/spf13/viper"
+)
+
+func GetCacheCmd() (cli.Command, error) {
+	return NewCommand(CommandArgs{
+		Path:         []string{cst.NounCache},
+		SynopsisText: "Manage the local cache",
+		HelpText:     fmt.Sprintf("Execute an action on the local store which caches tokens and %ss", cst.NounSecret),
+		NoPreAuth:    true,
+	})
+}
+
+func GetCacheListCmd() (cli.Command, error) {
+	return NewCommand(CommandArgs{
+		Path:         []string{cst.NounCache, cst.List},
+		SynopsisText: fmt.Sprintf("%s %s [<path> | --path|-r <path>]", cst.NounCache, cst.List),
+		HelpText: fmt.Sprintf(`List cached %[1]ss of all profiles
+
+Entries are expired according to the cache.age setting of the current profile.
+Entries cached by older CLI versions do not have a path.
+
+Usage:
+   • %[2]s %[3]s
+   • %[2]s %[3]s prod
+`, cst.NounSecret, cst.NounCache, cst.List),
+		FlagsPredictor: []*predictor.Params{
+			{Name: cst.Path, Shorthand: "r", Usage: fmt.Sprintf("List only %ss under the %s", cst.NounSecret, cst.Path)},
+		},
+		NoPreAuth: true,
+		RunFuncE:  handleCacheListCmd,
+	})
+}
+
+func GetCacheInspectCmd() (cli.Command, error) {
+	return NewCommand(CommandArgs{
+		Path:         []string{cst.NounCache, cst.Inspect},
+		SynopsisText: fmt.Sprintf("%s %s (<path> | --path|-r <path>)", cst.NounCache, cst.Inspect),
+		HelpText: fmt.Sprintf(`Show a cached %[1]s of the current profile including its data
+
+Usage:
+   • %[2]s %[3]s %[4]s
+`, cst.NounSecret, cst.NounCache, cst.Inspect, cst.ExamplePath),
+		FlagsPredictor: []*predictor.Params{
+			{Name: cst.Path, Shorthand: "r", Usage: fmt.Sprintf("Target %s to a %s (required)", cst.Path, cst.NounSecret)},
+		},
+		MinNumberArgs: 1,
+		NoPreAuth:     true,
+		RunFuncE:      handleCacheInspectCmd,
+	})
+}
+
+func GetCachePrefetchCmd() (cli.Command, error) {
+	return NewCommand(CommandArgs{
+		Path:         []string{cst.NounCache, cst.Prefetch},
+		SynopsisText: fmt.Sprintf("%s %s (<path> | --path|-r <path>) [--recursive] [--workers <n>] [--dry-run]", cst.NounCache, cst.Prefetch),
+		HelpText: fmt.Sprintf(`Read %[1]ss from %[2]s and store them in the cache of the current profile
+
+Use it to warm the cache ahead of a maintenance window, so reads can be served from the cache
+with the cache.server or cache.server.expired cache strategy while %[2]s is unavailable.
+The path can be a glob pattern where * matches any part of a single path segment.
+
+Usage:
+   • %[3]s %[4]s --path prod --recursive
+   • %[3]s %[4]s "prod/*/password"
+`, cst.NounSecret, cst.ProductName, cst.NounCache, cst.Prefetch),
+		FlagsPredictor: append([]*predictor.Params{
+			{Name: cst.Path, Shorthand: "r", Usage: fmt.Sprintf("Target %s or glob pattern (required)", cst.Path), Predictor: predictor.NewSecretPathPredictorDefault()},
+		}, secretBulkFlags()...),
+		ArgsPredictor: predictor.NewSecretPathPredictorDefault(),
+		MinNumberArgs: 1,
+		RunFuncE:      handleCachePrefetchCmd,
+	})
+}
+
+func GetCachePurgeCmd() (cli.Command, error) {
+	return NewCommand(CommandArgs{
+		Path:         []string{cst.NounCache, cst.Purge},
+		SynopsisText: fmt.Sprintf("%s %s [<path> | --path|-r <path>] [--older-than <duration>] [--expired] [--dry-run]", cst.NounCache, cst.Purge),
+		HelpText: fmt.Sprintf(`Delete cached %[1]ss of all profiles
+
+Without filters all cached %[1]ss are deleted. Tokens are kept.
+
+Usage:
+   • %[2]s %[3]s --older-than 24h
+   • %[2]s %[3]s --older-than 7d --path prod
+   • %[2]s %[3]s --expired --dry-run
+`, cst.NounSecret, cst.NounCache, cst.Purge),
+		FlagsPredictor: []*predictor.Params{
+			{Name: cst.Path, Shorthand: "r", Usage: fmt.Sprintf("Delete only %ss under the %s", cst.NounSecret, cst.Path)},
+			{Name: cst.OlderThan, Usage: "Delete only entries cached longer ago than the duration, e.g. 90m, 24h or 7d"},
+			{Name: cst.Expired, Usage: "Delete only entries older than cache.age", ValueType: "bool"},
+			{Name: cst.DryRun, Usage: "Only report what would be deleted", ValueType: "bool"},
+		},
+		NoPreAuth: true,
+		RunFuncE:  handleCachePurgeCmd,
+	})
+}
+
+func GetCacheStatsCmd() (cli.Command, error) {
+	return NewCommand(CommandArgs{
+		Path:         []string{cst.NounCache, cst.Stats},
+		SynopsisText: fmt.Sprintf("%s %s", cst.NounCache, cst.Stats),
+		HelpText: fmt.Sprintf(`Show statistics of the local %[1]s cache
+
+Usage:
+   • %[2]s %[3]s
+`, cst.NounSecret, cst.NounCache, cst.Stats),
+		NoPreAuth: true,
+		RunFuncE:  handleCacheStatsCmd,
+	})
+}
+
+func GetCacheRekeyCmd() (cli.Command, error) {
+	return NewCommand(CommandArgs{
+		Path:         []string{cst.NounCache, cst.Rekey},
+		SynopsisText: fmt.Sprintf("%s %s [--new-passphrase]", cst.NounCache, cst.Rekey),
+		HelpText: fmt.Sprintf(`Re-encrypt the local cache with a new key
+
+Requires store.type %[1]s. The key source is set with store.key.source:
+   • %[2]s - a random key in a file, store.key.file or ~/.thy.key by default
+   • %[3]s - a random key in pass on Linux or the Credential Manager on Windows
+   • %[4]s - a key derived from store.passphrase, usually set with THY_STORE_PASSPHRASE
+
+Random keys are replaced with new ones. With the %[4]s key source the cache is re-encrypted
+with the new passphrase, which must be used from then on. The default key file is kept in the home
+directory next to the default cache directory ~/.thy, so set store.key.file to keep the key on
+separately protected storage. An interrupted rekey is finished or rolled back on the next run.
+
+Usage:
+   • %[5]s %[6]s
+   • THY_STORE_PASSPHRASE=old %[5]s %[6]s --new-passphrase new
+`, store.FileEncrypted, store.KeySourceFile, store.KeySourceKeyring, store.KeySourcePassphrase, cst.NounCache, cst.Rekey),
+		FlagsPredictor: []*predictor.Params{
+			{Name: cst.NewPassphrase, Usage: fmt.Sprintf("New passphrase for the %s key source", store.KeySourcePassphrase)},
+		},
+		NoPreAuth: true,
+		RunFuncE:  handleCacheRekeyCmd,
+	})
+}
+
+func handleCacheRekeyCmd(vcli vaultcli.CLI, args []string) error {
+	st := viper.GetString(cst.StoreType)
+	if st != store.FileEncrypted {
+		return errors.NewF("error: %s %s requires store.type %s, current store type is %q", cst.NounCache, cst.Rekey, store.FileEncrypted, st)
+	}
+	s, err := vcli.Store(st)
+	if err != nil {
+		return err
+	}
+
+	newPassphrase := viper.GetString(cst.NewPassphrase)
+	if newPassphrase == "" && store.KeySource() == store.KeySourcePassphrase {
+		newPassphrase, err = cacheNewPassphrase()
+		if err != nil {
+			return err
+		}
+	}
+
+	n, err := store.Rekey(s, newPassphrase)
+	if err != nil {
+		return err
+	}
+	vcli.Out().WriteResponse([]byte(fmt.Sprintf("Re-encrypted %d cache entries.", n)), nil)
+	return nil
+}
+
+func cacheNewPassphrase() (string, error) {
+	var passphrase, again string
+	prompt := &survey.Password{Message: "Please enter new cache passphrase:"}
+	if err := survey.AskOne(prompt, &passphrase, survey.WithValidator(vaultcli.SurveyRequired)); err != nil {
+		return "", err
+	}
+	prompt = &survey.Password{Message: "Please enter new cache passphrase (confirm):"}
+	if err := survey.AskOne(prompt, &again); err != nil {
+		return "", err
+	}
+	if again != passphrase {
+		return "", errors.NewS("error: passphrases do not match")
+	}
+	return passphrase, nil
+}
+
+// cacheEntry describes a cached secret.
+type cacheEntry struct {
+	Path     string          `json:"path"`
+	Profile  string          `json:"profile"`
+	Type     string          `json:"type"`
+	CachedAt time.Time       `json:"cachedAt"`
+	Age      string          `json:"age"`
+	Expired  bool            `json:"expired"`
+	Size     int             `json:"size"`
+	Data     json.RawMessage `json:"data,omitempty"`
+
+	key string
+}
+
+func handleCacheListCmd(vcli vaultcli.CLI, args []string) error {
+	entries, err := readCacheEntries(vcli, cachePathArg(args))
+	if err != nil {
+		return err
+	}
+	return writeCacheResponse(vcli, map[string]interface{}{"data": entries})
+}
+
+func handleCacheInspectCmd(vcli vaultcli.CLI, args []string) error {
+	path := cachePathArg(args)
+	if path == "" {
+		return errors.NewF("error: must specify --%s", cst.Path)
+	}
+	s, err := cacheStore(vcli)
+	if err != nil {
+		return err
+	}
+	key := getSecretCacheKey(path, "", "")
+	entry, err := readCacheEntry(s, key)
+	if err != nil {
+		return err
+	}
+	if entry == nil {
+		return errors.NewF("error: %s %q is not cached for profile %q", cst.NounSecret, path, viper.GetString(cst.Profile))
+	}
+	return writeCacheResponse(vcli, entry)
+}
+
+func handleCachePrefetchCmd(vcli vaultcli.CLI, args []string) error {
+	path := secretBundlePath(cachePathArg(args))
+	if path == "" {
+		return errors.NewF("error: must specify --%s", cst.Path)
+	}
+	if _, err := cacheStore(vcli); err != nil {
+		return err
+	}
+	items, apiErr := findBulkSecrets(vcli, cst.NounSecret, path)
+	if apiErr != nil {
+		return apiErr
+	}
+	return runSecretBulkCmd(vcli, cst.Prefetch, items, func(item *secretSearchItem, dryRun bool) *secretBulkResult {
+		res := &secretBulkResult{Path: item.Path, Action: cst.Prefetch}
+		if dryRun {
+			return res
+		}
+		resp, apiErr := getSecretFromServer(vcli, cst.NounSecret, item.Path, "", false, "")
+		if apiErr != nil {
+			res.Error = apiErr.Error()
+			return res
+		}
+		putSecretToCache(vcli, getSecretCacheKey(item.Path, "", ""), secretCachePath(item.Path, ""), resp)
+		return res
+	})
+}
+
+func handleCachePurgeCmd(vcli vaultcli.CLI, args []string) error {
+	var olderThan time.Duration
+	if v := viper.GetString(cst.OlderThan); v != "" {
+		d, err := parseCacheDuration(v)
+		if err != nil {
+			return errors.NewF("error: invalid --older-than %q, expected a duration such as 90m, 24h or 7d", v)
+		}
+		olderThan = d
+	}
+	expiredOnly := viper.GetBool(cst.Expired)
+	dryRun := viper.GetBool(cst.DryRun)
+
+	s, err := cacheStore(vcli)
+	if err != nil {
+		return err
+	}
+	entries, err := readCacheEntries(vcli, cachePathArg(args))
+	if err != nil {
+		return err
+	}
+	now := time.Now().UTC()
+	purged := []*cacheEntry{}
+	for _, e := range entries {
+		if olderThan > 0 && now.Sub(e.CachedAt) < olderThan || expiredOnly && !e.Expired {
+			continue
+		}
+		if !dryRun {
+			if err := s.Delete(e.key); err != nil {
+				return errors.NewF("failed to delete cached %s %q: %v", cst.NounSecret, e.Path, err)
+			}
+		}
+		purged = append(purged, e)
+	}
+	return writeCacheResponse(vcli, map[string]interface{}{"dryRun": dryRun, "purged": len(purged), "data": purged})
+}
+
+func handleCacheStatsCmd(vcli vaultcli.CLI, args []string) error {
+	entries, err := readCacheEntries(vcli, "")
+	if err != nil {
+		return err
+	}
+	stats := map[string]interface{}{
+		"store":    viper.GetString(cst.StoreType),
+		"strategy": viper.GetString(cst.CacheStrategy),
+		"age":      viper.GetInt(cst.CacheAge),
+	}
+	profiles := map[string]int{}
+	types := map[string]int{}
+	var expired, size int
+	var oldest, newest *time.Time
+	for _, e := range entries {
+		profiles[e.Profile]++
+		types[e.Type]++
+		size += e.Size
+		if e.Expired {
+			expired++
+		}
+		cachedAt := e.CachedAt
+		if oldest == nil || cachedAt.Before(*oldest) {
+			oldest = &cachedAt
+		}
+		if newest == nil || cachedAt.After(*newest) {
+			newest = &cachedAt
+		}
+	}
+	stats["entries"] = len(entries)
+	stats["expired"] = expired
+	stats["size"] = size
+	stats["profiles"] = profiles
+	stats["types"] = types
+	stats["oldest"] = oldest
+	stats["newest"] = newest
+	return writeCacheResponse(vcli, stats)
+}
+
+func cachePathArg(args []string) string {
+	path := viper.GetString(cst.Path)
+	if path == "" && len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		path = args[0]
+	}
+	return path
+}
+
+func cacheStore(vcli vaultcli.CLI) (store.Store, error) {
+	st := viper.GetString(cst.StoreType)
+	if st == store.None || st == store.Unset {
+		return nil, errors.NewF("error: caching is disabled since store.type is %q", st)
+	}
+	return vcli.Store(st)
+}
+
+// readCacheEntries returns cached secrets and descriptions of all profiles under the path sorted by path.
+func readCacheEntries(vcli vaultcli.CLI, path string) ([]*cacheEntry, error) {
+	s, err := cacheStore(vcli)
+	if err != nil {
+		return nil, err
+	}
+	path = secretBundlePath(path)
+
+	entries := []*cacheEntry{}
+	for _, prefix := range []string{cst.SecretRoot + "-", cst.SecretDescriptionRoot + "-"} {
+		keys, err := s.List(prefix)
+		if err != nil {
+			return nil, err
+		}
+		for _, key := range keys {
+			e, err := readCacheEntry(s, key)
+			if err != nil {
+				return nil, err
+			}
+			if e == nil || path != "" && !secretBundleHasPrefix(secretBundlePath(e.Path), path) {
+				continue
+			}
+			e.Data = nil
+			entries = append(entries, e)
+		}
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		if entries[i].Path != entries[j].Path {
+			return entries[i].Path < entries[j].Path
+		}
+		return entries[i].Profile < entries[j].Profile
+	})
+	return entries, nil
+}
+
+// readCacheEntry returns the cached secret stored with the key or nil if there is none.
+func readCacheEntry(s store.Store, key string) (*cacheEntry, error) {
+	var data secretData
+	if err := s.Get(key, &data); err != nil {
+		return nil, err
+	}
+	if data.Date.IsZero() {
+		return nil, nil
+	}
+	e := &cacheEntry{
+		Path:     data.Path,
+		Profile:  data.Profile,
+		Type:     cst.SecretRoot,
+		CachedAt: data.Date,
+		Age:      time.Since(data.Date).Round(time.Second).String(),
+		Expired:  isSecretCacheExpired(data.Date),
+		Size:     len(data.Data),
+		key:      key,
+	}
+	if strings.HasPrefix(key, cst.SecretDescriptionRoot+"-") {
+		e.Type = cst.SecretDescriptionRoot
+	}
+	// Entries cached by older versions have no profile, but the key shows if it is the current one.
+	if e.Profile == "" && (strings.HasPrefix(key, getSecretCachePrefix()+"-") || strings.HasPrefix(key, getSecretDescCachePrefix()+"-")) {
+		e.Profile = viper.GetString(cst.Profile)
+	}
+	if json.Valid(data.Data) {
+		e.Data = data.Data
+	} else if len(data.Data) > 0 {
+		e.Data, _ = json.Marshal(string(data.Data))
+	}
+	return e, nil
+}
+
+// parseCacheDuration parses a duration which may also be given in days, e.g. 7d.
+func parseCacheDuration(s string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil || n < 0 {
+			return 0, fmt.Errorf("invalid number of days %q", days)
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err == nil && d < 0 {
+		return 0, fmt.Errorf("negative duration %q", s)
+	}
+	return d, err
+}
+
+func writeCacheResponse(vcli vaultcli.CLI, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	vcli.Out().WriteResponse(data, nil)
+	return nil
+}
@@ -0,0 +1,150 @@
+package cmd
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	cst "github.com/DelineaXPM/dsv-cli/constants"
+	"github.com/DelineaXPM/dsv-cli/errors"
+	"github.com/DelineaXPM/dsv-cli/internal/store"
+	"github.com/DelineaXPM/dsv-cli/tests/fake"
+	"github.com/DelineaXPM/dsv-cli/vaultcli"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetCacheCmd(t *testing.T) {
+	for _, f := range []func() (interface{}, error){
+		func() (interface{}, error) { return GetCacheCmd() },
+		func() (interface{}, error) { return GetCacheListCmd() },
+		func() (interface{}, error) { return GetCacheInspectCmd() },
+		func() (interface{}, error) { return GetCachePrefetchCmd() },
+		func() (interface{}, error) { return GetCachePurgeCmd() },
+		func() (interface{}, error) { return GetCacheStatsCmd() },
+		func() (interface{}, error) { return GetCacheRekeyCmd() },
+	} {
+		_, err := f()
+		assert.Nil(t, err)
+	}
+}
+
+func TestParseCacheDuration(t *testing.T) {
+	d, err := parseCacheDuration("7d")
+	assert.NoError(t, err)
+	assert.Equal(t, 7*24*time.Hour, d)
+	d, err = parseCacheDuration("90m")
+	assert.NoError(t, err)
+	assert.Equal(t, 90*time.Minute, d)
+	_, err = parseCacheDuration("xd")
+	assert.Error(t, err)
+	_, err = parseCacheDuration("-1h")
+	assert.Error(t, err)
+}
+
+func TestCacheCmds(t *testing.T) {
+	s := store.NewFileStore(t.TempDir())
+	server := &bulkFakeServer{}
+	var reads []string
+	do := func(method string, uri string, body interface{}) ([]byte, *errors.ApiError) {
+		u, _ := url.Parse(uri)
+		if p := strings.TrimPrefix(u.Path, "/v1/secrets/"); method == http.MethodGet && p != u.Path {
+			reads = append(reads, p)
+			return []byte(`{"path":"` + strings.ReplaceAll(p, "/", ":") + `","data":{"k":"v"}}`), nil
+		}
+		return server.do(method, uri, body)
+	}
+	var out []byte
+	outClient := &fake.FakeOutClient{}
+	outClient.WriteResponseStub = func(data []byte, apiError *errors.ApiError) { out = data }
+	vcli, err := vaultcli.NewWithOpts(
+		vaultcli.WithHTTPClient(&fake.FakeClient{DoRequestStub: do}),
+		vaultcli.WithOutClient(outClient),
+		vaultcli.WithStore(s),
+	)
+	if err != nil {
+		t.Fatalf("Unexpected error during vaultCLI init: %v", err)
+	}
+
+	reset := func(flags map[string]interface{}) {
+		viper.Reset()
+		viper.Set(cst.Tenant, "tenant")
+		viper.Set(cst.StoreType, store.File)
+		viper.Set(cst.Profile, "default")
+		viper.Set(cst.CacheAge, 60)
+		for k, v := range flags {
+			viper.Set(k, v)
+		}
+		out = nil
+	}
+	defer viper.Reset()
+
+	list := func() []map[string]interface{} {
+		t.Helper()
+		reset(nil)
+		assert.NoError(t, handleCacheListCmd(vcli, nil))
+		var resp struct{ Data []map[string]interface{} }
+		assert.NoError(t, json.Unmarshal(out, &resp))
+		return resp.Data
+	}
+
+	reset(map[string]interface{}{cst.Recursive: true})
+	assert.NoError(t, handleCachePrefetchCmd(vcli, []string{"prod/db"}))
+	sort.Strings(reads)
+	assert.Equal(t, []string{"prod/db/password", "prod/db/user"}, reads)
+	assert.Empty(t, server.requests)
+
+	// An entry of another profile cached two days ago.
+	assert.NoError(t, s.Store(cst.SecretRoot+"-other", secretData{Date: time.Now().UTC().Add(-48 * time.Hour), Data: []byte(`{}`), Path: "prod/api", Profile: "staging"}))
+
+	entries := list()
+	assert.Len(t, entries, 3)
+	assert.Equal(t, "prod/api", entries[0]["path"])
+	assert.Equal(t, "staging", entries[0]["profile"])
+	assert.Equal(t, true, entries[0]["expired"])
+	assert.Equal(t, "prod/db/password", entries[1]["path"])
+	assert.Equal(t, "default", entries[1]["profile"])
+	assert.Equal(t, false, entries[1]["expired"])
+	assert.Nil(t, entries[1]["data"])
+
+	reset(nil)
+	assert.NoError(t, handleCacheInspectCmd(vcli, []string{"prod/db/user"}))
+	entry := map[string]interface{}{}
+	assert.NoError(t, json.Unmarshal(out, &entry))
+	assert.Equal(t, "prod/db/user", entry["path"])
+	assert.NotEmpty(t, entry["data"])
+
+	reset(nil)
+	assert.ErrorContains(t, handleCacheInspectCmd(vcli, []string{"prod/none"}), "is not cached")
+
+	reset(nil)
+	assert.NoError(t, handleCacheStatsCmd(vcli, nil))
+	stats := map[string]interface{}{}
+	assert.NoError(t, json.Unmarshal(out, &stats))
+	assert.Equal(t, float64(3), stats["entries"])
+	assert.Equal(t, float64(1), stats["expired"])
+	assert.Equal(t, map[string]interface{}{"default": float64(2), "staging": float64(1)}, stats["profiles"])
+
+	reset(map[string]interface{}{cst.OlderThan: "1d", cst.DryRun: true})
+	assert.NoError(t, handleCachePurgeCmd(vcli, nil))
+	assert.Contains(t, string(out), `"purged":1`)
+	assert.Len(t, list(), 3)
+
+	reset(map[string]interface{}{cst.OlderThan: "1d"})
+	assert.NoError(t, handleCachePurgeCmd(vcli, nil))
+	assert.Len(t, list(), 2)
+
+	reset(nil)
+	assert.NoError(t, handleCachePurgeCmd(vcli, []string{"prod/db/user"}))
+	entries = list()
+	assert.Len(t, entries, 1)
+	assert.Equal(t, "prod/db/password", entries[0]["path"])
+
+	reset(map[string]interface{}{cst.StoreType: store.None})
+	assert.ErrorContains(t, handleCacheListCmd(vcli, nil), "caching is disabled")
+}
@@ -108,22 +108,30 @@ Examples:
 			{Name: cst.DomainName, Usage: "Domain name, e.g. 'secretsvaultcloud.com'"},
 
 			// Storing and Caching.
-			{Name: cst.StoreType, Usage: "Store type (file|none|pass_linux|wincred)"},
+			{Name: cst.StoreType, Usage: "Store type (file|file_encrypted|none|pass_linux|wincred)"},
 			{Name: cst.StorePath, Usage: "Path to directory where to store. Only if store type is 'file'"},
-			{Name: cst.CacheStrategy, Usage: "Cache strategy (server|server.cache|cache.server|cache.server.expired). Only if store type is not 'none'"},
+			{Name: cst.CacheStrategy, Usage: "Cache strategy (server|server.cache|cache.server|cache.server.expired|revalidate). Only if store type is not 'none'"},
 			{Name: cst.CacheAge, Usage: "Cache age in minutes. Only if cache strategy is not 'server'"},
 
 			// Authentication.
-			{Name: cst.AuthType, Usage: "Authentication type (password|clientcred|thy-one|aws|azure|gcp|oidc|cert)"},
+			{Name: cst.AuthType, Usage: "Authentication type (password|clientcred|thy-one|aws|azure|gcp|oidc|cert|jwt)"},
 			{Name: cst.Username, Usage: "Username for 'password' authentication type"},
 			{Name: cst.Password, Usage: "Password for 'password' authentication type"},
 			{Name: cst.AuthClientID, Usage: "Client ID for 'clientcred' authentication type"},
 			{Name: cst.AuthClientSecret, Usage: "Client Secret for 'clientcred' authentication type"},
 			{Name: cst.AwsProfile, Usage: "AWS profile name for 'aws' authentication type"},
-			{Name: cst.AuthProvider, Usage: "Authentication provider name for 'oidc' authentication type"},
+			{Name: cst.AuthProvider, Usage: "Authentication provider name for 'oidc' and 'jwt' authentication types"},
 			{Name: cst.AuthCert, Usage: "Certificate for 'cert' auth type. Prefix with '@' to denote filepath"},
 			{Name: cst.AuthPrivateKey, Usage: "Private key for 'cert' auth type. Prefix with '@' to denote filepath"},
 
+			// Proxy and TLS.
+			{Name: cst.NetworkProxy, Usage: "Proxy URL for all outbound requests (overrides HTTP_PROXY and HTTPS_PROXY)"},
+			{Name: cst.NetworkNoProxy, Usage: "Comma-separated list of hosts to reach without proxy (overrides NO_PROXY)"},
+			{Name: cst.TLSCAFile, Usage: "Path to a PEM encoded CA bundle trusted in addition to the system CAs"},
+			{Name: cst.TLSCertFile, Usage: "Path to a PEM encoded client certificate for mutual TLS"},
+			{Name: cst.TLSKeyFile, Usage: "Path to a PEM encoded private key of the client certificate"},
+			{Name: cst.TLSInsecure, Usage: "Skip verification of server certificates (insecure, for testing only)", ValueType: "bool"},
+
 			{Name: cst.Dev, Hidden: true, Usage: "Specify dev domain upon initialization (ignored when '--domain' is used)"},
 		},
 		RunFunc: handleCliConfigInitCmd,
@@ -571,19 +579,37 @@ func handleCliConfigInitCmd(vcli vaultcli.CLI, args []string) int {
 	}
 	prf.Set(domain, cst.DomainKey)
 
+	// Proxy and TLS.
+	if err := setNetworkSettings(prf); err != nil {
+		vcli.Out().WriteResponse(nil, errors.New(err))
+		return 1
+	}
+
 	// Check if tenant has been setup yet.
 	var setupRequired bool
 	heartbeatURI := paths.CreateURI("heartbeat", nil)
-	if respData, err := vcli.HTTPClient().DoRequest(http.MethodGet, heartbeatURI, nil); err != nil {
-		ui.Error(fmt.Sprintf("Failed to contact %s to determine if initial admin setup is required.", cst.ProductName))
-		return 1
-	} else {
-		var resp heartbeatResponse
-		if err := json.Unmarshal(respData, &resp); err != nil {
-			ui.Error(fmt.Sprintf("Failed to read the response from %s to determine if initial admin setup is required.", cst.ProductName))
+	for {
+		respData, err := vcli.HTTPClient().DoRequest(http.MethodGet, heartbeatURI, nil)
+		if err == nil {
+			var resp heartbeatResponse
+			if err := json.Unmarshal(respData, &resp); err != nil {
+				ui.Error(fmt.Sprintf("Failed to read the response from %s to determine if initial admin setup is required.", cst.ProductName))
+				return 1
+			} else if resp.StatusCode == 2 {
+				setupRequired = true
+			}
+			break
+		}
+		ui.Error(fmt.Sprintf("Failed to contact %s to determine if initial admin setup is required: %v", cst.ProductName, err))
+
+		// Network issues are often caused by a proxy or a TLS-intercepting middlebox.
+		retry, promptErr := promptNetworkSettings(prf)
+		if promptErr != nil {
+			ui.Error(promptErr.Error())
+			return 1
+		}
+		if !retry {
 			return 1
-		} else if resp.StatusCode == 2 {
-			setupRequired = true
 		}
 	}
 
@@ -605,7 +631,7 @@ func handleCliConfigInitCmd(vcli vaultcli.CLI, args []string) int {
 	prf.Set(storeType, cst.Store, cst.Type)
 	viper.Set(cst.StoreType, storeType)
 
-	if storeType == store.File {
+	if storeType == store.File || storeType == store.FileEncrypted {
 		fileStorePath := viper.GetString(cst.StorePath)
 
 		if fileStorePath == "" {
@@ -831,6 +857,30 @@ func handleCliConfigInitCmd(vcli vaultcli.CLI, args []string) int {
 		viper.Set(cst.AuthProvider, authProvider)
 		viper.Set(cst.Callback, callback)
 
+	case auth.AuthType(authType) == auth.Jwt:
+		if authProvider == "" {
+			authProviderPrompt := &survey.Input{Message: "Please enter auth provider name:"}
+			survErr := survey.AskOne(authProviderPrompt, &authProvider, survey.WithValidator(vaultcli.SurveyRequired))
+			if survErr != nil {
+				vcli.Out().WriteResponse(nil, errors.New(survErr))
+				return utils.GetExecStatus(survErr)
+			}
+			authProvider = strings.TrimSpace(authProvider)
+			viper.Set(cst.AuthProvider, authProvider)
+		}
+		prf.Set(authProvider, cst.NounAuth, cst.DataProvider)
+
+		// Without a file or a variable the token of the CI job or the Kubernetes pod is used.
+		if file := viper.GetString(cst.AuthJwtFile); file != "" {
+			prf.Set(file, cst.NounAuth, cst.JwtProvider, cst.File)
+		}
+		if env := viper.GetString(cst.AuthJwtEnv); env != "" {
+			prf.Set(env, cst.NounAuth, cst.JwtProvider, "env")
+		}
+		if viper.GetBool(cst.AuthJwtReread) {
+			prf.Set("true", cst.NounAuth, cst.JwtProvider, "reread")
+		}
+
 	case auth.AuthType(authType) == auth.Certificate:
 		clientCert := viper.GetString(cst.AuthCert)
 		clientPrivKey := viper.GetString(cst.AuthPrivateKey)
@@ -961,6 +1011,84 @@ func promptAzureClientID() (string, error) {
 	return strings.TrimSpace(clientID), nil
 }
 
+// networkSettings are proxy and TLS settings which are saved to the profile.
+var networkSettings = []struct {
+	key     string
+	message string
+	isFile  bool
+}{
+	{key: cst.NetworkProxy, message: "Please enter proxy URL (leave empty to use HTTP_PROXY and HTTPS_PROXY):"},
+	{key: cst.NetworkNoProxy, message: "Please enter comma-separated hosts to reach without proxy (leave empty to use NO_PROXY):"},
+	{key: cst.TLSCAFile, message: "Please enter path to a PEM encoded CA bundle (leave empty to use system CAs only):", isFile: true},
+	{key: cst.TLSCertFile, message: "Please enter path to a PEM encoded client certificate for mutual TLS (leave empty to skip):", isFile: true},
+	{key: cst.TLSKeyFile, message: "Please enter path to a PEM encoded private key of the client certificate (leave empty to skip):", isFile: true},
+}
+
+// setNetworkSettings copies proxy and TLS settings to the profile. Paths to files are made absolute
+// so that the profile can be used from any working directory.
+func setNetworkSettings(prf *vaultcli.Profile) error {
+	for _, setting := range networkSettings {
+		path := strings.Split(setting.key, ".")
+		val := strings.TrimSpace(viper.GetString(setting.key))
+		if val != "" && setting.isFile {
+			abs, err := filepath.Abs(val)
+			if err != nil {
+				return err
+			}
+			if _, err := os.Stat(abs); err != nil {
+				return fmt.Errorf("invalid value of %s: %w", setting.key, err)
+			}
+			val = abs
+		}
+		viper.Set(setting.key, val)
+		if val != "" || prf.Get(path...) != "" {
+			prf.Set(val, path...)
+		}
+	}
+
+	path := strings.Split(cst.TLSInsecure, ".")
+	if viper.GetBool(cst.TLSInsecure) {
+		prf.Set("true", path...)
+	} else if prf.Get(path...) != "" {
+		prf.Set("false", path...)
+	}
+	return nil
+}
+
+// promptNetworkSettings offers to configure proxy and TLS settings. It returns false if the user declined
+// or the prompt is not available, e.g. when the command is run non-interactively.
+func promptNetworkSettings(prf *vaultcli.Profile) (bool, error) {
+	var configure bool
+	configurePrompt := &survey.Confirm{Message: "Do you want to configure proxy or TLS settings and try again?", Default: false}
+	if survErr := survey.AskOne(configurePrompt, &configure); survErr != nil || !configure {
+		return false, nil
+	}
+
+	for _, setting := range networkSettings {
+		val := viper.GetString(setting.key)
+		prompt := &survey.Input{Message: setting.message, Default: val}
+		if survErr := survey.AskOne(prompt, &val); survErr != nil {
+			return false, survErr
+		}
+		viper.Set(setting.key, val)
+	}
+
+	insecure := viper.GetBool(cst.TLSInsecure)
+	insecurePrompt := &survey.Confirm{
+		Message: "Skip verification of server certificates? This is insecure and should be used for testing only.",
+		Default: insecure,
+	}
+	if survErr := survey.AskOne(insecurePrompt, &insecure); survErr != nil {
+		return false, survErr
+	}
+	viper.Set(cst.TLSInsecure, insecure)
+
+	if err := setNetworkSettings(prf); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
 func promptDomain() (string, error) {
 	var domain string
 	domainPrompt := &survey.Select{
@@ -988,6 +1116,7 @@ func promptStoreType() (string, error) {
 			"None (no caching)",
 			"Pass (Linux only)",
 			"Windows Credential Manager (Windows only)",
+			"Encrypted file store",
 		},
 	}
 	survErr := survey.AskOne(storeTypePrompt, &storeTypeID)
@@ -1003,6 +1132,8 @@ func promptStoreType() (string, error) {
 		return store.PassLinux, nil
 	case 3:
 		return store.WinCred, nil
+	case 4:
+		return store.FileEncrypted, nil
 	default:
 		return "", errors.NewF("Unhandled case for store type id %d", storeTypeID)
 	}
@@ -1017,6 +1148,7 @@ func promptCacheStrategy() (string, error) {
 			"Server then cache",
 			"Cache then server",
 			"Cache then server, but allow expired cache if server unreachable",
+			"Revalidate cache by version with every read",
 		},
 	}
 	survErr := survey.AskOne(cacheStrategyPrompt, &cacheStrategyID)
@@ -1032,6 +1164,8 @@ func promptCacheStrategy() (string, error) {
 		return cst.CacheStrategyCacheThenServer, nil
 	case 3:
 		return cst.CacheStrategyCacheThenServerThenExpired, nil
+	case 4:
+		return cst.CacheStrategyRevalidate, nil
 	default:
 		return "", errors.NewF("Unhandled case for cache strategy id %d", cacheStrategyID)
 	}
@@ -1050,8 +1184,9 @@ func promptAuthType() (string, error) {
 			"GCP (federated)",
 			"OIDC (federated)",
 			"x509 Certificate",
+			"JWT from a file or environment variable (federated)",
 		},
-		PageSize: 8,
+		PageSize: 9,
 	}
 	survErr := survey.AskOne(authTypePrompt, &authTypeID)
 	if survErr != nil {
@@ -1074,6 +1209,8 @@ func promptAuthType() (string, error) {
 		return string(auth.Oidc), nil
 	case 7:
 		return string(auth.Certificate), nil
+	case 8:
+		return string(auth.Jwt), nil
 	default:
 		return "", errors.NewF("Unhandled case for auth type id %d", authTypeID)
 	}
@@ -1,8 +1,14 @@
 package cmd
 
 import (
+	"os"
+	"path/filepath"
 	"testing"
 
+	cst "github.com/DelineaXPM/dsv-cli/constants"
+	"github.com/DelineaXPM/dsv-cli/vaultcli"
+
+	"github.com/spf13/viper"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -40,3 +46,36 @@ func TestGetCliConfigUseProfileCmd(t *testing.T) {
 	_, err := GetCliConfigUseProfileCmd()
 	assert.Nil(t, err)
 }
+
+func TestSetNetworkSettings(t *testing.T) {
+	dir := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "ca.pem"), []byte("ca"), 0o600))
+	wd, _ := os.Getwd()
+	assert.NoError(t, os.Chdir(dir))
+	defer os.Chdir(wd)
+
+	viper.Reset()
+	defer viper.Reset()
+	prf := vaultcli.NewProfile("default")
+	prf.Set("http", cst.HTTPSchemeKey)
+	viper.Set(cst.NetworkProxy, "http://proxy.corp:3128")
+	viper.Set(cst.TLSCAFile, "ca.pem")
+	viper.Set(cst.TLSInsecure, true)
+
+	assert.NoError(t, setNetworkSettings(prf))
+	assert.Equal(t, "http://proxy.corp:3128", prf.Get("network", "proxy"))
+	assert.Equal(t, "http", prf.Get(cst.HTTPSchemeKey), "scheme override must be kept")
+	assert.Equal(t, filepath.Join(dir, "ca.pem"), prf.Get("tls", "caFile"), "path must be absolute")
+	assert.Equal(t, filepath.Join(dir, "ca.pem"), viper.GetString(cst.TLSCAFile))
+	assert.Equal(t, "true", prf.Get("tls", "insecureSkipVerify"))
+	assert.Empty(t, prf.Get("tls", "certFile"))
+
+	viper.Set(cst.TLSInsecure, false)
+	viper.Set(cst.NetworkProxy, "")
+	assert.NoError(t, setNetworkSettings(prf))
+	assert.Equal(t, "false", prf.Get("tls", "insecureSkipVerify"))
+	assert.Empty(t, prf.Get("network", "proxy"))
+
+	viper.Set(cst.TLSCertFile, "missing.crt")
+	assert.ErrorContains(t, setNetworkSettings(prf), cst.TLSCertFile)
+}
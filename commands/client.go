@@ -8,6 +8,7 @@ import (
 
 	cst "github.com/DelineaXPM/dsv-cli/constants"
 	"github.com/DelineaXPM/dsv-cli/errors"
+	"github.com/DelineaXPM/dsv-cli/format"
 	"github.com/DelineaXPM/dsv-cli/internal/predictor"
 	"github.com/DelineaXPM/dsv-cli/paths"
 	"github.com/DelineaXPM/dsv-cli/utils"
@@ -132,12 +133,12 @@ Usage:
    • client search gcp-svc-1
    • client search --role gcp-svc-1 --sort asc
 `,
-		FlagsPredictor: []*predictor.Params{
+		FlagsPredictor: append([]*predictor.Params{
 			{Name: cst.NounRole, Usage: "Role that has attached clients (required)"},
 			{Name: cst.Limit, Shorthand: "l", Usage: cst.LimitHelpMessage},
-			{Name: cst.Cursor, Usage: cst.CursorHelpMessage},
 			{Name: cst.Sort, Usage: cst.SortHelpMessage},
-		},
+		}, paginationFlags()...),
+		Encodings:     format.ListEncodings,
 		MinNumberArgs: 1,
 		RunFunc:       handleClientSearchCmd,
 	})
@@ -228,13 +229,18 @@ func handleClientSearchCmd(vcli vaultcli.CLI, args []string) int {
 		return utils.GetExecStatus(err)
 	}
 
-	data, apiErr := clientSearch(vcli, &clientSearchParams{
-		role:   role,
-		limit:  limit,
-		cursor: cursor,
-		sort:   sort,
+	params := &clientSearchParams{
+		role:  role,
+		limit: limit,
+		sort:  sort,
+	}
+	apiErr := writePages(vcli, cursor, func(cursor string) ([]byte, *errors.ApiError) {
+		params.cursor = cursor
+		return clientSearch(vcli, params)
 	})
-	vcli.Out().WriteResponse(data, apiErr)
+	if apiErr != nil {
+		vcli.Out().WriteResponse(nil, apiErr)
+	}
 	return utils.GetExecStatus(apiErr)
 }
 
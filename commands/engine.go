@@ -7,6 +7,7 @@ import (
 
 	cst "github.com/DelineaXPM/dsv-cli/constants"
 	"github.com/DelineaXPM/dsv-cli/errors"
+	"github.com/DelineaXPM/dsv-cli/format"
 	"github.com/DelineaXPM/dsv-cli/internal/predictor"
 	"github.com/DelineaXPM/dsv-cli/paths"
 	"github.com/DelineaXPM/dsv-cli/utils"
@@ -66,7 +67,8 @@ Usage:
 			{Name: cst.Sort, Usage: cst.SortHelpMessage, Default: "desc"},
 			{Name: cst.SortedBy, Usage: "Sort by name or created field (optional)", Default: "created"},
 		},
-		RunFunc: handleEngineListCmd,
+		RunFunc:   handleEngineListCmd,
+		Encodings: format.ListEncodings,
 	})
 }
 
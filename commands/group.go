@@ -9,6 +9,7 @@ import (
 
 	cst "github.com/DelineaXPM/dsv-cli/constants"
 	"github.com/DelineaXPM/dsv-cli/errors"
+	"github.com/DelineaXPM/dsv-cli/format"
 	"github.com/DelineaXPM/dsv-cli/internal/predictor"
 	"github.com/DelineaXPM/dsv-cli/paths"
 	"github.com/DelineaXPM/dsv-cli/utils"
@@ -183,13 +184,13 @@ Usage:
    • user groups --username kadmin
    • user groups --username kadmin --sort asc --limit 5 --query adm
 `,
-		FlagsPredictor: []*predictor.Params{
+		FlagsPredictor: append([]*predictor.Params{
 			{Name: cst.DataUsername, Usage: "Username of user (required)"},
 			{Name: cst.Query, Shorthand: "q", Usage: "Partial search by path (optional)"},
 			{Name: cst.Limit, Shorthand: "l", Usage: cst.LimitHelpMessage},
-			{Name: cst.Cursor, Usage: cst.CursorHelpMessage},
 			{Name: cst.Sort, Usage: cst.SortHelpMessage, Default: "desc"},
-		},
+		}, paginationFlags()...),
+		Encodings:     format.ListEncodings,
 		MinNumberArgs: 1,
 		RunFunc:       handleUsersGroupReadCmd,
 	})
@@ -206,14 +207,14 @@ Usage:
    • group search --query adm --limit 10
    • group search --sort asc --sorted-by created
 `,
-		FlagsPredictor: []*predictor.Params{
+		FlagsPredictor: append([]*predictor.Params{
 			{Name: cst.Query, Shorthand: "q", Usage: fmt.Sprintf("%s of %ss to fetch (optional)", strings.Title(cst.Query), cst.NounGroup)},
 			{Name: cst.Limit, Shorthand: "l", Usage: cst.LimitHelpMessage},
-			{Name: cst.Cursor, Usage: cst.CursorHelpMessage},
 			{Name: cst.Sort, Usage: cst.SortHelpMessage},
 			{Name: cst.SortedBy, Usage: "Sort by name, created or lastModified field (optional)", Default: "lastModified"},
-		},
-		RunFunc: handleGroupSearchCmd,
+		}, paginationFlags()...),
+		Encodings: format.ListEncodings,
+		RunFunc:   handleGroupSearchCmd,
 	})
 }
 
@@ -378,14 +379,19 @@ func handleUsersGroupReadCmd(vcli vaultcli.CLI, args []string) int {
 	cursor := viper.GetString(cst.Cursor)
 	sort := viper.GetString(cst.Sort)
 
-	data, apiErr := userGroupsRead(vcli, &userGroupsSearchParams{
+	params := &userGroupsSearchParams{
 		username: username,
 		query:    query,
 		limit:    limit,
-		cursor:   cursor,
 		sort:     sort,
+	}
+	apiErr := writePages(vcli, cursor, func(cursor string) ([]byte, *errors.ApiError) {
+		params.cursor = cursor
+		return userGroupsRead(vcli, params)
 	})
-	vcli.Out().WriteResponse(data, apiErr)
+	if apiErr != nil {
+		vcli.Out().WriteResponse(nil, apiErr)
+	}
 	return utils.GetExecStatus(apiErr)
 }
 
@@ -447,14 +453,19 @@ func handleGroupSearchCmd(vcli vaultcli.CLI, args []string) int {
 		query = args[0]
 	}
 
-	data, apiErr := groupSearch(vcli, &groupSearchParams{
+	params := &groupSearchParams{
 		query:    query,
 		limit:    limit,
-		cursor:   cursor,
 		sort:     sort,
 		sortedBy: sortedBy,
+	}
+	apiErr := writePages(vcli, cursor, func(cursor string) ([]byte, *errors.ApiError) {
+		params.cursor = cursor
+		return groupSearch(vcli, params)
 	})
-	vcli.Out().WriteResponse(data, apiErr)
+	if apiErr != nil {
+		vcli.Out().WriteResponse(nil, apiErr)
+	}
 	return utils.GetExecStatus(apiErr)
 }
 
@@ -5,6 +5,7 @@ import (
 	"strings"
 
 	cst "github.com/DelineaXPM/dsv-cli/constants"
+	"github.com/DelineaXPM/dsv-cli/format"
 	"github.com/DelineaXPM/dsv-cli/internal/predictor"
 	"github.com/DelineaXPM/dsv-cli/utils"
 	"github.com/DelineaXPM/dsv-cli/vaultcli"
@@ -23,11 +24,13 @@ Usage:
    • home %[3]s
    • home --path %[3]s
 `, cst.NounHome, cst.ProductName, cst.ExamplePath),
-		FlagsPredictor: []*predictor.Params{
+		FlagsPredictor: append([]*predictor.Params{
 			{Name: cst.Path, Shorthand: "r", Usage: fmt.Sprintf("Target %s to a %s (required)", cst.Path, cst.NounSecret), Predictor: predictor.NewSecretPathPredictorDefault()},
 			{Name: cst.Version, Usage: "List the current and last (n) versions"},
-		},
+			{Name: cst.AtVersion, Usage: fmt.Sprintf("Version of the %s to read [default:latest]", cst.NounSecret)},
+		}, secretEncodingFlags()...),
 		MinNumberArgs: 1,
+		Encodings:     format.SecretEncodings,
 		RunFunc: func(vcli vaultcli.CLI, args []string) int {
 			path := viper.GetString(cst.Path)
 			if path == "" && len(args) > 0 && !strings.HasPrefix(args[0], "-") {
@@ -48,12 +51,15 @@ func GetHomeReadCmd() (cli.Command, error) {
 		HelpText: fmt.Sprintf(`Read a a secret in %[2]s
 Usage:
    • home %[1]s %[4]s
-   • home %[1]s --path %[4]s`, cst.Read, cst.NounHome, cst.ProductName, cst.ExamplePath),
-		FlagsPredictor: []*predictor.Params{
+   • home %[1]s --path %[4]s
+   • home %[1]s %[4]s -e shell`, cst.Read, cst.NounHome, cst.ProductName, cst.ExamplePath),
+		FlagsPredictor: append([]*predictor.Params{
 			{Name: cst.Path, Shorthand: "r", Usage: fmt.Sprintf("Target %s to a %s (required)", cst.Path, cst.NounSecret), Predictor: predictor.NewSecretPathPredictorDefault()},
 			{Name: cst.Version, Usage: "List the current and last (n) versions"},
-		},
+			{Name: cst.AtVersion, Usage: fmt.Sprintf("Version of the %s to read [default:latest]", cst.NounSecret)},
+		}, secretEncodingFlags()...),
 		MinNumberArgs: 1,
+		Encodings:     format.SecretEncodings,
 		RunFunc:       handleHomeRead,
 	})
 }
@@ -161,6 +167,7 @@ func GetHomeSearchCmd() (cli.Command, error) {
 	return NewCommand(CommandArgs{
 		Path:           []string{cst.NounHome, cst.Search},
 		FlagsPredictor: GetSearchOpWrappers(),
+		Encodings:      format.ListEncodings,
 		SynopsisText:   "Search for secrets in home",
 		HelpText: fmt.Sprintf(`Search for a %[2]s from %[3]s
 
@@ -210,6 +217,14 @@ Usage:
 	})
 }
 
+func GetHomeHistoryCmd() (cli.Command, error) {
+	return getSecretHistoryCmd(cst.NounHome, cst.ExamplePath)
+}
+
+func GetHomeDiffCmd() (cli.Command, error) {
+	return getSecretDiffCmd(cst.NounHome, cst.ExamplePath)
+}
+
 func handleHomeRead(vcli vaultcli.CLI, args []string) int {
 	return handleSecretReadCmd(vcli, cst.NounHome, args)
 }
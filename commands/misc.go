@@ -5,6 +5,7 @@ import (
 	"strings"
 
 	"github.com/DelineaXPM/dsv-cli/auth"
+	"github.com/DelineaXPM/dsv-cli/internal/predictor"
 	"github.com/DelineaXPM/dsv-cli/vaultcli"
 
 	cst "github.com/DelineaXPM/dsv-cli/constants"
@@ -17,9 +18,21 @@ func GetWhoAmICmd() (cli.Command, error) {
 	return NewCommand(CommandArgs{
 		Path:         []string{cst.NounWhoAmI},
 		SynopsisText: "Show current identity",
-		HelpText:     fmt.Sprintf("%s returns the current user identity, accounting for config, env, and flags", cst.NounWhoAmI),
-		NoPreAuth:    true,
-		RunFunc:      handleWhoAmICmd,
+		HelpText: fmt.Sprintf(`%[1]s returns the current user identity, accounting for config, env, and flags
+
+With --%[2]s it also shows all claims of the access token, the remaining lifetime of the access and
+refresh tokens, groups of the user and policy statements which subjects match the identity or its groups.
+Groups and policies are only listed if the identity is allowed to read them.
+
+Usage:
+   • %[1]s
+   • %[1]s --%[2]s
+`, cst.NounWhoAmI, cst.Detailed),
+		FlagsPredictor: []*predictor.Params{
+			{Name: cst.Detailed, Usage: "Show token claims and lifetime, groups and policies of the identity", ValueType: "bool"},
+		},
+		NoPreAuth: true,
+		RunFunc:   handleWhoAmICmd,
 	})
 }
 
@@ -34,6 +47,9 @@ func GetEvaluateFlagCmd() (cli.Command, error) {
 }
 
 func handleWhoAmICmd(vcli vaultcli.CLI, args []string) int {
+	if viper.GetBool(cst.Detailed) {
+		return wrapError(handleWhoAmIDetailedCmd)(vcli, args)
+	}
 	subject, err := auth.GetCurrentIdentity()
 	if err == nil {
 		vcli.Out().WriteResponse([]byte(subject), nil)
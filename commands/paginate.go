@@ -0,0 +1,163 @@
+package cmd
+
+import (
+	"encoding/json"
+	"strconv"
+
+	cst "github.com/DelineaXPM/dsv-cli/constants"
+	"github.com/DelineaXPM/dsv-cli/errors"
+	"github.com/DelineaXPM/dsv-cli/internal/predictor"
+	"github.com/DelineaXPM/dsv-cli/vaultcli"
+
+	"github.com/spf13/viper"
+)
+
+// pageFetcher returns the page of search or list results which starts at the cursor.
+type pageFetcher func(cursor string) ([]byte, *errors.ApiError)
+
+// paginationFlags are flags of search and list commands which return results page by page.
+func paginationFlags() []*predictor.Params {
+	return []*predictor.Params{
+		{Name: cst.Cursor, Usage: cst.CursorHelpMessage},
+		{Name: cst.All, Usage: "Follow cursors and return results from all pages (optional)", ValueType: "bool"},
+		{Name: cst.MaxResults, Usage: "Follow cursors until the number of results is reached (optional)"},
+	}
+}
+
+// writePages writes search or list results starting at the cursor. Without --all and --max-results
+// it writes a single page. Otherwise it follows cursors and merges "data" arrays of all pages into
+// the first page, or with the ndjson encoding writes every page as soon as it is fetched.
+func writePages(vcli vaultcli.CLI, cursor string, fetch pageFetcher) *errors.ApiError {
+	all := viper.GetBool(cst.All)
+	maxResults := 0
+	if v := viper.GetString(cst.MaxResults); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			return errors.NewF("error: --max-results must be a positive number, got %q", v)
+		}
+		maxResults = n
+	}
+	if !all && maxResults == 0 {
+		data, apiErr := fetch(cursor)
+		if apiErr != nil {
+			return apiErr
+		}
+		vcli.Out().WriteResponse(data, nil)
+		return nil
+	}
+
+	stream := viper.GetString(cst.Encoding) == cst.NDJson
+	var first []byte
+	var merged map[string]json.RawMessage
+	items := []json.RawMessage{}
+	count := 0
+	apiErr := followPages(cursor, fetch, func(data []byte, page map[string]json.RawMessage, pageItems []json.RawMessage) (bool, *errors.ApiError) {
+		truncated := maxResults > 0 && count+len(pageItems) > maxResults
+		if truncated {
+			pageItems = pageItems[:maxResults-count]
+		}
+		count += len(pageItems)
+
+		if stream {
+			if truncated {
+				var apiErr *errors.ApiError
+				if data, apiErr = setPageData(page, pageItems, len(pageItems)); apiErr != nil {
+					return false, apiErr
+				}
+			}
+			vcli.Out().WriteResponse(data, nil)
+		} else {
+			if merged == nil {
+				first, merged = data, page
+			}
+			items = append(items, pageItems...)
+		}
+		return maxResults == 0 || count < maxResults, nil
+	})
+	if apiErr != nil || stream {
+		return apiErr
+	}
+
+	if _, ok := merged["data"]; !ok {
+		// Not a list of results, e.g. an empty response.
+		vcli.Out().WriteResponse(first, nil)
+		return nil
+	}
+	delete(merged, cst.Cursor)
+	data, apiErr := setPageData(merged, items, count)
+	if apiErr != nil {
+		return apiErr
+	}
+	vcli.Out().WriteResponse(data, nil)
+	return nil
+}
+
+// readPages fetches all pages of search or list results and returns items of their "data" arrays.
+func readPages(fetch pageFetcher) ([]json.RawMessage, *errors.ApiError) {
+	items := []json.RawMessage{}
+	apiErr := followPages("", fetch, func(_ []byte, _ map[string]json.RawMessage, pageItems []json.RawMessage) (bool, *errors.ApiError) {
+		items = append(items, pageItems...)
+		return true, nil
+	})
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	return items, nil
+}
+
+// followPages fetches pages starting at the cursor and calls visit with every page and the items of its
+// "data" array. It stops when a page has no next cursor, the cursor repeats, a page is empty or visit returns false.
+func followPages(
+	cursor string, fetch pageFetcher,
+	visit func(data []byte, page map[string]json.RawMessage, items []json.RawMessage) (bool, *errors.ApiError),
+) *errors.ApiError {
+	for {
+		data, apiErr := fetch(cursor)
+		if apiErr != nil {
+			return apiErr
+		}
+		page := map[string]json.RawMessage{}
+		if err := json.Unmarshal(data, &page); err != nil {
+			return errors.New(err).Grow("Failed to parse results")
+		}
+		var items []json.RawMessage
+		if raw, ok := page["data"]; ok {
+			if err := json.Unmarshal(raw, &items); err != nil {
+				return errors.New(err).Grow("Failed to parse results")
+			}
+		}
+		var next string
+		if raw, ok := page[cst.Cursor]; ok {
+			_ = json.Unmarshal(raw, &next)
+		}
+
+		more, apiErr := visit(data, page, items)
+		if apiErr != nil {
+			return apiErr
+		}
+		if !more || next == "" || next == cursor || len(items) == 0 {
+			return nil
+		}
+		cursor = next
+	}
+}
+
+// setPageData replaces results of the page and returns the page as JSON.
+func setPageData(page map[string]json.RawMessage, items []json.RawMessage, length int) ([]byte, *errors.ApiError) {
+	if items == nil {
+		items = []json.RawMessage{}
+	}
+	raw, err := json.Marshal(items)
+	if err != nil {
+		return nil, errors.New(err)
+	}
+	page["data"] = raw
+	if _, ok := page["length"]; ok {
+		page["length"] = json.RawMessage(strconv.Itoa(length))
+	}
+	data, err := json.Marshal(page)
+	if err != nil {
+		return nil, errors.New(err)
+	}
+	return data, nil
+}
@@ -0,0 +1,155 @@
+package cmd
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"testing"
+
+	cst "github.com/DelineaXPM/dsv-cli/constants"
+	"github.com/DelineaXPM/dsv-cli/errors"
+	"github.com/DelineaXPM/dsv-cli/tests/fake"
+	"github.com/DelineaXPM/dsv-cli/vaultcli"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWritePages(t *testing.T) {
+	pages := map[string]string{
+		"":   `{"data":[{"name":"a"},{"name":"b"}],"cursor":"c1","length":2,"limit":2}`,
+		"c1": `{"data":[{"name":"c"},{"name":"d"}],"cursor":"c2","length":2,"limit":2}`,
+		"c2": `{"data":[{"name":"e"}],"cursor":"","length":1,"limit":2}`,
+	}
+
+	testCases := []struct {
+		name     string
+		flags    map[string]interface{}
+		cursor   string
+		expected []string
+		fetched  []string
+		err      string
+	}{
+		{
+			name:     "single page",
+			expected: []string{pages[""]},
+			fetched:  []string{""},
+		},
+		{
+			name:     "single page from cursor",
+			cursor:   "c1",
+			expected: []string{pages["c1"]},
+			fetched:  []string{"c1"},
+		},
+		{
+			name:     "all",
+			flags:    map[string]interface{}{cst.All: true},
+			expected: []string{`{"data":[{"name":"a"},{"name":"b"},{"name":"c"},{"name":"d"},{"name":"e"}],"length":5,"limit":2}`},
+			fetched:  []string{"", "c1", "c2"},
+		},
+		{
+			name:     "max results",
+			flags:    map[string]interface{}{cst.MaxResults: "3"},
+			expected: []string{`{"data":[{"name":"a"},{"name":"b"},{"name":"c"}],"length":3,"limit":2}`},
+			fetched:  []string{"", "c1"},
+		},
+		{
+			name:  "ndjson",
+			flags: map[string]interface{}{cst.All: true, cst.Encoding: cst.NDJson, cst.MaxResults: "3"},
+			expected: []string{
+				pages[""],
+				`{"cursor":"c2","data":[{"name":"c"}],"length":1,"limit":2}`,
+			},
+			fetched: []string{"", "c1"},
+		},
+		{
+			name:  "invalid max results",
+			flags: map[string]interface{}{cst.MaxResults: "many"},
+			err:   `--max-results must be a positive number, got "many"`,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			viper.Reset()
+			defer viper.Reset()
+			for k, v := range tt.flags {
+				viper.Set(k, v)
+			}
+
+			var out []string
+			outClient := &fake.FakeOutClient{}
+			outClient.WriteResponseStub = func(data []byte, apiErr *errors.ApiError) { out = append(out, string(data)) }
+			vcli, err := vaultcli.NewWithOpts(vaultcli.WithOutClient(outClient))
+			assert.NoError(t, err)
+
+			fetched := []string{}
+			apiErr := writePages(vcli, tt.cursor, func(cursor string) ([]byte, *errors.ApiError) {
+				fetched = append(fetched, cursor)
+				return []byte(pages[cursor]), nil
+			})
+			if tt.err != "" {
+				assert.ErrorContains(t, apiErr, tt.err)
+				return
+			}
+			assert.Nil(t, apiErr)
+			assert.Equal(t, tt.expected, out)
+			assert.Equal(t, tt.fetched, fetched)
+		})
+	}
+}
+
+func TestReadPages(t *testing.T) {
+	testCases := []struct {
+		name    string
+		pages   map[string]string
+		want    string
+		fetched []string
+	}{
+		{"single page", map[string]string{"": `{"data":[1,2]}`}, "[1 2]", []string{""}},
+		{"next pages", map[string]string{"": `{"data":[1],"cursor":"a"}`, "a": `{"data":[2],"cursor":"b"}`, "b": `{"data":[3],"cursor":""}`}, "[1 2 3]", []string{"", "a", "b"}},
+		{"repeated cursor", map[string]string{"": `{"data":[1],"cursor":"a"}`, "a": `{"data":[2],"cursor":"a"}`}, "[1 2]", []string{"", "a"}},
+		{"empty page", map[string]string{"": `{"data":[1],"cursor":"a"}`, "a": `{"data":[],"cursor":"b"}`}, "[1]", []string{"", "a"}},
+	}
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			var fetched []string
+			items, apiErr := readPages(func(cursor string) ([]byte, *errors.ApiError) {
+				fetched = append(fetched, cursor)
+				return []byte(tt.pages[cursor]), nil
+			})
+			assert.Nil(t, apiErr)
+			got := make([]string, 0, len(items))
+			for _, item := range items {
+				got = append(got, string(item))
+			}
+			assert.Equal(t, tt.want, fmt.Sprint(got))
+			assert.Equal(t, tt.fetched, fetched)
+		})
+	}
+}
+
+func TestHandleRoleSearchCmdAll(t *testing.T) {
+	viper.Reset()
+	defer viper.Reset()
+	viper.Set(cst.Tenant, "tenant")
+	viper.Set(cst.All, true)
+
+	var out []byte
+	outClient := &fake.FakeOutClient{}
+	outClient.WriteResponseStub = func(data []byte, apiErr *errors.ApiError) { out = data }
+	httpClient := &fake.FakeClient{}
+	httpClient.DoRequestStub = func(method string, uri string, body interface{}) ([]byte, *errors.ApiError) {
+		u, _ := url.Parse(uri)
+		if cursor := u.Query().Get(cst.Cursor); cursor != "" {
+			return []byte(fmt.Sprintf(`{"data":[{"name":"%s"}]}`, strings.ToUpper(cursor))), nil
+		}
+		return []byte(`{"data":[{"name":"first"}],"cursor":"next"}`), nil
+	}
+	vcli, err := vaultcli.NewWithOpts(vaultcli.WithHTTPClient(httpClient), vaultcli.WithOutClient(outClient))
+	assert.NoError(t, err)
+
+	assert.Equal(t, 0, handleRoleSearchCmd(vcli, nil))
+	assert.Equal(t, `{"data":[{"name":"first"},{"name":"NEXT"}]}`, string(out))
+	assert.Equal(t, 2, httpClient.DoRequestCallCount())
+}
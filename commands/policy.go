@@ -10,6 +10,7 @@ import (
 
 	cst "github.com/DelineaXPM/dsv-cli/constants"
 	"github.com/DelineaXPM/dsv-cli/errors"
+	"github.com/DelineaXPM/dsv-cli/format"
 	"github.com/DelineaXPM/dsv-cli/internal/predictor"
 	"github.com/DelineaXPM/dsv-cli/paths"
 	"github.com/DelineaXPM/dsv-cli/utils"
@@ -33,6 +34,7 @@ Usage:
 		FlagsPredictor: []*predictor.Params{
 			{Name: cst.Path, Shorthand: "r", Usage: fmt.Sprintf("Target %s to a %s", cst.Path, cst.NounPolicy)},
 			{Name: cst.Version, Usage: "List the current and last (n) versions"},
+			{Name: cst.AtVersion, Usage: fmt.Sprintf("Version of the %s to read [default:latest]", cst.NounPolicy)},
 		},
 		MinNumberArgs: 1,
 		RunFunc: func(vcli vaultcli.CLI, args []string) int {
@@ -51,7 +53,7 @@ Usage:
 func GetPolicyReadCmd() (cli.Command, error) {
 	return NewCommand(CommandArgs{
 		Path:         []string{cst.NounPolicy, cst.Read},
-		SynopsisText: "policy read (<path> | (--path | -r) <path>) [--version <n>]",
+		SynopsisText: "policy read (<path> | (--path | -r) <path>) [--version <n> | --at-version <n>]",
 		HelpText: fmt.Sprintf(`Read a policy
 
 Usage:
@@ -61,6 +63,7 @@ Usage:
 		FlagsPredictor: []*predictor.Params{
 			{Name: cst.Path, Shorthand: "r", Usage: fmt.Sprintf("Target %s to a %s", cst.Path, cst.NounPolicy)},
 			{Name: cst.Version, Usage: "List the current and last (n) versions"},
+			{Name: cst.AtVersion, Usage: fmt.Sprintf("Version of the %s to read [default:latest]", cst.NounPolicy)},
 		},
 		MinNumberArgs: 1,
 		RunFunc:       handlePolicyReadCmd,
@@ -124,15 +127,18 @@ Usage:
 func GetPolicyCreateCmd() (cli.Command, error) {
 	return NewCommand(CommandArgs{
 		Path:         []string{cst.NounPolicy, cst.Create},
-		SynopsisText: "policy create (<path> | --path|-r) ((--data|-d) | --subjects --actions --effect[default:allow] --resources [--desc] [--cidr])",
+		SynopsisText: "policy create (<path> | --path|-r) ((--data|-d) | --file | --subjects --actions --effect[default:allow] --resources [--desc] [--cidr])",
 		HelpText: fmt.Sprintf(`Add a policy
 
+%[3]s
 Usage:
    • policy create %[1]s --subjects '<users:kadmin|groups:admin>',users:userA --actions create,update --cidr 10.10.0.15/24
    • policy create --path %[1]s --data %[2]s
-`, cst.ExamplePolicyPath, cst.ExampleDataPath),
+   • policy create --file policy.yml
+`, cst.ExamplePolicyPath, cst.ExampleDataPath, policyFileHelp),
 		FlagsPredictor: []*predictor.Params{
 			{Name: cst.Data, Shorthand: "d", Usage: fmt.Sprintf("%s to be stored in a %s. Prefix with '@' to denote filepath (required)", strings.Title(cst.Data), cst.NounPolicy), Predictor: predictor.NewPrefixFilePredictor("*")},
+			{Name: cst.File, Usage: fmt.Sprintf("YAML file with the statements of the %s", cst.NounPolicy), Predictor: predictor.NewPrefixFilePredictor("*")},
 			{Name: cst.Path, Shorthand: "r", Usage: fmt.Sprintf("Target %s to a %s (required)", cst.Path, cst.NounPolicy)},
 			{Name: cst.DataAction, Usage: fmt.Sprintf("Policy actions to be stored in a %s (required, regex and list supported)(required)", cst.NounPolicy), Predictor: predictor.ActionTypePredictor{}},
 			{Name: cst.DataEffect, Usage: fmt.Sprintf("Policy effect to be stored in a %s. Defaults to allow if not specified", cst.NounPolicy), Default: "allow", Predictor: predictor.EffectTypePredictor{}},
@@ -149,17 +155,20 @@ Usage:
 func GetPolicyUpdateCmd() (cli.Command, error) {
 	return NewCommand(CommandArgs{
 		Path:         []string{cst.NounPolicy, cst.Update},
-		SynopsisText: "policy update (<path> | (--path | -r) <path>) ((--data|-d) | --subjects --actions --effect[default:allow] --resources [--desc] [--cidr])",
+		SynopsisText: "policy update (<path> | (--path | -r) <path>) ((--data|-d) | --file | --subjects --actions --effect[default:allow] --resources [--desc] [--cidr])",
 		HelpText: fmt.Sprintf(`Update a policy
 
 Policy Updates are all or nothing, so required fields must be included in the update and if optional fields are not included, they are deleted or go to default.
 
+%[3]s
 Usage:
    • policy update %[1]s --subjects 'users:<kadmin|groups:admin>',users:userA --actions update --cidr 192.168.0.15/24
    • policy update --path %[1]s --data %[2]s
-`, cst.ExamplePolicyPath, cst.ExampleDataPath),
+   • policy diff %[1]s --file policy.yml && policy update %[1]s --file policy.yml
+`, cst.ExamplePolicyPath, cst.ExampleDataPath, policyFileHelp),
 		FlagsPredictor: []*predictor.Params{
 			{Name: cst.Data, Shorthand: "d", Usage: fmt.Sprintf("%s to be stored in a %s. Prefix with '@' to denote filepath (required)", strings.Title(cst.Data), cst.NounPolicy), Predictor: predictor.NewPrefixFilePredictor("*")},
+			{Name: cst.File, Usage: fmt.Sprintf("YAML file with the statements of the %s", cst.NounPolicy), Predictor: predictor.NewPrefixFilePredictor("*")},
 			{Name: cst.Path, Shorthand: "r", Usage: fmt.Sprintf("Target %s to a %s (required)", cst.Path, cst.NounPolicy)},
 			{Name: cst.DataAction, Usage: fmt.Sprintf("Policy actions to be stored in a %s (required, regex and list supported)(required)", cst.NounPolicy), Predictor: predictor.ActionTypePredictor{}},
 			{Name: cst.DataEffect, Usage: fmt.Sprintf("Policy effect to be stored in a %s. Defaults to allow if not specified", cst.NounPolicy), Default: "allow", Predictor: predictor.EffectTypePredictor{}},
@@ -192,6 +201,74 @@ Usage:
 	})
 }
 
+func GetPolicyHistoryCmd() (cli.Command, error) {
+	return NewCommand(CommandArgs{
+		Path:         []string{cst.NounPolicy, cst.History},
+		SynopsisText: "policy history (<path> | (--path | -r) <path>) [(--limit | -l) <n>]",
+		HelpText: fmt.Sprintf(`List versions of a policy with the time and the author of every change, newest first
+
+Usage:
+   • policy history %[1]s
+   • policy history --path %[1]s --limit 5
+`, cst.ExamplePolicyPath),
+		FlagsPredictor: []*predictor.Params{
+			{Name: cst.Path, Shorthand: "r", Usage: fmt.Sprintf("Target %s to a %s (required)", cst.Path, cst.NounPolicy)},
+			{Name: cst.Limit, Shorthand: "l", Usage: "Maximum number of versions to list [default:all]"},
+		},
+		MinNumberArgs: 1,
+		RunFuncE: func(vcli vaultcli.CLI, args []string) error {
+			path, status := getPolicyParams(args)
+			if status != 0 {
+				return errors.NewF("error: must specify --%s", cst.Path)
+			}
+			return handleVersionHistoryCmd(vcli, policyVersionSource(vcli, path))
+		},
+	})
+}
+
+func GetPolicyDiffCmd() (cli.Command, error) {
+	return NewCommand(CommandArgs{
+		Path:         []string{cst.NounPolicy, cst.Diff},
+		SynopsisText: "policy diff (<path> | (--path | -r) <path>) ([--from <n>] [--to <n>]) | [<path> | (--path | -r) <path>] --file <file> [--from <n>]",
+		HelpText: fmt.Sprintf(`Show permissions which differ between two versions of a policy or between a policy and a policy file
+
+By default the latest version is compared with the one before it. With --file the statements of the
+latest version (or of the version given with --from) are compared with the statements in the file.
+The path can then be omitted if the file sets it.
+Statements are paired by content, then by description, then by subjects and effect and then by position,
+and every added, removed or changed statement is listed together with the names of changed fields.
+
+Usage:
+   • policy diff %[1]s
+   • policy diff --path %[1]s --from 1 --to 3
+   • policy diff %[1]s --file policy.yml
+   • policy diff --file policy.yml
+`, cst.ExamplePolicyPath),
+		FlagsPredictor: []*predictor.Params{
+			{Name: cst.Path, Shorthand: "r", Usage: fmt.Sprintf("Target %s to a %s (required)", cst.Path, cst.NounPolicy)},
+			{Name: cst.From, Usage: "Version to compare from [default:the version before --to, or the latest version with --file]"},
+			{Name: cst.To, Usage: "Version to compare to [default:latest]"},
+			{Name: cst.File, Usage: fmt.Sprintf("YAML file with the statements of the %s to compare to", cst.NounPolicy), Predictor: predictor.NewPrefixFilePredictor("*")},
+		},
+		RunFuncE: func(vcli vaultcli.CLI, args []string) error {
+			path, doc, err := getPolicyFileParams(args)
+			if err != nil {
+				return err
+			}
+			if path == "" {
+				return errors.NewF("error: must specify --%s", cst.Path)
+			}
+			if doc != nil {
+				if viper.GetString(cst.To) != "" {
+					return errors.NewF("error: --%s and --%s cannot be used together", cst.To, cst.File)
+				}
+				return handlePolicyFileDiff(vcli, path, doc)
+			}
+			return handleVersionDiffCmd(vcli, policyVersionSource(vcli, path), policyDiffFields, false)
+		},
+	})
+}
+
 func GetPolicySearchCmd() (cli.Command, error) {
 	return NewCommand(CommandArgs{
 		Path:         []string{cst.NounPolicy, cst.Search},
@@ -202,14 +279,14 @@ Usage:
    • policy search %[1]s
    • policy search --query %[1]s
 `, cst.ExamplePolicySearch),
-		FlagsPredictor: []*predictor.Params{
+		FlagsPredictor: append([]*predictor.Params{
 			{Name: cst.Query, Shorthand: "q", Usage: fmt.Sprintf("Filter %s of items to fetch (required)", strings.Title(cst.Query))},
 			{Name: cst.Limit, Shorthand: "l", Usage: cst.LimitHelpMessage},
-			{Name: cst.Cursor, Usage: cst.CursorHelpMessage},
 			{Name: cst.Sort, Usage: cst.SortHelpMessage},
 			{Name: cst.SortedBy, Usage: "Sort by lastModified or created field (optional)", Default: "lastModified"},
-		},
-		RunFunc: handlePolicySearchCmd,
+		}, paginationFlags()...),
+		Encodings: format.ListEncodings,
+		RunFunc:   handlePolicySearchCmd,
 	})
 }
 
@@ -219,13 +296,23 @@ func handlePolicyReadCmd(vcli vaultcli.CLI, args []string) int {
 		return status
 	}
 
-	path = paths.ProcessResource(path)
-	version := viper.GetString(cst.Version)
-	if strings.TrimSpace(version) != "" {
-		path = fmt.Sprint(path, "/", cst.Version, "/", version)
+	version := strings.TrimSpace(viper.GetString(cst.Version))
+	atVersion := strings.TrimSpace(viper.GetString(cst.AtVersion))
+	if version != "" && atVersion != "" {
+		vcli.Out().FailF("error: --%s and --%s cannot be used together", cst.Version, strings.ReplaceAll(cst.AtVersion, ".", "-"))
+		return 1
+	}
+	var data []byte
+	var apiErr *errors.ApiError
+	if atVersion != "" {
+		data, apiErr = readVersion(policyVersionSource(vcli, path), atVersion)
+	} else {
+		path = paths.ProcessResource(path)
+		if version != "" {
+			path = fmt.Sprint(path, "/", cst.Version, "/", version)
+		}
+		data, apiErr = policyRead(vcli, path)
 	}
-
-	data, apiErr := policyRead(vcli, path)
 	vcli.Out().WriteResponse(data, apiErr)
 	return utils.GetExecStatus(apiErr)
 }
@@ -307,9 +394,13 @@ func handlePolicyRollbackCmd(vcli vaultcli.CLI, args []string) int {
 }
 
 func handlePolicyCreateCmd(vcli vaultcli.CLI, args []string) int {
-	path, status := getPolicyParams(args)
-	if status != 0 {
-		return status
+	path, doc, err := getPolicyFileParams(args)
+	if err != nil {
+		vcli.Out().Fail(err)
+		return 1
+	}
+	if path == "" {
+		return cli.RunResultHelp
 	}
 	if err := vaultcli.ValidatePath(path); err != nil {
 		vcli.Out().FailF("Path %q is invalid: %v", path, err)
@@ -319,7 +410,14 @@ func handlePolicyCreateCmd(vcli vaultcli.CLI, args []string) int {
 	data := viper.GetString(cst.Data)
 	encoding := viper.GetString(cst.Encoding)
 
-	if data == "" {
+	switch {
+	case doc != nil:
+		if data, err = policyFileData(doc); err != nil {
+			vcli.Out().Fail(err)
+			return 1
+		}
+		encoding = cst.Json
+	case data == "":
 		var err *errors.ApiError
 		data, err = policyBuildFromFlags()
 		if err != nil {
@@ -340,15 +438,26 @@ func handlePolicyCreateCmd(vcli vaultcli.CLI, args []string) int {
 }
 
 func handlePolicyUpdateCmd(vcli vaultcli.CLI, args []string) int {
-	path, status := getPolicyParams(args)
-	if status != 0 {
-		return status
+	path, doc, err := getPolicyFileParams(args)
+	if err != nil {
+		vcli.Out().Fail(err)
+		return 1
+	}
+	if path == "" {
+		return cli.RunResultHelp
 	}
 
 	data := viper.GetString(cst.Data)
 	encoding := viper.GetString(cst.Encoding)
 
-	if data == "" {
+	switch {
+	case doc != nil:
+		if data, err = policyFileData(doc); err != nil {
+			vcli.Out().Fail(err)
+			return 1
+		}
+		encoding = cst.Json
+	case data == "":
 		var err *errors.ApiError
 		data, err = policyBuildFromFlags()
 		if err != nil {
@@ -377,14 +486,19 @@ func handlePolicySearchCmd(vcli vaultcli.CLI, args []string) int {
 		query = args[0]
 	}
 
-	data, apiErr := policySearch(vcli, &policySearchParams{
+	params := &policySearchParams{
 		query:    query,
 		limit:    limit,
-		cursor:   cursor,
 		sort:     sort,
 		sortedBy: sortedBy,
+	}
+	apiErr := writePages(vcli, cursor, func(cursor string) ([]byte, *errors.ApiError) {
+		params.cursor = cursor
+		return policySearch(vcli, params)
 	})
-	vcli.Out().WriteResponse(data, apiErr)
+	if apiErr != nil {
+		vcli.Out().WriteResponse(nil, apiErr)
+	}
 	return utils.GetExecStatus(apiErr)
 }
 
@@ -0,0 +1,590 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	cst "github.com/DelineaXPM/dsv-cli/constants"
+	"github.com/DelineaXPM/dsv-cli/errors"
+	"github.com/DelineaXPM/dsv-cli/internal/predictor"
+	"github.com/DelineaXPM/dsv-cli/paths"
+	"github.com/DelineaXPM/dsv-cli/utils"
+	"github.com/DelineaXPM/dsv-cli/vaultcli"
+
+	"github.com/mitchellh/cli"
+	"github.com/spf13/viper"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	policyEffectAllow = "allow"
+	policyEffectDeny  = "deny"
+	cidrConditionType = "CIDRCondition"
+)
+
+func GetPolicyTestCmd() (cli.Command, error) {
+	return NewCommand(CommandArgs{
+		Path: []string{cst.NounPolicy, cst.Test},
+		SynopsisText: fmt.Sprintf("%s %s (--subject <subject> --action <action> --resource <resource> [--ip <ip>] | --file <cases.yml>) [--policy-file <file>]",
+			cst.NounPolicy, cst.Test),
+		HelpText: fmt.Sprintf(`Check locally whether policies allow a subject to perform an action on a resource
+
+Statements of policies are evaluated the same way the server does: a statement matches if one of its
+subjects, one of its actions and one of its resources match the request and all its conditions are
+fulfilled. Subjects, actions and resources may contain regular expressions in angle brackets, e.g.
+users:<alice|bob> or secrets:prod:<.*>. A matching deny statement always wins over allow statements.
+If no statement matches, access is denied. CIDRCondition conditions are checked against --ip.
+
+Policies are read from the server at every path above the resource, e.g. secrets, secrets:prod and
+secrets:prod:db for the resource secrets:prod:db. With --policy-file only the given local files, in the
+format returned by '%[1]s %[2]s', are evaluated.
+
+The result lists every statement with the reason why it matches the request or not. Several subjects,
+e.g. a user and its groups, can be repeated or comma-separated.
+
+With --file a table of cases is evaluated and the command fails if any case has an unexpected result,
+so that policies can be tested in CI:
+
+   policies:            # optional local policy files, relative to the cases file
+     - prod.yml
+   cases:
+     - name: alice reads the prod database
+       subject: [users:alice, groups:dba]
+       action: read
+       resource: secrets:prod:db
+       ip: 10.0.0.5
+       expect: allow
+
+Usage:
+   • %[1]s %[3]s --subject users:alice --action read --resource secrets:prod:db --ip 10.0.0.5
+   • %[1]s %[3]s --subject users:alice,groups:dba --action update --resource secrets:prod:db --policy-file prod.yml
+   • %[1]s %[3]s --file cases.yml
+`, cst.NounPolicy, cst.Read, cst.Test),
+		FlagsPredictor: []*predictor.Params{
+			{Name: cst.Subject, Usage: "Subject of the request, e.g. users:alice, can be repeated or comma-separated", ValueType: "list"},
+			{Name: cst.Action, Usage: "Action of the request, e.g. read", Predictor: predictor.ActionTypePredictor{}},
+			{Name: cst.Resource, Usage: "Resource of the request, e.g. secrets:prod:db"},
+			{Name: cst.IP, Usage: "Remote IP address of the request (optional)"},
+			{Name: cst.File, Usage: "YAML file with test cases", Predictor: predictor.NewPrefixFilePredictor("*")},
+			{Name: cst.PolicyFile, Usage: "Local policy file to evaluate instead of policies on the server, can be repeated or comma-separated", ValueType: "list"},
+		},
+		NoPreAuth: true,
+		RunFuncE:  handlePolicyTestCmd,
+	})
+}
+
+func handlePolicyTestCmd(vcli vaultcli.CLI, args []string) error {
+	policyFiles := listFlagValues(cst.PolicyFile)
+
+	if file := viper.GetString(cst.File); file != "" {
+		return runPolicyTestFile(vcli, file, policyFiles)
+	}
+
+	req := &policyRequest{
+		Subjects: listFlagValues(cst.Subject),
+		Action:   viper.GetString(cst.Action),
+		Resource: paths.ProcessResource(viper.GetString(cst.Resource)),
+		IP:       viper.GetString(cst.IP),
+	}
+	if err := req.validate(); err != nil {
+		return err
+	}
+	src, err := newPolicySource(vcli, policyFiles)
+	if err != nil {
+		return err
+	}
+	decision, err := evaluatePolicies(src, req)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(decision)
+	if err != nil {
+		return err
+	}
+	vcli.Out().WriteResponse(data, nil)
+	return nil
+}
+
+// policyTestFile is a table of cases for policy test --file.
+type policyTestFile struct {
+	Policies []string          `yaml:"policies"`
+	Cases    []*policyTestCase `yaml:"cases"`
+}
+
+type policyTestCase struct {
+	Name     string       `yaml:"name"`
+	Subjects policyValues `yaml:"subject"`
+	Action   string       `yaml:"action"`
+	Resource string       `yaml:"resource"`
+	IP       string       `yaml:"ip"`
+	Expect   string       `yaml:"expect"`
+}
+
+// policyValues is a list which can also be written as a single string in YAML.
+type policyValues []string
+
+func (v *policyValues) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		*v = nonEmptyValues(utils.StringToSlice(node.Value))
+		return nil
+	}
+	var list []string
+	if err := node.Decode(&list); err != nil {
+		return err
+	}
+	*v = list
+	return nil
+}
+
+type policyTestCaseResult struct {
+	Name      string                 `json:"name"`
+	Expect    string                 `json:"expect"`
+	Decision  string                 `json:"decision"`
+	Passed    bool                   `json:"passed"`
+	Reason    string                 `json:"reason"`
+	MatchedBy *policyStatementResult `json:"matchedBy,omitempty"`
+}
+
+func runPolicyTestFile(vcli vaultcli.CLI, file string, policyFiles []string) error {
+	raw, err := os.ReadFile(file)
+	if err != nil {
+		return errors.New(err).Grow("Failed to read the cases file")
+	}
+	cfg := &policyTestFile{}
+	if err := yaml.Unmarshal(raw, cfg); err != nil {
+		return errors.New(err).Grow("Failed to parse the cases file")
+	}
+	if len(cfg.Cases) == 0 {
+		return errors.NewF("error: no cases found in %s", file)
+	}
+	for _, p := range cfg.Policies {
+		if !filepath.IsAbs(p) {
+			p = filepath.Join(filepath.Dir(file), p)
+		}
+		policyFiles = append(policyFiles, p)
+	}
+
+	src, err := newPolicySource(vcli, policyFiles)
+	if err != nil {
+		return err
+	}
+
+	results := make([]*policyTestCaseResult, 0, len(cfg.Cases))
+	failed := 0
+	for i, c := range cfg.Cases {
+		name := c.Name
+		if name == "" {
+			name = fmt.Sprintf("case %d", i+1)
+		}
+		expect := strings.ToLower(c.Expect)
+		if expect != policyEffectAllow && expect != policyEffectDeny {
+			return errors.NewF("error: %s: expect must be %q or %q", name, policyEffectAllow, policyEffectDeny)
+		}
+		req := &policyRequest{
+			Subjects: c.Subjects,
+			Action:   c.Action,
+			Resource: paths.ProcessResource(c.Resource),
+			IP:       c.IP,
+		}
+		if err := req.validate(); err != nil {
+			return errors.NewF("error: %s: %s", name, strings.TrimPrefix(err.Error(), "error: "))
+		}
+		decision, err := evaluatePolicies(src, req)
+		if err != nil {
+			return err
+		}
+		res := &policyTestCaseResult{
+			Name:      name,
+			Expect:    expect,
+			Decision:  decision.Decision,
+			Passed:    decision.Decision == expect,
+			Reason:    decision.Reason,
+			MatchedBy: decision.MatchedBy,
+		}
+		if !res.Passed {
+			failed++
+		}
+		results = append(results, res)
+	}
+
+	data, err := json.Marshal(map[string]interface{}{
+		"passed": len(results) - failed,
+		"failed": failed,
+		"cases":  results,
+	})
+	if err != nil {
+		return err
+	}
+	vcli.Out().WriteResponse(data, nil)
+	if failed > 0 {
+		return errors.NewF("%d of %d policy test case(s) failed", failed, len(results))
+	}
+	return nil
+}
+
+// listFlagValues returns values of a flag which can be repeated or comma-separated.
+func listFlagValues(key string) []string {
+	return nonEmptyValues(utils.StringToSlice(viper.GetString(key)))
+}
+
+func nonEmptyValues(values []string) []string {
+	res := []string{}
+	for _, v := range values {
+		if v != "" {
+			res = append(res, v)
+		}
+	}
+	return res
+}
+
+// policyRequest is a request evaluated against policies.
+type policyRequest struct {
+	Subjects []string
+	Action   string
+	Resource string
+	IP       string
+}
+
+func (r *policyRequest) validate() error {
+	switch {
+	case len(r.Subjects) == 0:
+		return errors.NewF("error: must specify --%s", cst.Subject)
+	case r.Action == "":
+		return errors.NewF("error: must specify --%s", cst.Action)
+	case r.Resource == "":
+		return errors.NewF("error: must specify --%s", cst.Resource)
+	}
+	if r.IP != "" && net.ParseIP(r.IP) == nil {
+		return errors.NewF("error: invalid IP address %q", r.IP)
+	}
+	return nil
+}
+
+// policyDocument is a policy with its path as returned by policy read.
+type policyDocument struct {
+	Path        string           `json:"path"`
+	Permissions []*defaultPolicy `json:"permissionDocument"`
+}
+
+// policySource returns policies which apply to a resource.
+type policySource interface {
+	policies(resource string) ([]*policyDocument, error)
+}
+
+// newPolicySource returns a source of local policy files if there are any, otherwise a source of
+// policies read from the server.
+func newPolicySource(vcli vaultcli.CLI, files []string) (policySource, error) {
+	if len(files) == 0 {
+		return &remotePolicySource{vcli: vcli, cache: make(map[string]*policyDocument)}, nil
+	}
+	src := &localPolicySource{}
+	for _, file := range files {
+		doc, err := readPolicyFile(file)
+		if err != nil {
+			return nil, err
+		}
+		src.docs = append(src.docs, doc)
+	}
+	return src, nil
+}
+
+// readPolicyFile reads a policy in JSON or YAML.
+func readPolicyFile(file string) (*policyDocument, error) {
+	raw, err := os.ReadFile(file)
+	if err != nil {
+		return nil, errors.New(err).Grow("Failed to read the policy file")
+	}
+	doc, err := parsePolicyDocument(raw)
+	if err != nil {
+		return nil, errors.New(err).Grow(fmt.Sprintf("Failed to parse the policy file %s", file))
+	}
+	return doc, nil
+}
+
+func parsePolicyDocument(raw []byte) (*policyDocument, error) {
+	return decodePolicyFile(raw, false)
+}
+
+type localPolicySource struct {
+	docs []*policyDocument
+}
+
+func (s *localPolicySource) policies(string) ([]*policyDocument, error) {
+	return s.docs, nil
+}
+
+// remotePolicySource reads policies at every path above a resource from the server.
+type remotePolicySource struct {
+	vcli          vaultcli.CLI
+	authenticated bool
+	cache         map[string]*policyDocument
+}
+
+func (s *remotePolicySource) policies(resource string) ([]*policyDocument, error) {
+	docs := []*policyDocument{}
+	segments := strings.Split(resource, ":")
+	for i := range segments {
+		doc, err := s.read(strings.Join(segments[:i+1], ":"))
+		if err != nil {
+			return nil, err
+		}
+		if doc != nil {
+			docs = append(docs, doc)
+		}
+	}
+	return docs, nil
+}
+
+// read returns the policy at the path or nil if there is none.
+func (s *remotePolicySource) read(path string) (*policyDocument, error) {
+	if doc, ok := s.cache[path]; ok {
+		return doc, nil
+	}
+	if !s.authenticated {
+		token, apiErr := s.vcli.Authenticator().GetToken()
+		if apiErr != nil {
+			return nil, apiErr
+		}
+		if token == nil || token.Token == "" {
+			return nil, errors.NewS("error: failed to authenticate")
+		}
+		viper.Set(cst.NounToken, token.Token)
+		s.authenticated = true
+	}
+
+	data, apiErr := policyRead(s.vcli, path)
+	if apiErr != nil {
+		if resp := apiErr.HttpResponse(); resp != nil && resp.StatusCode == http.StatusNotFound {
+			s.cache[path] = nil
+			return nil, nil
+		}
+		return nil, apiErr.Grow(fmt.Sprintf("Failed to read the policy %s", path))
+	}
+	doc := &policyDocument{}
+	if err := json.Unmarshal(data, doc); err != nil {
+		return nil, errors.New(err).Grow(fmt.Sprintf("Failed to parse the policy %s", path))
+	}
+	if doc.Path == "" {
+		doc.Path = path
+	}
+	s.cache[path] = doc
+	return doc, nil
+}
+
+// policyStatementResult tells whether a statement of a policy matches a request and why.
+type policyStatementResult struct {
+	Policy      string `json:"policy"`
+	Statement   int    `json:"statement"`
+	ID          string `json:"id,omitempty"`
+	Description string `json:"description,omitempty"`
+	Effect      string `json:"effect"`
+	Matched     bool   `json:"matched"`
+	Reason      string `json:"reason"`
+}
+
+// policyDecision is the result of evaluating policies for a request.
+type policyDecision struct {
+	Subjects   []string                 `json:"subjects"`
+	Action     string                   `json:"action"`
+	Resource   string                   `json:"resource"`
+	IP         string                   `json:"ip,omitempty"`
+	Decision   string                   `json:"decision"`
+	Reason     string                   `json:"reason"`
+	MatchedBy  *policyStatementResult   `json:"matchedBy,omitempty"`
+	Statements []*policyStatementResult `json:"statements"`
+}
+
+func evaluatePolicies(src policySource, req *policyRequest) (*policyDecision, error) {
+	docs, err := src.policies(req.Resource)
+	if err != nil {
+		return nil, err
+	}
+
+	decision := &policyDecision{
+		Subjects:   req.Subjects,
+		Action:     req.Action,
+		Resource:   req.Resource,
+		IP:         req.IP,
+		Decision:   policyEffectDeny,
+		Statements: []*policyStatementResult{},
+	}
+	var allowedBy, deniedBy *policyStatementResult
+	for _, doc := range docs {
+		for i, st := range doc.Permissions {
+			res := &policyStatementResult{
+				Policy:      doc.Path,
+				Statement:   i + 1,
+				ID:          st.ID,
+				Description: st.Description,
+				Effect:      strings.ToLower(st.Effect),
+			}
+			res.Matched, res.Reason = matchStatement(doc.Path, st, req)
+			decision.Statements = append(decision.Statements, res)
+			if !res.Matched {
+				continue
+			}
+			if res.Effect == policyEffectAllow {
+				if allowedBy == nil {
+					allowedBy = res
+				}
+			} else if deniedBy == nil {
+				// Anything but allow denies access, like the server does.
+				deniedBy = res
+			}
+		}
+	}
+
+	switch {
+	case deniedBy != nil:
+		decision.MatchedBy = deniedBy
+		decision.Reason = fmt.Sprintf("denied by statement %d of policy %s", deniedBy.Statement, deniedBy.Policy)
+	case allowedBy != nil:
+		decision.Decision = policyEffectAllow
+		decision.MatchedBy = allowedBy
+		decision.Reason = fmt.Sprintf("allowed by statement %d of policy %s", allowedBy.Statement, allowedBy.Policy)
+	case len(docs) == 0:
+		decision.Reason = "no policy applies to the resource, access is denied by default"
+	default:
+		decision.Reason = "no statement matches the request, access is denied by default"
+	}
+	return decision, nil
+}
+
+// matchStatement tells whether the statement of the policy at the path matches the request and why.
+func matchStatement(policyPath string, st *defaultPolicy, req *policyRequest) (bool, string) {
+	var subject, subjectPattern string
+	for _, s := range req.Subjects {
+		if p, ok := matchPolicyValues(st.Subjects, s); ok {
+			subject, subjectPattern = s, p
+			break
+		}
+	}
+	if subjectPattern == "" {
+		return false, fmt.Sprintf("subjects %q do not match %s", st.Subjects, strings.Join(req.Subjects, ", "))
+	}
+	actionPattern, ok := matchPolicyValues(st.Actions, req.Action)
+	if !ok {
+		return false, fmt.Sprintf("actions %q do not match %s", st.Actions, req.Action)
+	}
+	resources := st.Resources
+	if len(resources) == 0 && policyPath != "" {
+		// The server sets resources of statements without them to the policy path and all paths below.
+		resources = []string{policyPath, policyPath + ":<.*>"}
+	}
+	resourcePattern, ok := matchPolicyValues(resources, req.Resource)
+	if !ok {
+		return false, fmt.Sprintf("resources %q do not match %s", resources, req.Resource)
+	}
+
+	reasons := []string{
+		fmt.Sprintf("subject %q matches %s", subjectPattern, subject),
+		fmt.Sprintf("action %q matches %s", actionPattern, req.Action),
+		fmt.Sprintf("resource %q matches %s", resourcePattern, req.Resource),
+	}
+	keys := make([]string, 0, len(st.Conditions))
+	for k := range st.Conditions {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		ok, reason := checkPolicyCondition(key, st.Conditions[key], req)
+		if !ok {
+			return false, reason
+		}
+		reasons = append(reasons, reason)
+	}
+	return true, strings.Join(reasons, ", ")
+}
+
+func checkPolicyCondition(key string, cond jsonCondition, req *policyRequest) (bool, string) {
+	if cond.Type != cidrConditionType {
+		return false, fmt.Sprintf("condition %s has unsupported type %q", key, cond.Type)
+	}
+	var opts CIDRCondition
+	if err := json.Unmarshal(cond.Options, &opts); err != nil {
+		return false, fmt.Sprintf("condition %s has invalid options: %v", key, err)
+	}
+	_, cidr, err := net.ParseCIDR(opts.CIDR)
+	if err != nil {
+		return false, fmt.Sprintf("condition %s has invalid CIDR %q", key, opts.CIDR)
+	}
+	if req.IP == "" {
+		return false, fmt.Sprintf("condition %s requires an IP in %s, set --%s", key, opts.CIDR, cst.IP)
+	}
+	if !cidr.Contains(net.ParseIP(req.IP)) {
+		return false, fmt.Sprintf("condition %s: %s is not in %s", key, req.IP, opts.CIDR)
+	}
+	return true, fmt.Sprintf("condition %s: %s is in %s", key, req.IP, opts.CIDR)
+}
+
+// matchPolicyValues returns the first of the patterns which matches the value.
+func matchPolicyValues(patterns []string, value string) (string, bool) {
+	for _, p := range patterns {
+		if ok, err := matchPolicyPattern(p, value); err == nil && ok {
+			return p, true
+		}
+	}
+	return "", false
+}
+
+// matchPolicyPattern matches a subject, an action or a resource of a statement with the value.
+// Parts of the pattern in angle brackets are regular expressions, the rest must match exactly.
+func matchPolicyPattern(pattern string, value string) (bool, error) {
+	if !strings.Contains(pattern, "<") {
+		return pattern == value, nil
+	}
+	re, err := compilePolicyPattern(pattern)
+	if err != nil {
+		return false, err
+	}
+	return re.MatchString(value), nil
+}
+
+var policyPatternCache = map[string]*regexp.Regexp{}
+
+func compilePolicyPattern(pattern string) (*regexp.Regexp, error) {
+	if re, ok := policyPatternCache[pattern]; ok {
+		return re, nil
+	}
+	var sb strings.Builder
+	sb.WriteString("^")
+	depth, start := 0, 0
+	for i := 0; i < len(pattern); i++ {
+		switch pattern[i] {
+		case '<':
+			if depth == 0 {
+				sb.WriteString(regexp.QuoteMeta(pattern[start:i]))
+				start = i + 1
+			}
+			depth++
+		case '>':
+			depth--
+			if depth < 0 {
+				return nil, fmt.Errorf("unbalanced '>' in %q", pattern)
+			}
+			if depth == 0 {
+				sb.WriteString("(" + pattern[start:i] + ")")
+				start = i + 1
+			}
+		}
+	}
+	if depth != 0 {
+		return nil, fmt.Errorf("unbalanced '<' in %q", pattern)
+	}
+	sb.WriteString(regexp.QuoteMeta(pattern[start:]))
+	sb.WriteString("$")
+
+	re, err := regexp.Compile(sb.String())
+	if err != nil {
+		return nil, fmt.Errorf("invalid regular expression in %q: %w", pattern, err)
+	}
+	policyPatternCache[pattern] = re
+	return re, nil
+}
@@ -0,0 +1,301 @@
+package cmd
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/DelineaXPM/dsv-cli/auth"
+	cst "github.com/DelineaXPM/dsv-cli/constants"
+	"github.com/DelineaXPM/dsv-cli/errors"
+	"github.com/DelineaXPM/dsv-cli/tests/fake"
+	"github.com/DelineaXPM/dsv-cli/vaultcli"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetPolicyTestCmd(t *testing.T) {
+	_, err := GetPolicyTestCmd()
+	assert.Nil(t, err)
+}
+
+func TestMatchPolicyPattern(t *testing.T) {
+	testCases := []struct {
+		pattern string
+		value   string
+		matched bool
+		err     bool
+	}{
+		{pattern: "users:alice", value: "users:alice", matched: true},
+		{pattern: "users:alice", value: "users:alice2"},
+		{pattern: "users:<alice|bob>", value: "users:bob", matched: true},
+		{pattern: "users:<alice|bob>", value: "users:bobby"},
+		{pattern: "secrets:prod:<.*>", value: "secrets:prod:db:main", matched: true},
+		{pattern: "secrets:prod:<.*>", value: "secrets:production"},
+		{pattern: "secrets.<[a-z]+>", value: "secretsXabc"},
+		{pattern: "users:alice>", value: "users:alice>", matched: true},
+		{pattern: "users:<alice", err: true},
+		{pattern: "users:<alice>>", err: true},
+		{pattern: "users:<(>", err: true},
+	}
+	for _, tt := range testCases {
+		t.Run(tt.pattern+" "+tt.value, func(t *testing.T) {
+			matched, err := matchPolicyPattern(tt.pattern, tt.value)
+			if tt.err {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.matched, matched)
+		})
+	}
+}
+
+func TestEvaluatePolicies(t *testing.T) {
+	doc, err := parsePolicyDocument([]byte(`
+path: secrets/prod
+permissionDocument:
+  - subjects: ["users:<alice|bob>", "groups:dba"]
+    actions: ["read"]
+    effect: allow
+    resources: ["secrets:prod:<.*>"]
+    conditions:
+      remoteIP:
+        type: CIDRCondition
+        options:
+          cidr: 10.0.0.0/8
+  - subjects: ["users:bob"]
+    actions: ["<.*>"]
+    effect: deny
+  - subjects: ["users:carol"]
+    actions: ["<.*>"]
+    effect: allow
+    conditions:
+      sourceTime:
+        type: TimeCondition
+`))
+	assert.NoError(t, err)
+	assert.Equal(t, "secrets:prod", doc.Path)
+	src := &localPolicySource{docs: []*policyDocument{doc}}
+
+	testCases := []struct {
+		name      string
+		req       *policyRequest
+		decision  string
+		reason    string
+		statement int
+		reasons   []string
+	}{
+		{
+			name:      "allowed",
+			req:       &policyRequest{Subjects: []string{"users:alice"}, Action: "read", Resource: "secrets:prod:db", IP: "10.1.2.3"},
+			decision:  "allow",
+			reason:    "allowed by statement 1 of policy secrets:prod",
+			statement: 1,
+		},
+		{
+			name:      "group",
+			req:       &policyRequest{Subjects: []string{"users:dave", "groups:dba"}, Action: "read", Resource: "secrets:prod:db", IP: "10.1.2.3"},
+			decision:  "allow",
+			statement: 1,
+			reason:    "allowed by statement 1 of policy secrets:prod",
+		},
+		{
+			name:     "ip outside of cidr",
+			req:      &policyRequest{Subjects: []string{"users:alice"}, Action: "read", Resource: "secrets:prod:db", IP: "192.168.1.1"},
+			decision: "deny",
+			reason:   "no statement matches the request, access is denied by default",
+			reasons: []string{
+				"condition remoteIP: 192.168.1.1 is not in 10.0.0.0/8",
+				`subjects ["users:bob"] do not match users:alice`,
+				`subjects ["users:carol"] do not match users:alice`,
+			},
+		},
+		{
+			name:     "missing ip",
+			req:      &policyRequest{Subjects: []string{"users:alice"}, Action: "read", Resource: "secrets:prod:db"},
+			decision: "deny",
+			reason:   "no statement matches the request, access is denied by default",
+		},
+		{
+			name:      "deny wins",
+			req:       &policyRequest{Subjects: []string{"users:bob"}, Action: "read", Resource: "secrets:prod:db", IP: "10.1.2.3"},
+			decision:  "deny",
+			reason:    "denied by statement 2 of policy secrets:prod",
+			statement: 2,
+		},
+		{
+			name:      "default resources",
+			req:       &policyRequest{Subjects: []string{"users:bob"}, Action: "delete", Resource: "secrets:prod"},
+			decision:  "deny",
+			reason:    "denied by statement 2 of policy secrets:prod",
+			statement: 2,
+		},
+		{
+			name:     "resource outside of policy",
+			req:      &policyRequest{Subjects: []string{"users:bob"}, Action: "delete", Resource: "secrets:dev"},
+			decision: "deny",
+			reason:   "no statement matches the request, access is denied by default",
+		},
+		{
+			name:     "unsupported condition",
+			req:      &policyRequest{Subjects: []string{"users:carol"}, Action: "read", Resource: "secrets:prod:db"},
+			decision: "deny",
+			reason:   "no statement matches the request, access is denied by default",
+		},
+	}
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			d, err := evaluatePolicies(src, tt.req)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.decision, d.Decision)
+			assert.Equal(t, tt.reason, d.Reason)
+			assert.Len(t, d.Statements, 3)
+			if tt.statement > 0 {
+				assert.Equal(t, tt.statement, d.MatchedBy.Statement)
+				assert.True(t, d.MatchedBy.Matched)
+			} else {
+				assert.Nil(t, d.MatchedBy)
+			}
+			for i, reason := range tt.reasons {
+				assert.Equal(t, reason, d.Statements[i].Reason)
+			}
+		})
+	}
+}
+
+func TestRemotePolicySource(t *testing.T) {
+	viper.Reset()
+	defer viper.Reset()
+
+	authenticator := &fake.FakeAuthenticator{}
+	authenticator.GetTokenStub = func() (*auth.TokenResponse, *errors.ApiError) {
+		return &auth.TokenResponse{Token: "token"}, nil
+	}
+	httpClient := &fake.FakeClient{}
+	httpClient.DoRequestStub = func(method string, uri string, body interface{}) ([]byte, *errors.ApiError) {
+		switch filepath.Base(uri) {
+		case "secrets:prod":
+			return []byte(`{"path":"secrets:prod","permissionDocument":[{"subjects":["users:alice"],"actions":["read"],"effect":"allow","resources":["secrets:prod:<.*>"]}]}`), nil
+		case "secrets:prod:broken":
+			return nil, errors.NewS("internal error").WithResponse(&http.Response{StatusCode: http.StatusInternalServerError})
+		}
+		return nil, errors.NewS("not found").WithResponse(&http.Response{StatusCode: http.StatusNotFound})
+	}
+	vcli, err := vaultcli.NewWithOpts(vaultcli.WithHTTPClient(httpClient), vaultcli.WithAuthenticator(authenticator))
+	assert.NoError(t, err)
+
+	src, err := newPolicySource(vcli, nil)
+	assert.NoError(t, err)
+
+	d, err := evaluatePolicies(src, &policyRequest{Subjects: []string{"users:alice"}, Action: "read", Resource: "secrets:prod:db"})
+	assert.NoError(t, err)
+	assert.Equal(t, "allow", d.Decision)
+	assert.Equal(t, "token", viper.GetString(cst.NounToken))
+	assert.Equal(t, 3, httpClient.DoRequestCallCount())
+
+	// Policies are read once.
+	_, err = evaluatePolicies(src, &policyRequest{Subjects: []string{"users:alice"}, Action: "read", Resource: "secrets:prod:cache"})
+	assert.NoError(t, err)
+	assert.Equal(t, 4, httpClient.DoRequestCallCount())
+	assert.Equal(t, 1, authenticator.GetTokenCallCount())
+
+	_, err = evaluatePolicies(src, &policyRequest{Subjects: []string{"users:alice"}, Action: "read", Resource: "secrets:prod:broken"})
+	assert.ErrorContains(t, err, "Failed to read the policy secrets:prod:broken")
+}
+
+func TestHandlePolicyTestCmdFile(t *testing.T) {
+	dir := t.TempDir()
+	policy := `{"path":"secrets:prod","permissionDocument":[{"subjects":["users:alice"],"actions":["read"],"effect":"allow","resources":["secrets:prod:<.*>"]}]}`
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "prod.json"), []byte(policy), 0o600))
+
+	testCases := []struct {
+		name   string
+		cases  string
+		passed int
+		failed int
+		err    string
+	}{
+		{
+			name: "passed",
+			cases: `
+policies: [prod.json]
+cases:
+  - name: alice reads
+    subject: users:alice
+    action: read
+    resource: secrets/prod/db
+    expect: allow
+  - subject: [users:bob]
+    action: read
+    resource: secrets:prod:db
+    expect: Deny
+`,
+			passed: 2,
+		},
+		{
+			name: "failed",
+			cases: `
+policies: [prod.json]
+cases:
+  - subject: users:alice
+    action: delete
+    resource: secrets:prod:db
+    expect: allow
+`,
+			failed: 1,
+			err:    "1 of 1 policy test case(s) failed",
+		},
+		{
+			name:  "invalid expectation",
+			cases: "cases:\n  - {subject: users:alice, action: read, resource: secrets:prod, expect: maybe}",
+			err:   `error: case 1: expect must be "allow" or "deny"`,
+		},
+		{
+			name:  "missing action",
+			cases: "cases:\n  - {name: x, subject: users:alice, resource: secrets:prod, expect: allow}",
+			err:   "error: x: must specify --action",
+		},
+		{
+			name:  "no cases",
+			cases: "policies: [prod.json]",
+			err:   "error: no cases found",
+		},
+	}
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			viper.Reset()
+			defer viper.Reset()
+			file := filepath.Join(dir, "cases.yml")
+			assert.NoError(t, os.WriteFile(file, []byte(tt.cases), 0o600))
+			viper.Set(cst.File, file)
+			viper.Set(cst.PolicyFile, "")
+
+			var data []byte
+			outClient := &fake.FakeOutClient{}
+			outClient.WriteResponseStub = func(b []byte, apiErr *errors.ApiError) { data = b }
+			vcli, err := vaultcli.NewWithOpts(vaultcli.WithOutClient(outClient))
+			assert.NoError(t, err)
+
+			err = handlePolicyTestCmd(vcli, nil)
+			if tt.err != "" {
+				assert.ErrorContains(t, err, tt.err)
+			} else {
+				assert.NoError(t, err)
+			}
+			if tt.passed+tt.failed == 0 {
+				return
+			}
+			var report struct {
+				Passed int `json:"passed"`
+				Failed int `json:"failed"`
+			}
+			assert.NoError(t, json.Unmarshal(data, &report))
+			assert.Equal(t, tt.passed, report.Passed)
+			assert.Equal(t, tt.failed, report.Failed)
+		})
+	}
+}
@@ -0,0 +1,317 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+
+	cst "github.com/DelineaXPM/dsv-cli/constants"
+	"github.com/DelineaXPM/dsv-cli/errors"
+	"github.com/DelineaXPM/dsv-cli/paths"
+	"github.com/DelineaXPM/dsv-cli/vaultcli"
+
+	"github.com/spf13/viper"
+	"gopkg.in/yaml.v3"
+)
+
+const policyFileHelp = `The file lists the statements of the policy in YAML (or JSON):
+   path: secrets/databases        # optional if the path is given as an argument
+   permissionDocument:
+     - description: DBAs manage database secrets
+       subjects: [users:alice, "groups:<dba|ops>"]
+       actions: [create, read, update, delete, list]
+       effect: allow                # default: allow
+       resources: ["secrets:databases:<.*>"]
+       cidr: 10.0.0.0/8             # shorthand for a remoteIP CIDRCondition
+     - subjects: users:bob          # a single value or a comma separated list
+       actions: <.*>
+       effect: deny
+
+The file is checked before it is sent; errors reported by "policy lint" fail the command.
+`
+
+// policyFile is a policy written by hand, e.g. to be kept in version control.
+type policyFile struct {
+	Path       string                 `yaml:"path"`
+	Statements []*policyFileStatement `yaml:"permissionDocument"`
+}
+
+type policyFileStatement struct {
+	ID          string                         `yaml:"id"`
+	Description string                         `yaml:"description"`
+	Subjects    policyValues                   `yaml:"subjects"`
+	Actions     policyValues                   `yaml:"actions"`
+	Effect      string                         `yaml:"effect"`
+	Resources   policyValues                   `yaml:"resources"`
+	CIDR        string                         `yaml:"cidr"`
+	Conditions  map[string]policyFileCondition `yaml:"conditions"`
+}
+
+type policyFileCondition struct {
+	Type    string      `yaml:"type"`
+	Options interface{} `yaml:"options"`
+}
+
+// decodePolicyFile parses a policy in YAML or JSON. Unknown fields are rejected if strict is set.
+func decodePolicyFile(raw []byte, strict bool) (*policyDocument, error) {
+	f := &policyFile{}
+	dec := yaml.NewDecoder(bytes.NewReader(raw))
+	dec.KnownFields(strict)
+	if err := dec.Decode(f); err != nil && err != io.EOF {
+		return nil, err
+	}
+
+	doc := &policyDocument{Path: paths.ProcessResource(f.Path), Permissions: make([]*defaultPolicy, 0, len(f.Statements))}
+	for i, st := range f.Statements {
+		if st == nil {
+			return nil, fmt.Errorf("statement %d is empty", i+1)
+		}
+		policy := &defaultPolicy{
+			ID:          st.ID,
+			Description: st.Description,
+			Subjects:    st.Subjects,
+			Actions:     st.Actions,
+			Effect:      st.Effect,
+			Resources:   st.Resources,
+		}
+		if policy.Effect == "" {
+			policy.Effect = policyEffectAllow
+		}
+		for name, cond := range st.Conditions {
+			options, err := json.Marshal(cond.Options)
+			if err != nil {
+				return nil, fmt.Errorf("statement %d: condition %s: %v", i+1, name, err)
+			}
+			if policy.Conditions == nil {
+				policy.Conditions = make(map[string]jsonCondition, len(st.Conditions))
+			}
+			policy.Conditions[name] = jsonCondition{Type: cond.Type, Options: options}
+		}
+		if st.CIDR != "" {
+			if _, ok := policy.Conditions["remoteIP"]; ok {
+				return nil, fmt.Errorf("statement %d: cidr and the remoteIP condition cannot be used together", i+1)
+			}
+			// The CIDR is validated together with the rest of the policy.
+			options, _ := json.Marshal(CIDRCondition{CIDR: st.CIDR})
+			if policy.Conditions == nil {
+				policy.Conditions = make(map[string]jsonCondition, 1)
+			}
+			policy.Conditions["remoteIP"] = jsonCondition{Type: cidrConditionType, Options: options}
+		}
+		doc.Permissions = append(doc.Permissions, policy)
+	}
+	return doc, nil
+}
+
+// loadPolicyFile reads a policy file given with --file and checks it for errors.
+func loadPolicyFile(file string) (*policyDocument, error) {
+	raw, err := os.ReadFile(file)
+	if err != nil {
+		return nil, errors.New(err).Grow("Failed to read the policy file")
+	}
+	doc, err := decodePolicyFile(raw, true)
+	if err != nil {
+		return nil, errors.New(err).Grow(fmt.Sprintf("Failed to parse the policy file %s", file))
+	}
+	if len(doc.Permissions) == 0 {
+		return nil, errors.NewF("error: policy file %s has no statements", file)
+	}
+
+	report := lintPolicy(doc)
+	if report.Errors > 0 {
+		lines := []string{}
+		for _, issue := range report.Issues {
+			if issue.Severity == lintError {
+				lines = append(lines, fmt.Sprintf("   statement %d: %s", issue.Statement, issue.Message))
+			}
+		}
+		return nil, errors.NewF("error: policy file %s has %d error(s):\n%s", file, report.Errors, strings.Join(lines, "\n"))
+	}
+	return doc, nil
+}
+
+// getPolicyFileParams returns the path of the policy and the policy file given with --file, if any.
+// The path defaults to the path in the file.
+func getPolicyFileParams(args []string) (string, *policyDocument, error) {
+	path, _ := getPolicyParams(args)
+	file := viper.GetString(cst.File)
+	if file == "" {
+		return path, nil, nil
+	}
+	if viper.GetString(cst.Data) != "" {
+		return "", nil, errors.NewF("error: --%s and --%s cannot be used together", cst.Data, cst.File)
+	}
+	doc, err := loadPolicyFile(file)
+	if err != nil {
+		return "", nil, err
+	}
+	switch {
+	case path == "":
+		path = doc.Path
+	case doc.Path != "" && paths.ProcessResource(path) != doc.Path:
+		return "", nil, errors.NewF("error: %s %s does not match the path %s in the policy file", cst.Path, path, doc.Path)
+	}
+	return path, doc, nil
+}
+
+// policyFileData returns the policy of a file in the form accepted by the API.
+func policyFileData(doc *policyDocument) (string, error) {
+	b, err := json.Marshal(map[string][]*defaultPolicy{"permissionDocument": doc.Permissions})
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+// handlePolicyFileDiff compares the statements of a version of the policy at the path with a policy file.
+func handlePolicyFileDiff(vcli vaultcli.CLI, path string, doc *policyDocument) error {
+	src := policyVersionSource(vcli, path)
+	from := ""
+	before := []*defaultPolicy{}
+	current, apiErr := src.current()
+	switch {
+	case apiErr != nil && apiErr.HttpResponse() != nil && apiErr.HttpResponse().StatusCode == http.StatusNotFound && viper.GetString(cst.From) == "":
+		// The policy does not exist yet, every statement is added.
+	case apiErr != nil:
+		return apiErr
+	default:
+		version, err := itemVersion(current)
+		if err != nil {
+			return err
+		}
+		if s := strings.TrimSpace(viper.GetString(cst.From)); s != "" {
+			if version, err = parseVersion(s); err != nil {
+				return err
+			}
+		}
+		item, apiErr := readItemVersion(src, current, version)
+		if apiErr != nil {
+			return apiErr
+		}
+		b, err := json.Marshal(item)
+		if err != nil {
+			return err
+		}
+		old, err := decodePolicyFile(b, false)
+		if err != nil {
+			return errors.New(err).Grow("Failed to parse the policy")
+		}
+		from = fmt.Sprint(version)
+		before = old.Permissions
+	}
+
+	data, err := json.Marshal(map[string]interface{}{
+		"from":    from,
+		"to":      viper.GetString(cst.File),
+		"changes": diffPolicyStatements(before, doc.Permissions),
+	})
+	if err != nil {
+		return err
+	}
+	vcli.Out().WriteResponse(data, nil)
+	return nil
+}
+
+// policyStatementChange is a statement which differs between two policies.
+type policyStatementChange struct {
+	Action            string         `json:"action"`
+	Statement         int            `json:"statement,omitempty"`
+	PreviousStatement int            `json:"previousStatement,omitempty"`
+	Fields            []string       `json:"fields,omitempty"`
+	From              *defaultPolicy `json:"from,omitempty"`
+	To                *defaultPolicy `json:"to,omitempty"`
+}
+
+// diffPolicyStatements pairs statements of two policies and reports added, removed and changed ones.
+// Statements are paired by content first, then by description, then by subjects and effect and finally by position.
+func diffPolicyStatements(before, after []*defaultPolicy) []policyStatementChange {
+	pairs := make([]int, len(after))
+	paired := make([]bool, len(before))
+	for j := range pairs {
+		pairs[j] = -1
+	}
+	pair := func(match func(i, j int) bool) {
+		for j := range after {
+			if pairs[j] >= 0 {
+				continue
+			}
+			for i := range before {
+				if !paired[i] && match(i, j) {
+					pairs[j], paired[i] = i, true
+					break
+				}
+			}
+		}
+	}
+	pair(func(i, j int) bool { return statementKey(before[i]) == statementKey(after[j]) })
+	pair(func(i, j int) bool {
+		return after[j].Description != "" && before[i].Description == after[j].Description
+	})
+	pair(func(i, j int) bool {
+		fields := statementFieldsDiff(before[i], after[j])
+		return !slices.Contains(fields, "subjects") && !slices.Contains(fields, "effect")
+	})
+	pair(func(i, j int) bool { return i == j })
+
+	changes := []policyStatementChange{}
+	for j, i := range pairs {
+		if i < 0 {
+			changes = append(changes, policyStatementChange{Action: "added", Statement: j + 1, To: after[j]})
+			continue
+		}
+		if fields := statementFieldsDiff(before[i], after[j]); len(fields) > 0 {
+			changes = append(changes, policyStatementChange{
+				Action: "changed", Statement: j + 1, PreviousStatement: i + 1, Fields: fields, From: before[i], To: after[j],
+			})
+		}
+	}
+	for i := range before {
+		if !paired[i] {
+			changes = append(changes, policyStatementChange{Action: "removed", PreviousStatement: i + 1, From: before[i]})
+		}
+	}
+	return changes
+}
+
+// statementFieldsDiff returns names of fields which differ between two statements. The order of values is ignored.
+func statementFieldsDiff(a, b *defaultPolicy) []string {
+	sorted := func(values []string) []string {
+		s := append([]string{}, values...)
+		sort.Strings(s)
+		return s
+	}
+	conditions := func(st *defaultPolicy) string {
+		if len(st.Conditions) == 0 {
+			return ""
+		}
+		c, _ := json.Marshal(st.Conditions)
+		return string(c)
+	}
+	fields := []string{}
+	if a.Description != b.Description {
+		fields = append(fields, "description")
+	}
+	if !reflect.DeepEqual(sorted(a.Subjects), sorted(b.Subjects)) {
+		fields = append(fields, "subjects")
+	}
+	if !reflect.DeepEqual(sorted(a.Actions), sorted(b.Actions)) {
+		fields = append(fields, "actions")
+	}
+	if !strings.EqualFold(a.Effect, b.Effect) {
+		fields = append(fields, "effect")
+	}
+	if !reflect.DeepEqual(sorted(a.Resources), sorted(b.Resources)) {
+		fields = append(fields, "resources")
+	}
+	if conditions(a) != conditions(b) {
+		fields = append(fields, "conditions")
+	}
+	return fields
+}
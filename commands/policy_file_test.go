@@ -0,0 +1,227 @@
+package cmd
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	cst "github.com/DelineaXPM/dsv-cli/constants"
+	"github.com/DelineaXPM/dsv-cli/errors"
+	"github.com/DelineaXPM/dsv-cli/tests/fake"
+	"github.com/DelineaXPM/dsv-cli/vaultcli"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+)
+
+const testPolicyFile = `
+path: secrets/databases
+permissionDocument:
+  - description: DBAs manage database secrets
+    subjects: [users:alice, "groups:<dba|ops>"]
+    actions: [create, read, update, delete, list]
+    resources: ["secrets:databases:<.*>"]
+    cidr: 10.0.0.0/8
+  - subjects: users:bob
+    actions: read,list
+    effect: deny
+`
+
+func TestDecodePolicyFile(t *testing.T) {
+	doc, err := decodePolicyFile([]byte(testPolicyFile), true)
+	assert.NoError(t, err)
+	assert.Equal(t, "secrets:databases", doc.Path)
+	assert.Len(t, doc.Permissions, 2)
+
+	st := doc.Permissions[0]
+	assert.Equal(t, "DBAs manage database secrets", st.Description)
+	assert.Equal(t, []string{"users:alice", "groups:<dba|ops>"}, st.Subjects)
+	assert.Equal(t, "allow", st.Effect)
+	assert.Equal(t, cidrConditionType, st.Conditions["remoteIP"].Type)
+	assert.JSONEq(t, `{"cidr":"10.0.0.0/8"}`, string(st.Conditions["remoteIP"].Options))
+
+	st = doc.Permissions[1]
+	assert.Equal(t, []string{"users:bob"}, st.Subjects)
+	assert.Equal(t, []string{"read", "list"}, st.Actions)
+	assert.Equal(t, "deny", st.Effect)
+	assert.Empty(t, st.Resources)
+	assert.Nil(t, st.Conditions)
+
+	_, err = decodePolicyFile([]byte("permissionDocument:\n  - {subject: users:bob, actions: read}"), true)
+	assert.ErrorContains(t, err, "field subject not found")
+	_, err = decodePolicyFile([]byte("permissionDocument:\n  - {subject: users:bob, actions: read}"), false)
+	assert.NoError(t, err)
+
+	_, err = decodePolicyFile([]byte(`
+permissionDocument:
+  - subjects: users:bob
+    actions: read
+    cidr: 10.0.0.0/8
+    conditions:
+      remoteIP: {type: CIDRCondition, options: {cidr: 10.0.0.0/16}}
+`), true)
+	assert.ErrorContains(t, err, "statement 1: cidr and the remoteIP condition cannot be used together")
+}
+
+func TestLoadPolicyFile(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "policy.yml")
+
+	assert.NoError(t, os.WriteFile(file, []byte(testPolicyFile), 0o600))
+	doc, err := loadPolicyFile(file)
+	assert.NoError(t, err)
+	assert.Len(t, doc.Permissions, 2)
+
+	assert.NoError(t, os.WriteFile(file, []byte("permissionDocument:\n  - {subjects: users:bob, actions: read, cidr: 10.0.0.300/8}\n  - {actions: read}"), 0o600))
+	_, err = loadPolicyFile(file)
+	assert.ErrorContains(t, err, "has 2 error(s):\n")
+	assert.ErrorContains(t, err, `statement 1: condition remoteIP has invalid CIDR "10.0.0.300/8"`)
+	assert.ErrorContains(t, err, "statement 2: statement has no subjects and never matches")
+
+	assert.NoError(t, os.WriteFile(file, []byte("path: secrets/databases"), 0o600))
+	_, err = loadPolicyFile(file)
+	assert.ErrorContains(t, err, "has no statements")
+}
+
+func TestDiffPolicyStatements(t *testing.T) {
+	before := []*defaultPolicy{
+		{Description: "readers", Subjects: []string{"users:alice"}, Actions: []string{"read"}, Effect: "allow"},
+		{Subjects: []string{"users:bob"}, Actions: []string{"<.*>"}, Effect: "deny"},
+		{Subjects: []string{"users:carol"}, Actions: []string{"read", "list"}, Effect: "allow"},
+		{Subjects: []string{"users:dave"}, Actions: []string{"read"}, Effect: "allow"},
+	}
+	after := []*defaultPolicy{
+		{Subjects: []string{"users:carol"}, Actions: []string{"list", "read"}, Effect: "Allow"},
+		{Description: "readers", Subjects: []string{"users:alice", "users:erin"}, Actions: []string{"read"}, Effect: "allow"},
+		{Subjects: []string{"users:bob"}, Actions: []string{"<.*>"}, Effect: "deny", Resources: []string{"secrets:<.*>"}},
+		{Subjects: []string{"users:frank"}, Actions: []string{"read"}, Effect: "allow"},
+		{Subjects: []string{"users:grace"}, Actions: []string{"read"}, Effect: "allow"},
+	}
+
+	changes := diffPolicyStatements(before, after)
+	type change struct {
+		action   string
+		to, from int
+		fields   string
+	}
+	got := []change{}
+	for _, c := range changes {
+		got = append(got, change{c.Action, c.Statement, c.PreviousStatement, strings.Join(c.Fields, ",")})
+	}
+	assert.Equal(t, []change{
+		{"changed", 2, 1, "subjects"},
+		{"changed", 3, 2, "resources"},
+		{"changed", 4, 4, "subjects"},
+		{"added", 5, 0, ""},
+	}, got)
+
+	changes = diffPolicyStatements(after, before[:1])
+	assert.Len(t, changes, 5)
+	assert.Equal(t, "removed", changes[1].Action)
+	assert.Equal(t, 1, changes[1].PreviousStatement)
+	assert.Equal(t, after[0], changes[1].From)
+}
+
+func TestHandlePolicyFileCmds(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "policy.yml")
+	assert.NoError(t, os.WriteFile(file, []byte(testPolicyFile), 0o600))
+
+	current := `{"path":"secrets:databases","version":"3","permissionDocument":[` +
+		`{"id":"x1","description":"DBAs manage database secrets","subjects":["groups:<dba|ops>","users:alice"],"actions":["create","read","update","delete","list"],` +
+		`"effect":"allow","resources":["secrets:databases:<.*>"],"conditions":{"remoteIP":{"type":"CIDRCondition","options":{"cidr":"10.0.0.0/8"}}}},` +
+		`{"id":"x2","subjects":["users:bob"],"actions":["read"],"effect":"deny","resources":null}]}`
+
+	testCases := []struct {
+		name    string
+		args    []string
+		create  bool
+		exists  bool
+		body    string
+		changes string
+		err     string
+	}{
+		{
+			name:   "create",
+			create: true,
+			body: `{"permissionDocument":[` +
+				`{"id":"","description":"DBAs manage database secrets","subjects":["users:alice","groups:<dba|ops>"],"effect":"allow","resources":["secrets:databases:<.*>"],"actions":["create","read","update","delete","list"],"conditions":{"remoteIP":{"type":"CIDRCondition","options":{"cidr":"10.0.0.0/8"}}}},` +
+				`{"id":"","description":"","subjects":["users:bob"],"effect":"deny","resources":null,"actions":["read","list"],"conditions":null}]}`,
+		},
+		{name: "create with other path", args: []string{"secrets/other"}, create: true, err: "does not match the path secrets:databases"},
+		{name: "diff", exists: true, changes: `[{"action":"changed","statement":2,"previousStatement":2,"fields":["actions"]}]`},
+		{name: "diff with new policy", changes: `[{"action":"added","statement":1},{"action":"added","statement":2}]`},
+	}
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			viper.Reset()
+			defer viper.Reset()
+			viper.Set(cst.File, file)
+
+			var data []byte
+			var failure error
+			outClient := &fake.FakeOutClient{}
+			outClient.WriteResponseStub = func(b []byte, apiErr *errors.ApiError) { data = b }
+			outClient.FailStub = func(err error) { failure = err }
+
+			var body interface{}
+			httpClient := &fake.FakeClient{}
+			httpClient.DoRequestStub = func(method string, uri string, b interface{}) ([]byte, *errors.ApiError) {
+				if method == http.MethodGet {
+					if tt.exists {
+						return []byte(current), nil
+					}
+					return nil, errors.NewS("not found").WithResponse(&http.Response{StatusCode: http.StatusNotFound})
+				}
+				body = b
+				return []byte(`{"code":"success"}`), nil
+			}
+			vcli, err := vaultcli.NewWithOpts(vaultcli.WithHTTPClient(httpClient), vaultcli.WithOutClient(outClient))
+			assert.NoError(t, err)
+
+			if tt.create {
+				code := handlePolicyCreateCmd(vcli, tt.args)
+				if tt.err != "" {
+					assert.Equal(t, 1, code)
+					assert.ErrorContains(t, failure, tt.err)
+					return
+				}
+				assert.Equal(t, 0, code)
+				req, ok := body.(*policyCreateRequest)
+				assert.True(t, ok)
+				assert.Equal(t, "secrets:databases", req.Path)
+				assert.Equal(t, cst.Json, req.Serialization)
+				assert.JSONEq(t, tt.body, req.Policy)
+				return
+			}
+
+			assert.NoError(t, handlePolicyFileDiff(vcli, "secrets/databases", mustLoadPolicyFile(t, file)))
+			var out struct {
+				From    string `json:"from"`
+				Changes []map[string]interface{}
+			}
+			assert.NoError(t, json.Unmarshal(data, &out))
+			for _, c := range out.Changes {
+				delete(c, "from")
+				delete(c, "to")
+			}
+			changes, _ := json.Marshal(out.Changes)
+			assert.JSONEq(t, tt.changes, string(changes))
+			if tt.exists {
+				assert.Equal(t, "3", out.From)
+			} else {
+				assert.Empty(t, out.From)
+			}
+		})
+	}
+}
+
+func mustLoadPolicyFile(t *testing.T, file string) *policyDocument {
+	t.Helper()
+	doc, err := loadPolicyFile(file)
+	assert.NoError(t, err)
+	return doc
+}
@@ -0,0 +1,360 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"slices"
+	"sort"
+	"strings"
+
+	cst "github.com/DelineaXPM/dsv-cli/constants"
+	"github.com/DelineaXPM/dsv-cli/errors"
+	"github.com/DelineaXPM/dsv-cli/internal/predictor"
+	"github.com/DelineaXPM/dsv-cli/paths"
+	"github.com/DelineaXPM/dsv-cli/vaultcli"
+
+	"github.com/mitchellh/cli"
+	"github.com/spf13/viper"
+)
+
+// Severities of policy lint issues.
+const (
+	lintError   = "error"
+	lintWarning = "warning"
+	lintInfo    = "info"
+)
+
+// policyActions are actions known to the server.
+var policyActions = []string{"create", "read", "update", "delete", "list", "assign", "share"}
+
+func GetPolicyLintCmd() (cli.Command, error) {
+	return NewCommand(CommandArgs{
+		Path:         []string{cst.NounPolicy, cst.Lint},
+		SynopsisText: fmt.Sprintf("%s %s (<path> | @<file> | (--path | -r) <path> | (--data | -d) @<file>)", cst.NounPolicy, cst.Lint),
+		HelpText: fmt.Sprintf(`Check a policy for common mistakes and over-broad grants
+
+The policy is read from the server or from a local JSON or YAML file. Every issue has a severity:
+   • error    the policy is broken or grants far more than intended
+   • warning  a statement does not do what it seems to
+   • info     a statement could be removed without changing the policy
+
+Errors: statements without subjects or actions, invalid effects, malformed regular expressions in
+angle brackets, invalid CIDR conditions and allow statements for any subject on any resource.
+Warnings: unknown actions, statements without resources, resources outside of the policy path,
+unsupported conditions, duplicate statements and allow statements shadowed by broader deny statements.
+Info: statements already covered by another statement with the same effect.
+
+The command fails if the policy has errors.
+
+Usage:
+   • %[1]s %[2]s %[3]s
+   • %[1]s %[2]s @policy.yml
+   • %[1]s %[2]s --data @policy.json
+`, cst.NounPolicy, cst.Lint, cst.ExamplePolicyPath),
+		FlagsPredictor: []*predictor.Params{
+			{Name: cst.Path, Shorthand: "r", Usage: fmt.Sprintf("Target %s to a %s", cst.Path, cst.NounPolicy)},
+			{Name: cst.Data, Shorthand: "d", Usage: fmt.Sprintf("%s to check. Prefix with '@' to denote filepath", strings.Title(cst.NounPolicy)), Predictor: predictor.NewPrefixFilePredictor("*")},
+		},
+		NoPreAuth: true,
+		RunFuncE:  handlePolicyLintCmd,
+	})
+}
+
+func handlePolicyLintCmd(vcli vaultcli.CLI, args []string) error {
+	var doc *policyDocument
+	var err error
+	switch {
+	case len(args) > 0 && strings.HasPrefix(args[0], cst.CmdFilePrefix):
+		doc, err = readPolicyFile(strings.TrimPrefix(args[0], cst.CmdFilePrefix))
+	case viper.GetString(cst.Data) != "":
+		doc, err = parsePolicyDocument([]byte(viper.GetString(cst.Data)))
+		if err != nil {
+			err = errors.New(err).Grow("Failed to parse the policy")
+		}
+	default:
+		path, status := getPolicyParams(args)
+		if status != 0 {
+			return errors.NewF("error: must specify a %s %s or a file", cst.NounPolicy, cst.Path)
+		}
+		path = paths.ProcessResource(path)
+		src := &remotePolicySource{vcli: vcli, cache: make(map[string]*policyDocument)}
+		doc, err = src.read(path)
+		if err == nil && doc == nil {
+			err = errors.NewF("error: %s %s not found", cst.NounPolicy, path)
+		}
+	}
+	if err != nil {
+		return err
+	}
+
+	report := lintPolicy(doc)
+	data, err := json.Marshal(report)
+	if err != nil {
+		return err
+	}
+	vcli.Out().WriteResponse(data, nil)
+	if report.Errors > 0 {
+		return errors.NewF("%s has %d error(s)", cst.NounPolicy, report.Errors)
+	}
+	return nil
+}
+
+type policyIssue struct {
+	Severity  string `json:"severity"`
+	Rule      string `json:"rule"`
+	Statement int    `json:"statement,omitempty"`
+	Message   string `json:"message"`
+}
+
+type policyLintReport struct {
+	Path     string         `json:"path,omitempty"`
+	Errors   int            `json:"errors"`
+	Warnings int            `json:"warnings"`
+	Issues   []*policyIssue `json:"issues"`
+}
+
+func (r *policyLintReport) add(severity string, rule string, statement int, format string, args ...interface{}) {
+	r.Issues = append(r.Issues, &policyIssue{
+		Severity:  severity,
+		Rule:      rule,
+		Statement: statement,
+		Message:   fmt.Sprintf(format, args...),
+	})
+	switch severity {
+	case lintError:
+		r.Errors++
+	case lintWarning:
+		r.Warnings++
+	}
+}
+
+func lintPolicy(doc *policyDocument) *policyLintReport {
+	report := &policyLintReport{Path: doc.Path, Issues: []*policyIssue{}}
+	if len(doc.Permissions) == 0 {
+		report.add(lintWarning, "empty-policy", 0, "policy has no statements")
+		return report
+	}
+	for i, st := range doc.Permissions {
+		lintStatement(report, doc.Path, i+1, st)
+	}
+	lintStatementPairs(report, doc)
+
+	sort.SliceStable(report.Issues, func(i, j int) bool {
+		return report.Issues[i].Statement < report.Issues[j].Statement
+	})
+	return report
+}
+
+// lintStatement checks a single statement of the policy at the path.
+func lintStatement(report *policyLintReport, path string, n int, st *defaultPolicy) {
+	effect := strings.ToLower(st.Effect)
+	if effect != policyEffectAllow && effect != policyEffectDeny {
+		report.add(lintError, "invalid-effect", n, "effect %q must be %q or %q", st.Effect, policyEffectAllow, policyEffectDeny)
+	}
+	if len(st.Subjects) == 0 {
+		report.add(lintError, "empty-subjects", n, "statement has no subjects and never matches")
+	}
+	if len(st.Actions) == 0 {
+		report.add(lintError, "empty-actions", n, "statement has no actions and never matches")
+	}
+	if len(st.Resources) == 0 {
+		target := "the policy path"
+		if path != "" {
+			target = path
+		}
+		report.add(lintWarning, "empty-resources", n, "statement has no resources, the server applies it to %s and all paths below", target)
+	}
+
+	for _, list := range [][]string{st.Subjects, st.Actions, st.Resources} {
+		for _, p := range list {
+			if !strings.ContainsAny(p, "<>") {
+				continue
+			}
+			if _, err := compilePolicyPattern(p); err != nil {
+				report.add(lintError, "invalid-regex", n, "%v", err)
+			}
+		}
+	}
+	for _, a := range st.Actions {
+		if !strings.Contains(a, "<") && !slices.Contains(policyActions, a) {
+			report.add(lintWarning, "unknown-action", n, "action %q is unknown, known actions are %s", a, strings.Join(policyActions, ", "))
+		}
+	}
+
+	if path != "" && len(st.Resources) > 0 {
+		outside := []string{}
+		for _, r := range st.Resources {
+			if !resourceUnderPath(r, path) {
+				outside = append(outside, r)
+			}
+		}
+		if len(outside) == len(st.Resources) {
+			report.add(lintWarning, "unreachable-statement", n, "resources %q are outside of the policy path %s, the statement never applies", outside, path)
+		} else {
+			for _, r := range outside {
+				report.add(lintWarning, "unreachable-resource", n, "resource %q is outside of the policy path %s and never matches", r, path)
+			}
+		}
+	}
+
+	keys := make([]string, 0, len(st.Conditions))
+	for k := range st.Conditions {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		cond := st.Conditions[key]
+		if cond.Type != cidrConditionType {
+			report.add(lintWarning, "unknown-condition", n, "condition %s has unsupported type %q", key, cond.Type)
+			continue
+		}
+		var opts CIDRCondition
+		if err := json.Unmarshal(cond.Options, &opts); err != nil {
+			report.add(lintError, "invalid-cidr", n, "condition %s has invalid options: %v", key, err)
+			continue
+		}
+		if _, _, err := net.ParseCIDR(opts.CIDR); err != nil {
+			report.add(lintError, "invalid-cidr", n, "condition %s has invalid CIDR %q", key, opts.CIDR)
+		}
+	}
+
+	if effect == policyEffectAllow && anyWildcard(st.Subjects) && anyWildcard(st.Resources) {
+		report.add(lintError, "wildcard-grant", n, "statement allows %s to any subject on any resource", strings.Join(st.Actions, ", "))
+	}
+}
+
+// lintStatementPairs finds duplicate statements and statements covered by other statements.
+func lintStatementPairs(report *policyLintReport, doc *policyDocument) {
+	keys := make([]string, len(doc.Permissions))
+	for i, st := range doc.Permissions {
+		keys[i] = statementKey(st)
+	}
+	for i, st := range doc.Permissions {
+		effect := strings.ToLower(st.Effect)
+		duplicate := false
+		for j := 0; j < i; j++ {
+			if keys[i] == keys[j] {
+				report.add(lintWarning, "duplicate-statement", i+1, "statement is a duplicate of statement %d", j+1)
+				duplicate = true
+				break
+			}
+		}
+		if duplicate {
+			continue
+		}
+		redundant := false
+		for j, other := range doc.Permissions {
+			if i == j || keys[i] == keys[j] || len(other.Conditions) > 0 || !statementCovers(doc.Path, other, st) {
+				continue
+			}
+			otherEffect := strings.ToLower(other.Effect)
+			switch {
+			case effect == policyEffectAllow && otherEffect == policyEffectDeny:
+				report.add(lintWarning, "shadowed-allow", i+1, "statement is shadowed by deny statement %d and never grants access", j+1)
+			case effect == otherEffect && !redundant:
+				report.add(lintInfo, "redundant-statement", i+1, "statement %d already covers this statement", j+1)
+				redundant = true
+			}
+		}
+	}
+}
+
+// statementKey identifies statements which are equal regardless of the order of their values.
+func statementKey(st *defaultPolicy) string {
+	sorted := func(values []string) []string {
+		s := append([]string{}, values...)
+		sort.Strings(s)
+		return s
+	}
+	b, _ := json.Marshal([]interface{}{
+		strings.ToLower(st.Effect), sorted(st.Subjects), sorted(st.Actions), sorted(st.Resources), st.Conditions,
+	})
+	return string(b)
+}
+
+// statementCovers tells whether every request matched by st is also matched by other.
+func statementCovers(path string, other *defaultPolicy, st *defaultPolicy) bool {
+	return patternsCover(other.Subjects, st.Subjects) &&
+		patternsCover(other.Actions, st.Actions) &&
+		patternsCover(statementResources(path, other), statementResources(path, st))
+}
+
+func statementResources(path string, st *defaultPolicy) []string {
+	if len(st.Resources) == 0 && path != "" {
+		return []string{path, path + ":<.*>"}
+	}
+	return st.Resources
+}
+
+func patternsCover(patterns []string, values []string) bool {
+	if len(values) == 0 {
+		return false
+	}
+	for _, v := range values {
+		covered := false
+		for _, p := range patterns {
+			if patternCovers(p, v) {
+				covered = true
+				break
+			}
+		}
+		if !covered {
+			return false
+		}
+	}
+	return true
+}
+
+// patternCovers tells whether the pattern matches everything the other pattern matches. Regular
+// expressions are only compared when the pattern is a literal prefix followed by <.*>.
+func patternCovers(pattern string, other string) bool {
+	if pattern == other {
+		return true
+	}
+	if !strings.Contains(other, "<") {
+		ok, err := matchPolicyPattern(pattern, other)
+		return err == nil && ok
+	}
+	prefix, ok := strings.CutSuffix(pattern, "<.*>")
+	if !ok || strings.Contains(prefix, "<") {
+		return false
+	}
+	return strings.HasPrefix(literalPrefix(other), prefix)
+}
+
+// literalPrefix returns the part of a pattern before its first regular expression.
+func literalPrefix(pattern string) string {
+	if i := strings.Index(pattern, "<"); i >= 0 {
+		return pattern[:i]
+	}
+	return pattern
+}
+
+// resourceUnderPath tells whether the resource pattern can match the policy path or a path below it.
+func resourceUnderPath(resource string, path string) bool {
+	prefix := literalPrefix(resource)
+	if prefix == path || strings.HasPrefix(prefix, path+":") {
+		return true
+	}
+	// A regular expression may still expand to the path.
+	return strings.Contains(resource, "<") && strings.HasPrefix(path, prefix)
+}
+
+// anyWildcard tells whether any of the patterns matches everything of a kind, e.g. <.*> or users:<.*>.
+func anyWildcard(patterns []string) bool {
+	for _, p := range patterns {
+		i := strings.Index(p, "<")
+		if i < 0 {
+			continue
+		}
+		rest := p[i:]
+		if rest != "<.*>" && rest != "<.+>" {
+			continue
+		}
+		if !strings.Contains(strings.TrimSuffix(p[:i], ":"), ":") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,211 @@
+package cmd
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/DelineaXPM/dsv-cli/auth"
+	cst "github.com/DelineaXPM/dsv-cli/constants"
+	"github.com/DelineaXPM/dsv-cli/errors"
+	"github.com/DelineaXPM/dsv-cli/tests/fake"
+	"github.com/DelineaXPM/dsv-cli/vaultcli"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetPolicyLintCmd(t *testing.T) {
+	_, err := GetPolicyLintCmd()
+	assert.Nil(t, err)
+}
+
+func TestLintPolicy(t *testing.T) {
+	testCases := []struct {
+		name   string
+		policy string
+		issues []policyIssue
+	}{
+		{
+			name: "clean",
+			policy: `
+path: secrets/prod
+permissionDocument:
+  - subjects: ["users:alice"]
+    actions: ["read", "list"]
+    effect: allow
+    resources: ["secrets:prod:<.*>"]
+    conditions:
+      remoteIP: {type: CIDRCondition, options: {cidr: 10.0.0.0/8}}
+`,
+		},
+		{
+			name:   "empty",
+			policy: `{"path":"secrets:prod"}`,
+			issues: []policyIssue{{Severity: lintWarning, Rule: "empty-policy"}},
+		},
+		{
+			name: "wildcard grant",
+			policy: `
+path: secrets
+permissionDocument:
+  - {subjects: ["users:<.*>"], actions: ["<.*>"], effect: allow, resources: ["secrets:<.*>"]}
+  - {subjects: ["<.+>"], actions: ["read"], effect: allow, resources: ["secrets:prod:<.*>"]}
+`,
+			issues: []policyIssue{{Severity: lintError, Rule: "wildcard-grant", Statement: 1}},
+		},
+		{
+			name: "malformed statement",
+			policy: `
+path: secrets/prod
+permissionDocument:
+  - subjects: []
+    actions: ["reed", "<(>"]
+    effect: permit
+    conditions:
+      remoteIP: {type: CIDRCondition, options: {cidr: 10.0.0.300/8}}
+      sourceTime: {type: TimeCondition}
+`,
+			issues: []policyIssue{
+				{Severity: lintError, Rule: "invalid-effect", Statement: 1},
+				{Severity: lintError, Rule: "empty-subjects", Statement: 1},
+				{Severity: lintWarning, Rule: "empty-resources", Statement: 1},
+				{Severity: lintError, Rule: "invalid-regex", Statement: 1},
+				{Severity: lintWarning, Rule: "unknown-action", Statement: 1},
+				{Severity: lintError, Rule: "invalid-cidr", Statement: 1},
+				{Severity: lintWarning, Rule: "unknown-condition", Statement: 1},
+			},
+		},
+		{
+			name: "outside of path",
+			policy: `
+path: secrets/prod
+permissionDocument:
+  - {subjects: ["users:alice"], actions: ["read"], effect: allow, resources: ["secrets:dev:<.*>"]}
+  - {subjects: ["users:bob"], actions: ["read"], effect: allow, resources: ["secrets:prod:db", "secrets:production"]}
+  - {subjects: ["users:carol"], actions: ["read"], effect: allow, resources: ["secrets:<prod|dev>:db"]}
+`,
+			issues: []policyIssue{
+				{Severity: lintWarning, Rule: "unreachable-statement", Statement: 1},
+				{Severity: lintWarning, Rule: "unreachable-resource", Statement: 2},
+			},
+		},
+		{
+			name: "shadowed, duplicate and redundant",
+			policy: `
+path: secrets/prod
+permissionDocument:
+  - {subjects: ["users:alice"], actions: ["read"], effect: allow, resources: ["secrets:prod:db"]}
+  - {subjects: ["users:<alice|bob>"], actions: ["<.*>"], effect: deny}
+  - {subjects: ["users:carol"], actions: ["read", "list"], effect: allow, resources: ["secrets:prod:<.*>"]}
+  - {subjects: ["users:carol"], actions: ["list", "read"], effect: Allow, resources: ["secrets:prod:<.*>"]}
+  - {subjects: ["users:carol"], actions: ["read"], effect: allow, resources: ["secrets:prod:db:<.*>"]}
+  - subjects: ["users:dave"]
+    actions: ["read"]
+    effect: deny
+    resources: ["secrets:prod:<.*>"]
+    conditions:
+      remoteIP: {type: CIDRCondition, options: {cidr: 10.0.0.0/8}}
+  - {subjects: ["users:dave"], actions: ["read"], effect: allow, resources: ["secrets:prod:db"]}
+`,
+			issues: []policyIssue{
+				{Severity: lintWarning, Rule: "shadowed-allow", Statement: 1},
+				{Severity: lintWarning, Rule: "empty-resources", Statement: 2},
+				{Severity: lintWarning, Rule: "duplicate-statement", Statement: 4},
+				{Severity: lintInfo, Rule: "redundant-statement", Statement: 5},
+			},
+		},
+	}
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := parsePolicyDocument([]byte(tt.policy))
+			assert.NoError(t, err)
+
+			report := lintPolicy(doc)
+			assert.Len(t, report.Issues, len(tt.issues), "%+v", report.Issues)
+			errs, warnings := 0, 0
+			for i, issue := range tt.issues {
+				switch issue.Severity {
+				case lintError:
+					errs++
+				case lintWarning:
+					warnings++
+				}
+				if i >= len(report.Issues) {
+					continue
+				}
+				assert.Equal(t, issue.Severity, report.Issues[i].Severity, report.Issues[i].Message)
+				assert.Equal(t, issue.Rule, report.Issues[i].Rule, report.Issues[i].Message)
+				assert.Equal(t, issue.Statement, report.Issues[i].Statement, report.Issues[i].Message)
+			}
+			assert.Equal(t, errs, report.Errors)
+			assert.Equal(t, warnings, report.Warnings)
+		})
+	}
+}
+
+func TestHandlePolicyLintCmd(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "policy.yml")
+	policy := "permissionDocument:\n  - {subjects: [\"<.*>\"], actions: [read], effect: allow, resources: [\"<.*>\"]}\n"
+	assert.NoError(t, os.WriteFile(file, []byte(policy), 0o600))
+
+	testCases := []struct {
+		name   string
+		args   []string
+		data   string
+		errors int
+		err    string
+	}{
+		{name: "file", args: []string{"@" + file}, errors: 1, err: "policy has 1 error(s)"},
+		{name: "data", data: `{"permissionDocument":[{"subjects":["users:alice"],"actions":["read"],"effect":"allow","resources":["secrets:<.*>"]}]}`},
+		{name: "invalid data", data: "[", err: "Failed to parse the policy"},
+		{name: "missing file", args: []string{"@" + filepath.Join(dir, "missing.yml")}, err: "no such file"},
+		{name: "remote", args: []string{"secrets/prod"}},
+		{name: "remote not found", args: []string{"secrets/dev"}, err: "error: policy secrets:dev not found"},
+		{name: "no path", err: "error: must specify a policy path or a file"},
+	}
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			viper.Reset()
+			defer viper.Reset()
+			viper.Set(cst.Data, tt.data)
+
+			var data []byte
+			outClient := &fake.FakeOutClient{}
+			outClient.WriteResponseStub = func(b []byte, apiErr *errors.ApiError) { data = b }
+			authenticator := &fake.FakeAuthenticator{}
+			authenticator.GetTokenStub = func() (*auth.TokenResponse, *errors.ApiError) {
+				return &auth.TokenResponse{Token: "token"}, nil
+			}
+			httpClient := &fake.FakeClient{}
+			httpClient.DoRequestStub = func(method string, uri string, body interface{}) ([]byte, *errors.ApiError) {
+				if filepath.Base(uri) == "secrets:prod" {
+					return []byte(`{"path":"secrets:prod","permissionDocument":[{"subjects":["users:alice"],"actions":["read"],"effect":"allow","resources":["secrets:prod:<.*>"]}]}`), nil
+				}
+				return nil, errors.NewS("not found").WithResponse(&http.Response{StatusCode: http.StatusNotFound})
+			}
+			vcli, err := vaultcli.NewWithOpts(
+				vaultcli.WithOutClient(outClient),
+				vaultcli.WithHTTPClient(httpClient),
+				vaultcli.WithAuthenticator(authenticator),
+			)
+			assert.NoError(t, err)
+
+			err = handlePolicyLintCmd(vcli, tt.args)
+			if tt.err != "" {
+				assert.ErrorContains(t, err, tt.err)
+			} else {
+				assert.NoError(t, err)
+			}
+			if data == nil {
+				return
+			}
+			var report policyLintReport
+			assert.NoError(t, json.Unmarshal(data, &report))
+			assert.Equal(t, tt.errors, report.Errors)
+		})
+	}
+}
@@ -63,6 +63,7 @@ func TestHandlePolicyReadCommand(t *testing.T) {
 		name            string
 		fPath           string // flag: --path
 		fVersion        string // flag: --version
+		fAtVersion      string // flag: --at-version
 		args            []string
 		apiOut          []byte
 		apiErr          *errors.ApiError
@@ -83,6 +84,20 @@ func TestHandlePolicyReadCommand(t *testing.T) {
 			apiOut:   []byte(`{"out":"val"}`),
 			wantOut:  []byte(`{"out":"val"}`),
 		},
+		{
+			name:       "Path and exact version",
+			fPath:      "secrets:databases:postgres58",
+			fAtVersion: "3",
+			apiOut:     []byte(`{"out":"val","version":"3"}`),
+			wantOut:    []byte(`{"out":"val","version":"3"}`),
+		},
+		{
+			name:            "Version and exact version",
+			fPath:           "secrets:databases:postgres58",
+			fVersion:        "3",
+			fAtVersion:      "3",
+			wantNonZeroCode: true,
+		},
 		{
 			name:    "Path from args",
 			args:    []string{"secrets:databases:postgres58"},
@@ -129,6 +144,7 @@ func TestHandlePolicyReadCommand(t *testing.T) {
 			viper.Reset()
 			viper.Set(cst.Path, tt.fPath)
 			viper.Set(cst.Version, tt.fVersion)
+			viper.Set(cst.AtVersion, tt.fAtVersion)
 
 			code := handlePolicyReadCmd(vcli, tt.args)
 			if tt.wantErr == nil {
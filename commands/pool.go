@@ -8,6 +8,7 @@ import (
 
 	cst "github.com/DelineaXPM/dsv-cli/constants"
 	"github.com/DelineaXPM/dsv-cli/errors"
+	"github.com/DelineaXPM/dsv-cli/format"
 	"github.com/DelineaXPM/dsv-cli/internal/predictor"
 	"github.com/DelineaXPM/dsv-cli/paths"
 	"github.com/DelineaXPM/dsv-cli/utils"
@@ -77,13 +78,13 @@ Usage:
    • pool list
    • pool list --sort asc --sorted-by name
 `,
-		FlagsPredictor: []*predictor.Params{
+		FlagsPredictor: append([]*predictor.Params{
 			{Name: cst.Sort, Usage: cst.SortHelpMessage, Default: "desc"},
 			{Name: cst.SortedBy, Usage: "Sort by name or created field (optional)", Default: "created"},
 			{Name: cst.Limit, Shorthand: "l", Usage: cst.LimitHelpMessage},
-			{Name: cst.Cursor, Usage: cst.CursorHelpMessage},
-		},
-		RunFunc: handlePoolList,
+		}, paginationFlags()...),
+		Encodings: format.ListEncodings,
+		RunFunc:   handlePoolList,
 	})
 }
 
@@ -137,13 +138,18 @@ func handlePoolCreate(vcli vaultcli.CLI, args []string) int {
 }
 
 func handlePoolList(vcli vaultcli.CLI, args []string) int {
-	data, apiErr := poolList(vcli, &poolListParams{
+	params := &poolListParams{
 		sort:     viper.GetString(cst.Sort),
 		sortedBy: viper.GetString(cst.SortedBy),
 		limit:    viper.GetString(cst.Limit),
-		cursor:   viper.GetString(cst.Cursor),
+	}
+	apiErr := writePages(vcli, viper.GetString(cst.Cursor), func(cursor string) ([]byte, *errors.ApiError) {
+		params.cursor = cursor
+		return poolList(vcli, params)
 	})
-	vcli.Out().WriteResponse(data, apiErr)
+	if apiErr != nil {
+		vcli.Out().WriteResponse(nil, apiErr)
+	}
 	return utils.GetExecStatus(apiErr)
 }
 
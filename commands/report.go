@@ -6,6 +6,7 @@ import (
 
 	cst "github.com/DelineaXPM/dsv-cli/constants"
 	"github.com/DelineaXPM/dsv-cli/errors"
+	"github.com/DelineaXPM/dsv-cli/format"
 	"github.com/DelineaXPM/dsv-cli/internal/predictor"
 	"github.com/DelineaXPM/dsv-cli/paths"
 	"github.com/DelineaXPM/dsv-cli/utils"
@@ -44,7 +45,8 @@ Usage:
 			{Name: cst.OffSet, Usage: "Offset for the next secrets (optional)"},
 			{Name: cst.Cursor, Usage: cst.CursorHelpMessage},
 		},
-		RunFunc: handleSecretReport,
+		RunFunc:   handleSecretReport,
+		Encodings: format.ListEncodings,
 	})
 }
 
@@ -63,7 +65,8 @@ Usage:
 			{Name: cst.Limit, Shorthand: "l", Usage: cst.LimitHelpMessage},
 			{Name: cst.OffSet, Usage: "Offset for the next groups (optional)"},
 		},
-		RunFunc: handleGroupReport,
+		RunFunc:   handleGroupReport,
+		Encodings: format.ListEncodings,
 	})
 }
 
@@ -12,6 +12,7 @@ import (
 
 	cst "github.com/DelineaXPM/dsv-cli/constants"
 	"github.com/DelineaXPM/dsv-cli/errors"
+	"github.com/DelineaXPM/dsv-cli/format"
 	"github.com/DelineaXPM/dsv-cli/internal/predictor"
 	"github.com/DelineaXPM/dsv-cli/paths"
 	"github.com/DelineaXPM/dsv-cli/utils"
@@ -77,14 +78,14 @@ Usage:
    • role search --query adm --limit 10
    • role search --sort asc --sorted-by name
 `,
-		FlagsPredictor: []*predictor.Params{
+		FlagsPredictor: append([]*predictor.Params{
 			{Name: cst.Query, Shorthand: "q", Usage: "Query of roles to fetch (optional)"},
 			{Name: cst.Limit, Shorthand: "l", Usage: cst.LimitHelpMessage},
-			{Name: cst.Cursor, Usage: cst.CursorHelpMessage},
 			{Name: cst.Sort, Usage: cst.SortHelpMessage, Default: "desc"},
 			{Name: cst.SortedBy, Usage: "Sort by name, created or lastModified field (optional)", Default: "lastModified"},
-		},
-		RunFunc: handleRoleSearchCmd,
+		}, paginationFlags()...),
+		Encodings: format.ListEncodings,
+		RunFunc:   handleRoleSearchCmd,
 	})
 }
 
@@ -195,14 +196,19 @@ func handleRoleSearchCmd(vcli vaultcli.CLI, args []string) int {
 		query = args[0]
 	}
 
-	data, apiErr := roleSearch(vcli, &roleSearchParams{
+	params := &roleSearchParams{
 		query:    query,
 		limit:    limit,
-		cursor:   cursor,
 		sort:     sort,
 		sortedBy: sortedBy,
+	}
+	apiErr := writePages(vcli, cursor, func(cursor string) ([]byte, *errors.ApiError) {
+		params.cursor = cursor
+		return roleSearch(vcli, params)
 	})
-	vcli.Out().WriteResponse(data, apiErr)
+	if apiErr != nil {
+		vcli.Out().WriteResponse(nil, apiErr)
+	}
 	return utils.GetExecStatus(apiErr)
 }
 
@@ -0,0 +1,269 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"os/signal"
+	"regexp"
+	"sort"
+	"strings"
+	"text/template"
+
+	cst "github.com/DelineaXPM/dsv-cli/constants"
+	"github.com/DelineaXPM/dsv-cli/errors"
+	"github.com/DelineaXPM/dsv-cli/internal/predictor"
+	"github.com/DelineaXPM/dsv-cli/utils"
+	"github.com/DelineaXPM/dsv-cli/vaultcli"
+
+	"github.com/mitchellh/cli"
+	"github.com/spf13/viper"
+)
+
+func GetRunCmd() (cli.Command, error) {
+	return NewCommand(CommandArgs{
+		Path:         []string{cst.Run},
+		SynopsisText: fmt.Sprintf("%s (--%s <path>)... [--env-prefix <prefix>] [--env-template <template>] -- <command> [args...]", cst.Run, cst.NounSecret),
+		HelpText: fmt.Sprintf(`Run a command with %[1]s data injected into its environment
+
+Every key of the "data" object of each %[1]s becomes an environment variable of the child process.
+By default the variable name is the upper-cased key with all characters other than letters, digits
+and underscores replaced by underscores, prepended with --env-prefix.
+
+Use --env-template to fully control variable names. The template is a Go template which receives
+.Path, .Key and .Prefix and can use the "upper", "lower" and "envname" functions.
+
+Values are never printed. Signals received by the CLI are forwarded to the child process and
+the CLI exits with the exit code of the child process.
+
+Usage:
+   • %[2]s --%[1]s %[3]s -- ./server
+   • %[2]s --%[1]s %[3]s --%[1]s api/keys --env-prefix APP_ -- ./server --port 8080
+   • %[2]s --%[1]s %[3]s --env-template '{{ .Path | envname }}_{{ .Key | upper }}' -- env
+`, cst.NounSecret, cst.Run, cst.ExamplePath),
+		FlagsPredictor: runFlags(),
+		MinNumberArgs:  1,
+		ServedByAgent:  true,
+		RunFunc:        handleRunCmd,
+	})
+}
+
+func runFlags() []*predictor.Params {
+	return []*predictor.Params{
+		{Name: cst.NounSecret, Usage: fmt.Sprintf("Path to a %s to inject, can be repeated or comma-separated (required)", cst.NounSecret), ValueType: "list", Predictor: predictor.NewSecretPathPredictorDefault()},
+		{Name: cst.EnvPrefix, Usage: "Prefix added to the name of every environment variable (optional)"},
+		{Name: cst.EnvTemplate, Usage: "Go template used to build the name of every environment variable (optional)"},
+	}
+}
+
+func handleRunCmd(vcli vaultcli.CLI, args []string) int {
+	command := runCommandFromArgs(args)
+	if len(command) == 0 {
+		vcli.Out().FailS("error: must specify a command to run after '--'")
+		return 1
+	}
+
+	secretPaths := []string{}
+	for _, p := range utils.StringToSlice(viper.GetString(cst.NounSecret)) {
+		if p != "" {
+			secretPaths = append(secretPaths, p)
+		}
+	}
+	if len(secretPaths) == 0 {
+		vcli.Out().FailF("error: must specify at least one --%s", cst.NounSecret)
+		return 1
+	}
+
+	namer, err := newRunEnvNamer(viper.GetString(cst.EnvPrefix), viper.GetString(cst.EnvTemplate))
+	if err != nil {
+		vcli.Out().FailF("error: invalid --%s: %v", vaultcli.ToFlagName(cst.EnvTemplate), err)
+		return 1
+	}
+
+	env, apiErr := runBuildEnv(vcli, secretPaths, namer)
+	if apiErr != nil {
+		// Only the error is written, secret data never reaches the output.
+		vcli.Out().FailE(apiErr)
+		return utils.GetExecStatus(apiErr)
+	}
+
+	return runExec(vcli, command, append(os.Environ(), env...))
+}
+
+// runCommandFromArgs returns everything after the "--" separator. If the separator is
+// missing, all arguments which are not flags of the run command are considered to be the command.
+func runCommandFromArgs(args []string) []string {
+	for i, arg := range args {
+		if arg == "--" {
+			return args[i+1:]
+		}
+	}
+	boolFlags := make(map[string]bool)
+	for _, p := range append(BasePredictorWrappers(), runFlags()...) {
+		if p.ValueType == "bool" {
+			boolFlags["--"+vaultcli.ToFlagName(p.Name)] = true
+			if p.Shorthand != "" {
+				boolFlags["-"+p.Shorthand] = true
+			}
+		}
+	}
+	command := []string{}
+	for i := 0; i < len(args); i++ {
+		if strings.HasPrefix(args[i], "-") {
+			// Skip the flag value unless the flag takes no value or the value is set with "=".
+			if !boolFlags[args[i]] && !strings.Contains(args[i], "=") {
+				i++
+			}
+			continue
+		}
+		command = append(command, args[i:]...)
+		break
+	}
+	return command
+}
+
+// runBuildEnv reads every secret and converts its data to a list of "KEY=value" pairs.
+func runBuildEnv(vcli vaultcli.CLI, secretPaths []string, namer *runEnvNamer) ([]string, *errors.ApiError) {
+	vars := make(map[string]string)
+	for _, path := range secretPaths {
+		resp, apiErr := getSecret(vcli, cst.NounSecret, path, "", "")
+		if apiErr != nil {
+			return nil, apiErr.Grow(fmt.Sprintf("Failed to read %s %q", cst.NounSecret, path))
+		}
+
+		secret := &secretGetResponse{}
+		if err := json.Unmarshal(resp, secret); err != nil {
+			return nil, errors.New(err).Grow(fmt.Sprintf("Failed to parse %s %q", cst.NounSecret, path))
+		}
+
+		for key, val := range secret.Data {
+			name, err := namer.name(path, key)
+			if err != nil {
+				return nil, errors.New(err).Grow(fmt.Sprintf("Failed to build variable name for key %q", key))
+			}
+			if name == "" {
+				return nil, errors.NewF("Variable name for key %q of %s %q is empty", key, cst.NounSecret, path)
+			}
+			if _, ok := vars[name]; ok {
+				log.Printf("Variable %s is defined more than once, using the value from %q.", name, path)
+			}
+
+			value, err := runEnvValue(val)
+			if err != nil {
+				return nil, errors.New(err).Grow(fmt.Sprintf("Failed to convert value of key %q", key))
+			}
+			vars[name] = value
+		}
+	}
+
+	names := make([]string, 0, len(vars))
+	for name := range vars {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	env := make([]string, 0, len(vars))
+	for _, name := range names {
+		env = append(env, name+"="+vars[name])
+	}
+	return env, nil
+}
+
+// runEnvValue converts a value of secret data to a string. Strings are used as is,
+// everything else is encoded as JSON.
+func runEnvValue(val interface{}) (string, error) {
+	if s, ok := val.(string); ok {
+		return s, nil
+	}
+	b, err := json.Marshal(val)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+func runExec(vcli vaultcli.CLI, command []string, env []string) int {
+	c := exec.Command(command[0], command[1:]...)
+	c.Env = env
+	c.Stdin = os.Stdin
+	c.Stdout = os.Stdout
+	c.Stderr = os.Stderr
+
+	if err := c.Start(); err != nil {
+		vcli.Out().FailF("error: failed to start %q: %v", command[0], err)
+		return 1
+	}
+
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, runForwardedSignals...)
+	defer signal.Stop(sigs)
+
+	go func() {
+		for sig := range sigs {
+			if err := c.Process.Signal(sig); err != nil {
+				log.Printf("Failed to forward signal %s: %v", sig, err)
+			}
+		}
+	}()
+
+	err := c.Wait()
+	if err == nil {
+		return 0
+	}
+	if c.ProcessState != nil {
+		return runExitStatus(c.ProcessState)
+	}
+	vcli.Out().FailF("error: %q failed: %v", command[0], err)
+	return 1
+}
+
+var runEnvNameInvalidChars = regexp.MustCompile(`[^A-Za-z0-9_]`)
+
+// runEnvName converts a string to a valid environment variable name.
+func runEnvName(s string) string {
+	return runEnvNameInvalidChars.ReplaceAllString(s, "_")
+}
+
+type runEnvNamer struct {
+	prefix string
+	tmpl   *template.Template
+}
+
+func newRunEnvNamer(prefix string, tmpl string) (*runEnvNamer, error) {
+	n := &runEnvNamer{prefix: prefix}
+	if tmpl == "" {
+		return n, nil
+	}
+
+	funcs := template.FuncMap{
+		"upper":   strings.ToUpper,
+		"lower":   strings.ToLower,
+		"envname": func(s string) string { return strings.ToUpper(runEnvName(s)) },
+	}
+	t, err := template.New(cst.Run).Funcs(funcs).Option("missingkey=error").Parse(tmpl)
+	if err != nil {
+		return nil, err
+	}
+	n.tmpl = t
+	return n, nil
+}
+
+func (n *runEnvNamer) name(path string, key string) (string, error) {
+	if n.tmpl == nil {
+		return n.prefix + strings.ToUpper(runEnvName(key)), nil
+	}
+
+	data := map[string]string{
+		"Path":   path,
+		"Key":    key,
+		"Prefix": n.prefix,
+	}
+	var b bytes.Buffer
+	if err := n.tmpl.Execute(&b, data); err != nil {
+		return "", err
+	}
+	return runEnvName(strings.TrimSpace(b.String())), nil
+}
@@ -0,0 +1,125 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+
+	cst "github.com/DelineaXPM/dsv-cli/constants"
+	"github.com/DelineaXPM/dsv-cli/errors"
+	"github.com/DelineaXPM/dsv-cli/tests/fake"
+	"github.com/DelineaXPM/dsv-cli/vaultcli"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetRunCmd(t *testing.T) {
+	_, err := GetRunCmd()
+	assert.Nil(t, err)
+}
+
+func TestRunCommandFromArgs(t *testing.T) {
+	testCases := []struct {
+		name string
+		args []string
+		want []string
+	}{
+		{"separator", []string{"--secret", "a", "--", "./server", "--port", "80"}, []string{"./server", "--port", "80"}},
+		{"separator only", []string{"--"}, []string{}},
+		{"no separator", []string{"--secret", "a", "env"}, []string{"env"}},
+		{"no separator with equals", []string{"--secret=a", "env", "-0"}, []string{"env", "-0"}},
+		{"no command", []string{"--secret", "a"}, []string{}},
+		{"bool flag", []string{"--secret", "a", "--verbose", "env"}, []string{"env"}},
+		{"bool shorthand", []string{"-v", "--secret", "a", "env", "-v"}, []string{"env", "-v"}},
+	}
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, runCommandFromArgs(tt.args))
+		})
+	}
+}
+
+func TestRunEnvNamer(t *testing.T) {
+	testCases := []struct {
+		name     string
+		prefix   string
+		template string
+		path     string
+		key      string
+		want     string
+	}{
+		{"default", "", "", "db/prod", "password", "PASSWORD"},
+		{"default with invalid chars", "", "", "db/prod", "api-key.v2", "API_KEY_V2"},
+		{"prefix", "DB_", "", "db/prod", "password", "DB_PASSWORD"},
+		{"template", "", "{{ .Path | envname }}_{{ .Key | upper }}", "db/prod", "password", "DB_PROD_PASSWORD"},
+		{"template with prefix", "APP_", "{{ .Prefix }}{{ .Key }}", "db/prod", "password", "APP_password"},
+	}
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			n, err := newRunEnvNamer(tt.prefix, tt.template)
+			assert.NoError(t, err)
+			got, err := n.name(tt.path, tt.key)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	_, err := newRunEnvNamer("", "{{ .Key ")
+	assert.Error(t, err)
+}
+
+func TestRunBuildEnv(t *testing.T) {
+	responses := map[string][]byte{
+		"db/prod":  []byte(`{"path":"db/prod","data":{"password":"secret","port":5432}}`),
+		"api/keys": []byte(`{"path":"api/keys","data":{"token":"abc","password":"override"}}`),
+	}
+
+	httpClient := &fake.FakeClient{}
+	httpClient.DoRequestStub = func(method string, uri string, body interface{}) ([]byte, *errors.ApiError) {
+		for path, resp := range responses {
+			if strings.Contains(uri, "/secrets/"+path+"?") {
+				return resp, nil
+			}
+		}
+		return nil, errors.NewS("not found")
+	}
+
+	vcli, rerr := vaultcli.NewWithOpts(vaultcli.WithHTTPClient(httpClient))
+	if rerr != nil {
+		t.Fatalf("Unexpected error during vaultCLI init: %v", rerr)
+	}
+
+	viper.Reset()
+	viper.Set(cst.CacheStrategy, cst.CacheStrategyNever)
+
+	namer, _ := newRunEnvNamer("", "")
+	env, apiErr := runBuildEnv(vcli, []string{"db/prod", "api/keys"}, namer)
+	assert.Nil(t, apiErr)
+	assert.Equal(t, []string{"PASSWORD=override", "PORT=5432", "TOKEN=abc"}, env)
+
+	_, apiErr = runBuildEnv(vcli, []string{"missing"}, namer)
+	assert.NotNil(t, apiErr)
+}
+
+func TestHandleRunCmd(t *testing.T) {
+	var err *errors.ApiError
+	outClient := &fake.FakeOutClient{}
+	outClient.FailEStub = func(apiError *errors.ApiError) { err = apiError }
+	outClient.FailSStub = func(s string) { err = errors.NewS(s) }
+	outClient.FailFStub = func(format string, args ...interface{}) { err = errors.NewF(format, args...) }
+
+	vcli, rerr := vaultcli.NewWithOpts(vaultcli.WithOutClient(outClient))
+	if rerr != nil {
+		t.Fatalf("Unexpected error during vaultCLI init: %v", rerr)
+	}
+
+	viper.Reset()
+	code := handleRunCmd(vcli, []string{"--"})
+	assert.Equal(t, 1, code)
+	assert.NotNil(t, err)
+
+	err = nil
+	code = handleRunCmd(vcli, []string{"--", "env"})
+	assert.Equal(t, 1, code)
+	assert.NotNil(t, err)
+}
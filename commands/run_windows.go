@@ -0,0 +1,17 @@
+//go:build windows
+// +build windows
+
+package cmd
+
+import (
+	"os"
+)
+
+// runForwardedSignals lists signals which are passed to the child process of the run command.
+var runForwardedSignals = []os.Signal{
+	os.Interrupt,
+}
+
+func runExitStatus(ps *os.ProcessState) int {
+	return ps.ExitCode()
+}
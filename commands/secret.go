@@ -13,6 +13,7 @@ import (
 	"github.com/DelineaXPM/dsv-cli/auth"
 	cst "github.com/DelineaXPM/dsv-cli/constants"
 	"github.com/DelineaXPM/dsv-cli/errors"
+	"github.com/DelineaXPM/dsv-cli/format"
 	"github.com/DelineaXPM/dsv-cli/internal/predictor"
 	"github.com/DelineaXPM/dsv-cli/paths"
 	"github.com/DelineaXPM/dsv-cli/utils"
@@ -35,20 +36,31 @@ func GetNoDataOpWrappers(targetEntity string) []*predictor.Params {
 		{Name: cst.Path, Shorthand: "r", Usage: fmt.Sprintf("Target %s to a %s (required)", cst.Path, targetEntity), Predictor: predictor.NewSecretPathPredictorDefault()},
 		{Name: cst.ID, Shorthand: "i", Usage: fmt.Sprintf("Target %s for a %s", cst.ID, targetEntity)},
 		{Name: cst.Version, Usage: "List the current and last (n) versions"},
+		{Name: cst.AtVersion, Usage: fmt.Sprintf("Version of the %s to read [default:latest]", targetEntity)},
 	}
 }
 
-func GetSearchOpWrappers() []*predictor.Params {
+// secretEncodingFlags are flags of the encodings for secret data, see format.SecretEncodings.
+func secretEncodingFlags() []*predictor.Params {
 	return []*predictor.Params{
+		{Name: cst.KeyPrefix, Usage: fmt.Sprintf("Prefix added to keys of %s data with -e %s", cst.NounSecret, strings.Join(format.SecretEncodings, "|"))},
+		{Name: cst.KeySeparator, Usage: fmt.Sprintf("Separator joining keys of nested %s data [default:_]", cst.NounSecret)},
+		{Name: cst.KeyCase, Usage: fmt.Sprintf("Case of keys (%s|%s|%s) [default:%s, %s for -e %s]", format.KeyCaseUpper, format.KeyCaseLower, format.KeyCaseNone, format.KeyCaseUpper, format.KeyCaseNone, cst.K8s)},
+		{Name: cst.K8sName, Usage: fmt.Sprintf("Name of the Kubernetes Secret with -e %s [default:%s path]", cst.K8s, cst.NounSecret)},
+		{Name: cst.K8sNamespace, Usage: fmt.Sprintf("Namespace of the Kubernetes Secret with -e %s", cst.K8s)},
+	}
+}
+
+func GetSearchOpWrappers() []*predictor.Params {
+	return append([]*predictor.Params{
 		{Name: cst.Query, Shorthand: "q", Usage: fmt.Sprintf("%s of %ss to fetch (optional)", strings.Title(cst.Query), cst.NounSecret)},
 		{Name: cst.SearchLinks, Usage: "Find secrets that link to the secret path in the query", ValueType: "bool"},
 		{Name: cst.Limit, Shorthand: "l", Usage: cst.LimitHelpMessage},
-		{Name: cst.Cursor, Usage: cst.CursorHelpMessage},
 		{Name: cst.SearchField, Usage: "Advanced search on a secret field (optional)"},
 		{Name: cst.SearchType, Usage: "Specify the value type for advanced field searching, can be 'number' or 'string' (optional)"},
 		{Name: cst.SearchComparison, Usage: "Specify the operator for advanced field searching, can be 'contains' or 'equal' (optional)"},
 		{Name: cst.Sort, Usage: "Change result sorting order (asc|desc) [default: desc] when search field is specified (optional)"},
-	}
+	}, paginationFlags()...)
 }
 
 func GetSecretCmd() (cli.Command, error) {
@@ -61,8 +73,9 @@ Usage:
    • secret %[3]s
    • secret --path %[3]s
 `, cst.NounSecret, cst.ProductName, cst.ExamplePath),
-		FlagsPredictor: GetNoDataOpWrappers(cst.NounSecret),
+		FlagsPredictor: append(GetNoDataOpWrappers(cst.NounSecret), secretEncodingFlags()...),
 		MinNumberArgs:  1,
+		Encodings:      format.SecretEncodings,
 		RunFunc: func(vcli vaultcli.CLI, args []string) int {
 			id := viper.GetString(cst.ID)
 			path := viper.GetString(cst.Path)
@@ -87,10 +100,15 @@ Usage:
    • secret %[1]s %[4]s
    • secret %[1]s --path %[4]s -f data.Data.Key
    • secret %[1]s --version
+   • secret %[1]s %[4]s --at-version 2
+   • secret %[1]s %[4]s -e dotenv --key-prefix DB_ > .env
+   • secret %[1]s %[4]s -e k8s --k8s-namespace prod | kubectl apply -f -
 `, cst.Read, cst.NounSecret, cst.ProductName, cst.ExamplePath),
-		FlagsPredictor: GetNoDataOpWrappers(cst.NounSecret),
+		FlagsPredictor: append(GetNoDataOpWrappers(cst.NounSecret), secretEncodingFlags()...),
 		ArgsPredictor:  predictor.NewSecretPathPredictorDefault(),
 		MinNumberArgs:  1,
+		ServedByAgent:  true,
+		Encodings:      format.SecretEncodings,
 		RunFunc: func(vcli vaultcli.CLI, args []string) int {
 			return handleSecretReadCmd(vcli, cst.NounSecret, args)
 		},
@@ -110,6 +128,7 @@ Usage:
 		FlagsPredictor: DescribeOpWrappers(cst.NounSecret),
 		ArgsPredictor:  predictor.NewSecretPathPredictorDefault(),
 		MinNumberArgs:  1,
+		ServedByAgent:  true,
 		RunFunc: func(vcli vaultcli.CLI, args []string) int {
 			return handleSecretDescribeCmd(vcli, cst.NounSecret, args)
 		},
@@ -122,15 +141,20 @@ func GetSecretDeleteCmd() (cli.Command, error) {
 		SynopsisText: fmt.Sprintf("%s %s (<path> | --path|-r)", cst.NounSecret, cst.Delete),
 		HelpText: fmt.Sprintf(`Delete a %[2]s from %[3]s
 
+With --recursive all %[2]ss under the path are deleted. The path can be a glob pattern
+where * matches any part of a single path segment. Matching %[2]ss are found with search.
+
 Usage:
    • secret %[1]s %[4]s
    • secret %[1]s --path %[4]s --force
+   • secret %[1]s --path staging --recursive --dry-run
+   • secret %[1]s "staging/*/password" --workers 8 --stop-on-error
 `, cst.Delete, cst.NounSecret, cst.ProductName, cst.ExamplePath),
-		FlagsPredictor: []*predictor.Params{
+		FlagsPredictor: append([]*predictor.Params{
 			{Name: cst.Path, Shorthand: "r", Usage: fmt.Sprintf("Target %s to a %s (required)", cst.Path, cst.NounSecret), Predictor: predictor.NewSecretPathPredictorDefault()},
 			{Name: cst.ID, Shorthand: "i", Usage: fmt.Sprintf("Target %s for a %s", cst.ID, cst.NounSecret)},
 			{Name: cst.Force, Usage: fmt.Sprintf("Immediately delete %s", cst.NounSecret), ValueType: "bool"},
-		},
+		}, secretBulkFlags()...),
 		ArgsPredictor: predictor.NewSecretPathPredictorDefault(),
 		MinNumberArgs: 1,
 		RunFunc: func(vcli vaultcli.CLI, args []string) int {
@@ -141,17 +165,25 @@ Usage:
 
 func GetSecretRestoreCmd() (cli.Command, error) {
 	return NewCommand(CommandArgs{
-		Path:         []string{cst.NounSecret, cst.Read},
+		Path:         []string{cst.NounSecret, cst.Restore},
 		SynopsisText: fmt.Sprintf("%s %s (<path> | --path|-r)", cst.NounSecret, cst.Restore),
 		HelpText: fmt.Sprintf(`Restore a deleted %[2]s from %[3]s
 
+With --recursive all deleted %[2]ss under the path are restored. The path can be a glob pattern
+where * matches any part of a single path segment. Search returns only live %[2]ss, so deleted
+%[2]ss are found in audit records of deletions since --startdate, 30 days ago by default.
+Deleted %[2]ss which exist again are skipped.
+
 Usage:
    • secret %[1]s %[4]s
+   • secret %[1]s --path staging --recursive --dry-run
+   • secret %[1]s "staging/*/password" --startdate 2026-10-01
 `, cst.Restore, cst.NounSecret, cst.ProductName, cst.ExamplePath),
-		FlagsPredictor: []*predictor.Params{
+		FlagsPredictor: append([]*predictor.Params{
 			{Name: cst.Path, Shorthand: "r", Usage: fmt.Sprintf("Target %s to a %s (required)", cst.Path, cst.NounSecret), Predictor: predictor.NewSecretPathPredictorDefault()},
 			{Name: cst.ID, Shorthand: "i", Usage: fmt.Sprintf("Target %s for a %s", cst.ID, cst.NounSecret)},
-		},
+			{Name: cst.StartDate, Shorthand: "s", Usage: fmt.Sprintf("Date from which deleted %ss are looked up with --recursive or a glob pattern, e.g. 2026-10-01 [default:30 days ago]", cst.NounSecret)},
+		}, secretBulkFlags()...),
 		ArgsPredictor: predictor.NewSecretPathPredictorDefault(),
 		MinNumberArgs: 1,
 		RunFunc: func(vcli vaultcli.CLI, args []string) int {
@@ -286,6 +318,7 @@ Usage:
     • secret %[1]s --query production --search-field attributes.stage --search-comparison equal
 `, cst.Search, cst.NounSecret, cst.ProductName, cst.ExampleUserSearch),
 		FlagsPredictor: GetSearchOpWrappers(),
+		Encodings:      format.ListEncodings,
 		RunFunc: func(vcli vaultcli.CLI, args []string) int {
 			return handleSecretSearchCmd(vcli, cst.NounSecret, args)
 		},
@@ -337,10 +370,21 @@ func handleSecretReadCmd(vcli vaultcli.CLI, secretType string, args []string) in
 		path = args[0]
 	}
 	version := strings.TrimSpace(viper.GetString(cst.Version))
-	if version != "" {
-		version = fmt.Sprint("/", cst.Version, "/", version)
+	atVersion := strings.TrimSpace(viper.GetString(cst.AtVersion))
+	if version != "" && atVersion != "" {
+		vcli.Out().FailF("error: --%s and --%s cannot be used together", cst.Version, strings.ReplaceAll(cst.AtVersion, ".", "-"))
+		return 1
+	}
+	var resp []byte
+	var err *errors.ApiError
+	if atVersion != "" {
+		resp, err = readVersion(secretVersionSource(vcli, secretType, path, id), atVersion)
+	} else {
+		if version != "" {
+			version = fmt.Sprint("/", cst.Version, "/", version)
+		}
+		resp, err = getSecret(vcli, secretType, path, id, version)
 	}
-	resp, err := getSecret(vcli, secretType, path, id, version)
 
 	vcli.Out().WriteResponse(resp, err)
 	return utils.GetExecStatus(err)
@@ -354,6 +398,11 @@ func handleSecretRestoreCmd(vcli vaultcli.CLI, secretType string, args []string)
 	if path == "" && len(args) > 0 && !strings.HasPrefix(args[0], "-") {
 		path = args[0]
 	}
+	if secretType == cst.NounSecret && isSecretBulkPath(path) {
+		return wrapError(func(vcli vaultcli.CLI, _ []string) error {
+			return handleSecretBulkRestoreCmd(vcli, path)
+		})(vcli, args)
+	}
 
 	rc, rerr := getResourceConfig(path, secretType)
 	if rerr != nil {
@@ -384,7 +433,6 @@ func handleSecretSearchCmd(vcli vaultcli.CLI, secretType string, args []string)
 	queryParams := map[string]string{
 		cst.SearchKey:        query,
 		cst.Limit:            limit,
-		cst.Cursor:           cursor,
 		cst.SearchType:       searchType,
 		cst.SearchComparison: searchComparison,
 		cst.SearchField:      searchField,
@@ -399,13 +447,47 @@ func handleSecretSearchCmd(vcli vaultcli.CLI, secretType string, args []string)
 		vcli.Out().Fail(rerr)
 		return utils.GetExecStatus(rerr)
 	}
-	uri := paths.CreateResourceURI(rc.resourceType, "", "", false, queryParams)
-	data, err := vcli.HTTPClient().DoRequest(http.MethodGet, uri, nil)
-
-	vcli.Out().WriteResponse(data, err)
+	err := writePages(vcli, cursor, func(cursor string) ([]byte, *errors.ApiError) {
+		queryParams[cst.Cursor] = cursor
+		uri := paths.CreateResourceURI(rc.resourceType, "", "", false, queryParams)
+		return vcli.HTTPClient().DoRequest(http.MethodGet, uri, nil)
+	})
+	if err != nil {
+		vcli.Out().WriteResponse(nil, err)
+	}
 	return utils.GetExecStatus(err)
 }
 
+// secretSearchAll follows the cursor and returns secrets from all pages of the search results.
+func secretSearchAll(vcli vaultcli.CLI, secretType string, query string) ([]*secretSearchItem, *errors.ApiError) {
+	rc, rerr := getResourceConfig("", secretType)
+	if rerr != nil {
+		return nil, errors.New(rerr)
+	}
+
+	found, apiErr := readPages(func(cursor string) ([]byte, *errors.ApiError) {
+		queryParams := map[string]string{
+			cst.SearchKey: query,
+			cst.Limit:     "100",
+			cst.Cursor:    cursor,
+		}
+		uri := paths.CreateResourceURI(rc.resourceType, "", "", false, queryParams)
+		return vcli.HTTPClient().DoRequest(http.MethodGet, uri, nil)
+	})
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	items := make([]*secretSearchItem, 0, len(found))
+	for _, raw := range found {
+		item := &secretSearchItem{}
+		if err := json.Unmarshal(raw, item); err != nil {
+			return nil, errors.New(err).Grow("Failed to parse search results")
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
 func handleSecretDeleteCmd(vcli vaultcli.CLI, secretType string, args []string) int {
 	id := viper.GetString(cst.ID)
 	path := viper.GetString(cst.Path)
@@ -417,6 +499,11 @@ func handleSecretDeleteCmd(vcli vaultcli.CLI, secretType string, args []string)
 	if path == "" && len(args) > 0 && !strings.HasPrefix(args[0], "-") {
 		path = args[0]
 	}
+	if secretType == cst.NounSecret && id == "" && isSecretBulkPath(path) {
+		return wrapError(func(vcli vaultcli.CLI, _ []string) error {
+			return handleSecretBulkDeleteCmd(vcli, secretType, path)
+		})(vcli, args)
+	}
 
 	query := map[string]string{"force": strconv.FormatBool(force)}
 	rc, rerr := getResourceConfig(path, secretType)
@@ -548,16 +635,21 @@ func handleSecretUpsertCmd(vcli vaultcli.CLI, secretType string, action string,
 		Overwrite:   overwrite,
 	}
 
+	resp, err := secretUpsert(vcli, action, uri, &postData)
+
+	vcli.Out().WriteResponse(resp, err)
+	return utils.GetExecStatus(err)
+}
+
+// secretUpsert creates (POST) or updates (PUT) a secret depending on the action.
+func secretUpsert(vcli vaultcli.CLI, action string, uri string, body *secretUpsertBody) ([]byte, *errors.ApiError) {
 	var reqMethod string
 	if action == cst.Create {
 		reqMethod = http.MethodPost
 	} else {
 		reqMethod = http.MethodPut
 	}
-	resp, err := vcli.HTTPClient().DoRequest(reqMethod, uri, &postData)
-
-	vcli.Out().WriteResponse(resp, err)
-	return utils.GetExecStatus(err)
+	return vcli.HTTPClient().DoRequest(reqMethod, uri, body)
 }
 
 func handleSecretCreateWizard(vcli vaultcli.CLI) int {
@@ -942,7 +1034,7 @@ func getSecret(vcli vaultcli.CLI, secretType string, path string, id string, req
 
 		resp, apiErr := getSecretFromServer(vcli, secretType, path, id, false, requestSuffix)
 		if apiErr == nil {
-			putSecretToCache(vcli, secretCacheKey, resp)
+			putSecretToCache(vcli, secretCacheKey, secretCachePath(path, id), resp)
 			return resp, nil
 		}
 
@@ -967,7 +1059,7 @@ func getSecret(vcli vaultcli.CLI, secretType string, path string, id string, req
 		if apiErr != nil {
 			return nil, apiErr
 		}
-		putSecretToCache(vcli, secretCacheKey, resp)
+		putSecretToCache(vcli, secretCacheKey, secretCachePath(path, id), resp)
 
 		return resp, nil
 
@@ -982,7 +1074,7 @@ func getSecret(vcli vaultcli.CLI, secretType string, path string, id string, req
 
 		resp, apiErr := getSecretFromServer(vcli, secretType, path, id, false, requestSuffix)
 		if apiErr == nil {
-			putSecretToCache(vcli, secretCacheKey, resp)
+			putSecretToCache(vcli, secretCacheKey, secretCachePath(path, id), resp)
 			return resp, nil
 		}
 
@@ -992,6 +1084,36 @@ func getSecret(vcli vaultcli.CLI, secretType string, path string, id string, req
 		}
 		return nil, apiErr
 
+	case cst.CacheStrategyRevalidate:
+		if requestSuffix != "" {
+			return getSecretFromServer(vcli, secretType, path, id, false, requestSuffix)
+		}
+		secretCacheKey := getSecretCacheKey(path, id, requestSuffix)
+
+		cacheData, expired := getSecretFromCache(vcli, secretCacheKey)
+		if len(cacheData) > 0 {
+			// The description is much smaller than a secret with data, but has the same version.
+			desc, apiErr := getSecretFromServer(vcli, secretType, path, id, false, cst.SuffixDescription)
+			switch {
+			case apiErr == nil && utils.GetVersion(desc) != "" && utils.GetVersion(desc) == utils.GetVersion(cacheData):
+				log.Print("Cached secret is up to date. Returning secret data from cache.")
+				putSecretToCache(vcli, secretCacheKey, secretCachePath(path, id), cacheData)
+				return cacheData, nil
+			case apiErr != nil && apiErr.HttpResponse() == nil && !expired:
+				log.Print("Failed to reach server to revalidate the cached secret. Returning secret data from cache.")
+				return cacheData, nil
+			case apiErr != nil && apiErr.HttpResponse() == nil:
+				return nil, apiErr
+			}
+		}
+
+		resp, apiErr := getSecretFromServer(vcli, secretType, path, id, false, requestSuffix)
+		if apiErr != nil {
+			return nil, apiErr
+		}
+		putSecretToCache(vcli, secretCacheKey, secretCachePath(path, id), resp)
+		return resp, nil
+
 	default:
 		// In case of unknown cache strategy CLI acts as it is set to "store.Never".
 		log.Printf("Unsupported cache strategy %q. Requesting secret from server.", cacheStrategy)
@@ -1054,17 +1176,30 @@ func getSecretFromCache(vcli vaultcli.CLI, key string) (cacheData []byte, expire
 		log.Printf("Failed to fetch cached secret from store type %s. Error: %s", st, err.Error())
 	} else {
 		cacheData = data.Data
-		cacheAgeMinutes := viper.GetInt(cst.CacheAge)
-		if cacheAgeMinutes > 0 {
-			expired = (data.Date.Sub(time.Now().UTC()).Seconds() + float64(cacheAgeMinutes)*60) < 0
-		} else {
-			log.Printf("Invalid cache age: %d", cacheAgeMinutes)
-		}
+		expired = isSecretCacheExpired(data.Date)
 	}
 	return cacheData, expired
 }
 
-func putSecretToCache(vcli vaultcli.CLI, key string, data []byte) {
+// isSecretCacheExpired reports whether a secret cached at the given time is older than cache.age.
+func isSecretCacheExpired(date time.Time) bool {
+	cacheAgeMinutes := viper.GetInt(cst.CacheAge)
+	if cacheAgeMinutes <= 0 {
+		log.Printf("Invalid cache age: %d", cacheAgeMinutes)
+		return false
+	}
+	return (date.Sub(time.Now().UTC()).Seconds() + float64(cacheAgeMinutes)*60) < 0
+}
+
+// secretCachePath returns the path or the ID of a secret as it is shown by cache commands.
+func secretCachePath(path string, id string) string {
+	if path == "" {
+		return id
+	}
+	return strings.ReplaceAll(path, ":", "/")
+}
+
+func putSecretToCache(vcli vaultcli.CLI, key string, path string, data []byte) {
 	st := viper.GetString(cst.StoreType)
 	s, err := vcli.Store(st)
 	if err != nil {
@@ -1072,7 +1207,7 @@ func putSecretToCache(vcli vaultcli.CLI, key string, data []byte) {
 		return
 	}
 
-	err = s.Store(key, secretData{Date: time.Now().UTC(), Data: data})
+	err = s.Store(key, secretData{Date: time.Now().UTC(), Data: data, Path: path, Profile: viper.GetString(cst.Profile)})
 	if err != nil {
 		log.Printf("Failed to cache secret for store type %s. Error: %s", st, err)
 	}
@@ -1081,6 +1216,10 @@ func putSecretToCache(vcli vaultcli.CLI, key string, data []byte) {
 type secretData struct {
 	Date time.Time
 	Data []byte
+
+	// Path and Profile identify the secret for cache commands. Entries cached by older versions do not have them.
+	Path    string `json:",omitempty"`
+	Profile string `json:",omitempty"`
 }
 
 // secretGetResponse contains only info that can be updated.
@@ -1090,6 +1229,13 @@ type secretGetResponse struct {
 	Data        map[string]interface{} `json:"data"`
 }
 
+type secretSearchItem struct {
+	Path        string                 `json:"path"`
+	Description string                 `json:"description"`
+	Attributes  map[string]interface{} `json:"attributes"`
+	Data        map[string]interface{} `json:"data"`
+}
+
 type secretUpsertBody struct {
 	Data        map[string]interface{}
 	Description string
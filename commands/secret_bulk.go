@@ -0,0 +1,465 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	cst "github.com/DelineaXPM/dsv-cli/constants"
+	"github.com/DelineaXPM/dsv-cli/errors"
+	"github.com/DelineaXPM/dsv-cli/internal/predictor"
+	"github.com/DelineaXPM/dsv-cli/paths"
+	"github.com/DelineaXPM/dsv-cli/vaultcli"
+
+	"github.com/mitchellh/cli"
+	"github.com/spf13/viper"
+	"golang.org/x/term"
+)
+
+const (
+	defaultBulkWorkers = 4
+
+	// defaultRestoreLookback is how far back audit records are searched for deleted secrets.
+	defaultRestoreLookback = 30 * 24 * time.Hour
+	auditDateLayout        = "2006-01-02"
+)
+
+// secretBulkFlags are flags of commands which can operate on many secrets at once.
+func secretBulkFlags() []*predictor.Params {
+	return []*predictor.Params{
+		{Name: cst.Recursive, Usage: fmt.Sprintf("Apply to all %ss under the path", cst.NounSecret), ValueType: "bool"},
+		{Name: cst.Workers, Usage: fmt.Sprintf("Number of %ss processed concurrently [default:%d]", cst.NounSecret, defaultBulkWorkers)},
+		{Name: cst.DryRun, Usage: "Only report what would be done", ValueType: "bool"},
+		{Name: cst.StopOnError, Usage: fmt.Sprintf("Stop at the first failure instead of processing remaining %ss", cst.NounSecret), ValueType: "bool"},
+	}
+}
+
+func GetSecretCopyCmd() (cli.Command, error) {
+	return getSecretCopyCmd(cst.Copy)
+}
+
+func GetSecretMoveCmd() (cli.Command, error) {
+	return getSecretCopyCmd(cst.Move)
+}
+
+func getSecretCopyCmd(action string) (cli.Command, error) {
+	flags := []*predictor.Params{
+		{Name: cst.Path, Shorthand: "r", Usage: fmt.Sprintf("Source %s or glob pattern (required)", cst.Path), Predictor: predictor.NewSecretPathPredictorDefault()},
+		{Name: cst.Dest, Usage: fmt.Sprintf("Destination %s (required)", cst.Path)},
+		{Name: cst.Overwrite, Usage: fmt.Sprintf("Overwrite existing %ss at the destination", cst.NounSecret), ValueType: "bool"},
+	}
+	description := "Copy"
+	if action == cst.Move {
+		description = "Move"
+		flags = append(flags, &predictor.Params{Name: cst.Force, Usage: fmt.Sprintf("Immediately delete source %ss", cst.NounSecret), ValueType: "bool"})
+	}
+	return NewCommand(CommandArgs{
+		Path:         []string{cst.NounSecret, action},
+		SynopsisText: fmt.Sprintf("%s %s (<path> <dest> | --path|-r <path> --dest <dest>) [--recursive] [--overwrite] [--dry-run]", cst.NounSecret, action),
+		HelpText: fmt.Sprintf(`%[1]s a %[2]s or all %[2]ss matching a pattern to another path
+
+With --recursive all %[2]ss under the path are processed and keep their path relative to it.
+The path can be a glob pattern where * matches any part of a single path segment.
+Existing %[2]ss at the destination are skipped unless --overwrite is set.
+A source %[2]s is deleted only after it was written to the destination.
+
+Usage:
+   • %[2]s %[3]s %[4]s staging/db
+   • %[2]s %[3]s --path prod --dest staging --recursive --dry-run
+   • %[2]s %[3]s "prod/*/password" staging --workers 8
+`, description, cst.NounSecret, action, cst.ExamplePath),
+		FlagsPredictor: append(flags, secretBulkFlags()...),
+		ArgsPredictor:  predictor.NewSecretPathPredictorDefault(),
+		MinNumberArgs:  2,
+		RunFuncE: func(vcli vaultcli.CLI, args []string) error {
+			return handleSecretCopyCmd(vcli, action, args)
+		},
+	})
+}
+
+func handleSecretCopyCmd(vcli vaultcli.CLI, action string, args []string) error {
+	src := viper.GetString(cst.Path)
+	dest := viper.GetString(cst.Dest)
+	var positional []string
+	for _, a := range args {
+		if !strings.HasPrefix(a, "-") {
+			positional = append(positional, a)
+		}
+	}
+	if src == "" && len(positional) > 0 {
+		src, positional = positional[0], positional[1:]
+	}
+	if dest == "" && len(positional) > 0 {
+		dest = positional[0]
+	}
+	src, dest = secretBundlePath(src), secretBundlePath(dest)
+	if src == "" {
+		return errors.NewF("error: must specify --%s", cst.Path)
+	}
+	if dest == "" {
+		return errors.NewF("error: must specify --%s", cst.Dest)
+	}
+	if err := vaultcli.ValidatePath(dest); err != nil {
+		return errors.NewF("Destination %q is invalid: %v", dest, err)
+	}
+	overwrite := viper.GetBool(cst.Overwrite)
+	force := viper.GetBool(cst.Force)
+
+	items, apiErr := findBulkSecrets(vcli, cst.NounSecret, src)
+	if apiErr != nil {
+		return apiErr
+	}
+	from := secretGlobBase(src)
+
+	return runSecretBulkCmd(vcli, action, items, func(item *secretSearchItem, dryRun bool) *secretBulkResult {
+		res := &secretBulkResult{Path: item.Path}
+		if apiErr := fillSecretSearchItem(vcli, item); apiErr != nil {
+			res.Error = apiErr.Error()
+			return res
+		}
+		imported := importSecret(vcli, item, from, dest, overwrite, dryRun)
+		res.Dest, res.Action, res.Error = imported.Path, imported.Action, imported.Error
+		if action != cst.Move || res.Error != "" || res.Action == "skip" || dryRun {
+			return res
+		}
+		if apiErr := deleteSecret(vcli, cst.NounSecret, item.Path, force); apiErr != nil {
+			res.Error = fmt.Sprintf("written to the destination, but failed to delete the source: %v", apiErr)
+		}
+		return res
+	})
+}
+
+// handleSecretBulkDeleteCmd deletes all secrets matching the path.
+func handleSecretBulkDeleteCmd(vcli vaultcli.CLI, secretType string, path string) error {
+	items, apiErr := findBulkSecrets(vcli, secretType, secretBundlePath(path))
+	if apiErr != nil {
+		return apiErr
+	}
+	force := viper.GetBool(cst.Force)
+	return runSecretBulkCmd(vcli, cst.Delete, items, func(item *secretSearchItem, dryRun bool) *secretBulkResult {
+		res := &secretBulkResult{Path: item.Path, Action: cst.Delete}
+		if dryRun {
+			return res
+		}
+		if apiErr := deleteSecret(vcli, secretType, item.Path, force); apiErr != nil {
+			res.Error = apiErr.Error()
+		}
+		return res
+	})
+}
+
+// handleSecretBulkRestoreCmd restores all deleted secrets matching the path.
+func handleSecretBulkRestoreCmd(vcli vaultcli.CLI, path string) error {
+	since := time.Now().Add(-defaultRestoreLookback)
+	if s := viper.GetString(cst.StartDate); s != "" {
+		t, err := time.Parse(auditDateLayout, s)
+		if err != nil {
+			return errors.NewF("error: --%s must be a date, e.g. 2026-10-01", cst.StartDate)
+		}
+		since = t
+	}
+	pattern := secretBundlePath(path)
+	deleted, apiErr := findDeletedSecrets(vcli, since)
+	if apiErr != nil {
+		return apiErr
+	}
+	live, apiErr := secretSearchAll(vcli, cst.NounSecret, secretGlobBase(pattern))
+	if apiErr != nil {
+		return apiErr
+	}
+	exists := make(map[string]bool, len(live))
+	for _, item := range live {
+		exists[secretBundlePath(item.Path)] = true
+	}
+
+	recursive := viper.GetBool(cst.Recursive)
+	var items []*secretSearchItem
+	for _, p := range deleted {
+		if !exists[p] && matchSecretPath(pattern, p, recursive) {
+			items = append(items, &secretSearchItem{Path: p})
+		}
+	}
+	return runSecretBulkCmd(vcli, cst.Restore, items, func(item *secretSearchItem, dryRun bool) *secretBulkResult {
+		res := &secretBulkResult{Path: item.Path, Action: cst.Restore}
+		if dryRun {
+			return res
+		}
+		if apiErr := restoreSecret(vcli, item.Path); apiErr != nil {
+			res.Error = apiErr.Error()
+		}
+		return res
+	})
+}
+
+// findDeletedSecrets returns sorted paths of secrets deleted since the date according to audit records.
+// Some of them can be restored or created again since then.
+func findDeletedSecrets(vcli vaultcli.CLI, since time.Time) ([]string, *errors.ApiError) {
+	queryParams := map[string]string{
+		"startDate": since.Format(auditDateLayout),
+		// The end date is exclusive.
+		"endDate": time.Now().AddDate(0, 0, 1).Format(auditDateLayout),
+		"action":  http.MethodDelete,
+		cst.Path:  strings.TrimSuffix(cst.PrefixEntity, "/"),
+	}
+	records, apiErr := readPages(func(cursor string) ([]byte, *errors.ApiError) {
+		delete(queryParams, cst.Cursor)
+		if cursor != "" {
+			queryParams[cst.Cursor] = cursor
+		}
+		return vcli.HTTPClient().DoRequest(http.MethodGet, paths.CreateURI(cst.NounAudit, queryParams), nil)
+	})
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	found := make(map[string]bool)
+	for _, raw := range records {
+		var record struct {
+			Action string `json:"action"`
+			Path   string `json:"path"`
+			Status string `json:"status"`
+		}
+		if err := json.Unmarshal(raw, &record); err != nil {
+			return nil, errors.New(err).Grow("Failed to parse audit records")
+		}
+		if !strings.EqualFold(record.Action, http.MethodDelete) || record.Status != "" && !strings.EqualFold(record.Status, "success") {
+			continue
+		}
+		p := secretBundlePath(record.Path)
+		if !strings.HasPrefix(p, cst.PrefixEntity) || strings.Contains(p, "//") {
+			// Not a secret or not the secret itself, e.g. its description.
+			continue
+		}
+		found[strings.TrimPrefix(p, cst.PrefixEntity)] = true
+	}
+	deleted := make([]string, 0, len(found))
+	for p := range found {
+		deleted = append(deleted, p)
+	}
+	sort.Strings(deleted)
+	return deleted, nil
+}
+
+func restoreSecret(vcli vaultcli.CLI, path string) *errors.ApiError {
+	rc, rerr := getResourceConfig(path, cst.NounSecret)
+	if rerr != nil {
+		return errors.New(rerr)
+	}
+	uri := paths.CreateResourceURI(rc.resourceType, rc.path, "/restore", true, nil)
+	_, apiErr := vcli.HTTPClient().DoRequest(http.MethodPut, uri, nil)
+	return apiErr
+}
+
+// isSecretBulkPath reports whether a command should operate on all secrets matching the path.
+func isSecretBulkPath(path string) bool {
+	return viper.GetBool(cst.Recursive) || isSecretGlob(path)
+}
+
+func deleteSecret(vcli vaultcli.CLI, secretType string, path string, force bool) *errors.ApiError {
+	rc, rerr := getResourceConfig(path, secretType)
+	if rerr != nil {
+		return errors.New(rerr)
+	}
+	query := map[string]string{"force": strconv.FormatBool(force)}
+	uri, apiErr := paths.GetResourceURIFromResourcePath(rc.resourceType, rc.path, "", "", query)
+	if apiErr != nil {
+		return apiErr
+	}
+	_, apiErr = vcli.HTTPClient().DoRequest(http.MethodDelete, uri, nil)
+	return apiErr
+}
+
+type secretBulkResult struct {
+	Path   string `json:"path"`
+	Dest   string `json:"dest,omitempty"`
+	Action string `json:"action"`
+	Error  string `json:"error,omitempty"`
+}
+
+// runSecretBulkCmd applies op to all items, writes a report and returns an error if any item failed.
+func runSecretBulkCmd(
+	vcli vaultcli.CLI, action string, items []*secretSearchItem,
+	op func(item *secretSearchItem, dryRun bool) *secretBulkResult,
+) error {
+	workers := defaultBulkWorkers
+	if s := viper.GetString(cst.Workers); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 1 {
+			return errors.NewF("error: --%s must be a positive number", cst.Workers)
+		}
+		workers = n
+	}
+	dryRun := viper.GetBool(cst.DryRun)
+	stopOnError := viper.GetBool(cst.StopOnError)
+
+	progress, stopProgress := newBulkProgress(strings.Title(action), len(items))
+	results, stopped := runSecretBulk(items, workers, stopOnError, func(item *secretSearchItem) *secretBulkResult {
+		return op(item, dryRun)
+	}, progress)
+	stopProgress()
+
+	failed := 0
+	for _, res := range results {
+		if res.Error != "" {
+			failed++
+		}
+	}
+	report, err := json.Marshal(map[string]interface{}{
+		"dryRun":    dryRun,
+		"stopped":   stopped,
+		"succeeded": len(results) - failed,
+		"failed":    failed,
+		"results":   results,
+	})
+	if err != nil {
+		return err
+	}
+	vcli.Out().WriteResponse(report, nil)
+
+	if failed > 0 {
+		return errors.NewF("%d of %d %s(s) failed to %s", failed, len(items), cst.NounSecret, action)
+	}
+	return nil
+}
+
+// runSecretBulk calls op for every item using a pool of workers and returns results in the order of items.
+// With stopOnError, items which are not started yet when an operation fails are not processed
+// and the second return value is true.
+func runSecretBulk(
+	items []*secretSearchItem, workers int, stopOnError bool,
+	op func(*secretSearchItem) *secretBulkResult, progress func(),
+) ([]*secretBulkResult, bool) {
+	results := make([]*secretBulkResult, len(items))
+	var stop atomic.Bool
+
+	jobs := make(chan int)
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				if stop.Load() {
+					// Drain jobs sent before the failure was noticed.
+					continue
+				}
+				res := op(items[i])
+				if res.Error != "" && stopOnError {
+					stop.Store(true)
+				}
+				results[i] = res
+				done <- struct{}{}
+			}
+		}()
+	}
+	go func() {
+		defer close(jobs)
+		for i := range items {
+			if stop.Load() {
+				return
+			}
+			jobs <- i
+		}
+	}()
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	for range done {
+		progress()
+	}
+
+	processed := make([]*secretBulkResult, 0, len(results))
+	for _, res := range results {
+		if res != nil {
+			processed = append(processed, res)
+		}
+	}
+	return processed, len(processed) < len(items)
+}
+
+// newBulkProgress returns functions which advance and remove a progress line. The progress line
+// is written to stderr only if it is a terminal, so it never mixes with the report.
+func newBulkProgress(title string, total int) (func(), func()) {
+	noop := func() {}
+	if total < 2 || !term.IsTerminal(int(os.Stderr.Fd())) {
+		return noop, noop
+	}
+	const width = 30
+	current := 0
+	render := func() {
+		filled := width * current / total
+		fmt.Fprintf(os.Stderr, "\r%s [%s%s] %d/%d", title, strings.Repeat("=", filled), strings.Repeat(" ", width-filled), current, total)
+	}
+	step := func() {
+		current++
+		render()
+	}
+	remove := func() {
+		fmt.Fprintf(os.Stderr, "\r%s\r", strings.Repeat(" ", len(title)+width+2*len(strconv.Itoa(total))+5))
+	}
+	render()
+	return step, remove
+}
+
+// findBulkSecrets returns secrets matching the pattern. Without --recursive and wildcards the pattern is a path of a single secret.
+func findBulkSecrets(vcli vaultcli.CLI, secretType string, pattern string) ([]*secretSearchItem, *errors.ApiError) {
+	recursive := viper.GetBool(cst.Recursive)
+	if !recursive && !isSecretGlob(pattern) {
+		return []*secretSearchItem{{Path: pattern}}, nil
+	}
+
+	found, apiErr := secretSearchAll(vcli, secretType, secretGlobBase(pattern))
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	var items []*secretSearchItem
+	for _, item := range found {
+		item.Path = secretBundlePath(item.Path)
+		if matchSecretPath(pattern, item.Path, recursive) {
+			items = append(items, item)
+		}
+	}
+	return items, nil
+}
+
+func isSecretGlob(pattern string) bool {
+	return strings.ContainsAny(pattern, "*?[")
+}
+
+// secretGlobBase returns the segments of the pattern before the first one with wildcards.
+func secretGlobBase(pattern string) string {
+	i := strings.IndexAny(pattern, "*?[")
+	if i < 0 {
+		return pattern
+	}
+	base := pattern[:i]
+	if j := strings.LastIndex(base, "/"); j >= 0 {
+		return base[:j]
+	}
+	return ""
+}
+
+// matchSecretPath reports whether the secret path matches the pattern. The pattern matches
+// whole segments. With recursive, paths under a matching path match as well.
+func matchSecretPath(pattern string, p string, recursive bool) bool {
+	if !isSecretGlob(pattern) {
+		return p == pattern || recursive && secretBundleHasPrefix(p, pattern)
+	}
+	segments := strings.Split(p, "/")
+	n := strings.Count(pattern, "/") + 1
+	if len(segments) < n || len(segments) > n && !recursive {
+		return false
+	}
+	ok, _ := path.Match(pattern, strings.Join(segments[:n], "/"))
+	return ok
+}
@@ -0,0 +1,279 @@
+package cmd
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	cst "github.com/DelineaXPM/dsv-cli/constants"
+	"github.com/DelineaXPM/dsv-cli/errors"
+	"github.com/DelineaXPM/dsv-cli/tests/fake"
+	"github.com/DelineaXPM/dsv-cli/vaultcli"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetSecretCopyCmd(t *testing.T) {
+	_, err := GetSecretCopyCmd()
+	assert.Nil(t, err)
+	_, err = GetSecretMoveCmd()
+	assert.Nil(t, err)
+}
+
+func TestMatchSecretPath(t *testing.T) {
+	testCases := []struct {
+		pattern   string
+		path      string
+		recursive bool
+		want      bool
+	}{
+		{"prod", "prod", false, true},
+		{"prod", "prod/db", false, false},
+		{"prod", "prod/db", true, true},
+		{"prod", "production/db", true, false},
+		{"prod/*", "prod/db", false, true},
+		{"prod/*", "prod/db/password", false, false},
+		{"prod/*", "prod/db/password", true, true},
+		{"prod/*/password", "prod/db/password", false, true},
+		{"prod/*/password", "prod/db/user", false, false},
+		{"prod/db-?", "prod/db-1", false, true},
+		{"*/db", "staging/db", false, true},
+		{"prod/*", "prod", true, false},
+	}
+	for _, tt := range testCases {
+		assert.Equal(t, tt.want, matchSecretPath(tt.pattern, tt.path, tt.recursive), "%s %s recursive=%v", tt.pattern, tt.path, tt.recursive)
+	}
+
+	assert.Equal(t, "prod", secretGlobBase("prod"))
+	assert.Equal(t, "prod", secretGlobBase("prod/*/password"))
+	assert.Equal(t, "prod", secretGlobBase("prod/db-*"))
+	assert.Equal(t, "", secretGlobBase("*/db"))
+}
+
+func TestRunSecretBulk(t *testing.T) {
+	var items []*secretSearchItem
+	for _, p := range []string{"a", "b", "c", "d", "e", "f"} {
+		items = append(items, &secretSearchItem{Path: p})
+	}
+
+	var mu sync.Mutex
+	var processed []string
+	op := func(item *secretSearchItem) *secretBulkResult {
+		mu.Lock()
+		processed = append(processed, item.Path)
+		mu.Unlock()
+		res := &secretBulkResult{Path: item.Path, Action: cst.Delete}
+		if item.Path == "b" {
+			res.Error = "failed"
+		}
+		return res
+	}
+
+	steps := 0
+	results, stopped := runSecretBulk(items, 3, false, op, func() { steps++ })
+	assert.False(t, stopped)
+	assert.Equal(t, 6, steps)
+	var paths []string
+	for _, res := range results {
+		paths = append(paths, res.Path)
+	}
+	assert.Equal(t, []string{"a", "b", "c", "d", "e", "f"}, paths, "results must keep the order of items")
+
+	processed = nil
+	results, stopped = runSecretBulk(items, 1, true, op, func() {})
+	assert.True(t, stopped)
+	assert.Len(t, results, len(processed))
+	assert.Less(t, len(processed), len(items))
+	assert.Equal(t, "b", results[len(results)-1].Path)
+}
+
+// bulkFakeServer serves search results for "prod" and records requests other than reads.
+type bulkFakeServer struct {
+	mu       sync.Mutex
+	requests []string
+	fail     string
+}
+
+func (s *bulkFakeServer) do(method string, uri string, body interface{}) ([]byte, *errors.ApiError) {
+	u, _ := url.Parse(uri)
+	p := strings.TrimPrefix(u.Path, "/v1/secrets")
+	switch {
+	case method == http.MethodGet && u.Path == "/v1/audit":
+		q := u.Query()
+		if q.Get("action") != http.MethodDelete || q.Get("path") != "secrets" || q.Get("startDate") == "" {
+			return nil, errors.NewF("unexpected audit query %s", u.RawQuery)
+		}
+		if q.Get("cursor") == "" {
+			return []byte(`{"data":[
+				{"path":"secrets:prod:db:old","action":"DELETE","status":"success"},
+				{"path":"secrets:prod:db:user","action":"DELETE","status":"success"},
+				{"path":"secrets:prod:db:failed","action":"DELETE","status":"failed"},
+				{"path":"secrets:prod:db:read","action":"GET","status":"success"}
+			],"cursor":"next"}`), nil
+		}
+		return []byte(`{"data":[
+			{"path":"secrets:prod:db:old","action":"DELETE","status":"success"},
+			{"path":"secrets:prod:db:other::description","action":"DELETE","status":"success"},
+			{"path":"secrets:prod:db:token","action":"DELETE","status":"success"},
+			{"path":"secrets:staging:db","action":"DELETE","status":"success"},
+			{"path":"roles:prod","action":"DELETE","status":"success"}
+		],"cursor":"next"}`), nil
+	case method == http.MethodGet && p == "":
+		return []byte(`{"data":[
+			{"path":"prod:db:password","data":{"password":"p@ss"}},
+			{"path":"prod:db:user","data":{"user":"admin"}},
+			{"path":"prod:api","data":{"token":"abc"}},
+			{"path":"production:db","data":{"password":"other"}}
+		]}`), nil
+	case method == http.MethodGet && strings.HasPrefix(p, "/staging/db/user"):
+		return []byte(`{"path":"staging:db:user"}`), nil
+	case method == http.MethodGet:
+		return nil, errors.NewS("not found").WithResponse(&http.Response{StatusCode: http.StatusNotFound, Status: "404 Not Found"})
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.requests = append(s.requests, method+" "+p)
+	if s.fail != "" && strings.HasPrefix(p, s.fail) {
+		return nil, errors.NewS("forbidden")
+	}
+	return []byte(`{}`), nil
+}
+
+func (s *bulkFakeServer) sortedRequests() []string {
+	sort.Strings(s.requests)
+	return s.requests
+}
+
+func TestHandleSecretBulkDeleteCmd(t *testing.T) {
+	server := &bulkFakeServer{}
+	var out []byte
+	outClient := &fake.FakeOutClient{}
+	outClient.WriteResponseStub = func(data []byte, apiError *errors.ApiError) { out = data }
+	vcli, err := vaultcli.NewWithOpts(vaultcli.WithHTTPClient(&fake.FakeClient{DoRequestStub: server.do}), vaultcli.WithOutClient(outClient))
+	if err != nil {
+		t.Fatalf("Unexpected error during vaultCLI init: %v", err)
+	}
+
+	testCases := []struct {
+		name     string
+		path     string
+		flags    map[string]interface{}
+		fail     string
+		requests []string
+		exitCode int
+		report   map[string]interface{}
+	}{
+		{
+			name:     "recursive",
+			path:     "prod",
+			flags:    map[string]interface{}{cst.Recursive: true},
+			requests: []string{"DELETE /prod/api", "DELETE /prod/db/password", "DELETE /prod/db/user"},
+			report:   map[string]interface{}{"succeeded": float64(3), "failed": float64(0)},
+		},
+		{
+			name:     "glob",
+			path:     "prod/*/password",
+			requests: []string{"DELETE /prod/db/password"},
+			report:   map[string]interface{}{"succeeded": float64(1)},
+		},
+		{
+			name:   "dry run",
+			path:   "prod/db/*",
+			flags:  map[string]interface{}{cst.DryRun: true},
+			report: map[string]interface{}{"dryRun": true, "succeeded": float64(2)},
+		},
+		{
+			name:     "continue on error",
+			path:     "prod",
+			flags:    map[string]interface{}{cst.Recursive: true},
+			fail:     "/prod/db",
+			requests: []string{"DELETE /prod/api", "DELETE /prod/db/password", "DELETE /prod/db/user"},
+			exitCode: 1,
+			report:   map[string]interface{}{"succeeded": float64(1), "failed": float64(2), "stopped": false},
+		},
+		{
+			name:     "stop on error",
+			path:     "prod",
+			flags:    map[string]interface{}{cst.Recursive: true, cst.StopOnError: true, cst.Workers: "1"},
+			fail:     "/prod/db/password",
+			requests: []string{"DELETE /prod/db/password"},
+			exitCode: 1,
+			report:   map[string]interface{}{"failed": float64(1), "stopped": true},
+		},
+	}
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			viper.Reset()
+			defer viper.Reset()
+			viper.Set(cst.Tenant, "tenant")
+			for k, v := range tt.flags {
+				viper.Set(k, v)
+			}
+			server.requests, server.fail = nil, tt.fail
+
+			code := handleSecretDeleteCmd(vcli, cst.NounSecret, []string{tt.path})
+			assert.Equal(t, tt.exitCode, code)
+			assert.Equal(t, tt.requests, server.sortedRequests())
+
+			report := map[string]interface{}{}
+			assert.NoError(t, json.Unmarshal(out, &report))
+			for k, v := range tt.report {
+				assert.Equal(t, v, report[k], k)
+			}
+		})
+	}
+
+	// Deleted secrets are found in audit records, secrets which exist again are skipped.
+	viper.Reset()
+	viper.Set(cst.Tenant, "tenant")
+	server.requests, server.fail = nil, ""
+	code := handleSecretRestoreCmd(vcli, cst.NounSecret, []string{"prod/db/*"})
+	assert.Equal(t, 0, code)
+	assert.Equal(t, []string{"PUT /prod/db/old/restore", "PUT /prod/db/token/restore"}, server.sortedRequests())
+
+	viper.Set(cst.StartDate, "yesterday")
+	code = handleSecretRestoreCmd(vcli, cst.NounSecret, []string{"prod/db/*"})
+	assert.Equal(t, 1, code)
+}
+
+func TestHandleSecretCopyCmd(t *testing.T) {
+	server := &bulkFakeServer{}
+	var out []byte
+	outClient := &fake.FakeOutClient{}
+	outClient.WriteResponseStub = func(data []byte, apiError *errors.ApiError) { out = data }
+	vcli, err := vaultcli.NewWithOpts(vaultcli.WithHTTPClient(&fake.FakeClient{DoRequestStub: server.do}), vaultcli.WithOutClient(outClient))
+	if err != nil {
+		t.Fatalf("Unexpected error during vaultCLI init: %v", err)
+	}
+
+	viper.Reset()
+	defer viper.Reset()
+	viper.Set(cst.Tenant, "tenant")
+	viper.Set(cst.Recursive, true)
+
+	err = handleSecretCopyCmd(vcli, cst.Copy, []string{"prod", "staging"})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"POST /staging/api", "POST /staging/db/password"}, server.sortedRequests())
+	assert.Contains(t, string(out), `{"path":"prod/db/user","dest":"staging/db/user","action":"skip"}`)
+
+	// Sources are deleted only after they are written. The skipped one is kept.
+	server.requests = nil
+	err = handleSecretCopyCmd(vcli, cst.Move, []string{"prod/db", "staging/db"})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"DELETE /prod/db/password", "POST /staging/db/password"}, server.sortedRequests())
+
+	server.requests, server.fail = nil, "/staging"
+	err = handleSecretCopyCmd(vcli, cst.Move, []string{"prod/db", "staging/db"})
+	assert.ErrorContains(t, err, "1 of 2 secret(s) failed to move")
+	assert.Equal(t, []string{"POST /staging/db/password"}, server.sortedRequests())
+
+	viper.Reset()
+	err = handleSecretCopyCmd(vcli, cst.Copy, []string{"prod/db"})
+	assert.ErrorContains(t, err, "must specify --dest")
+}
@@ -0,0 +1,416 @@
+package cmd
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/DelineaXPM/dsv-cli/auth"
+	cst "github.com/DelineaXPM/dsv-cli/constants"
+	"github.com/DelineaXPM/dsv-cli/errors"
+	"github.com/DelineaXPM/dsv-cli/format"
+	"github.com/DelineaXPM/dsv-cli/internal/predictor"
+	"github.com/DelineaXPM/dsv-cli/paths"
+	"github.com/DelineaXPM/dsv-cli/utils"
+	"github.com/DelineaXPM/dsv-cli/vaultcli"
+
+	"github.com/AlecAivazis/survey/v2"
+	"github.com/mitchellh/cli"
+	"github.com/spf13/viper"
+	"golang.org/x/crypto/scrypt"
+	"gopkg.in/yaml.v3"
+)
+
+const secretBundleVersion = 1
+
+func GetSecretExportCmd() (cli.Command, error) {
+	return NewCommand(CommandArgs{
+		Path:         []string{cst.NounSecret, cst.Export},
+		SynopsisText: fmt.Sprintf("%s (<path> | --path|-r) [--out <file>] [--encrypt] [--passphrase]", cst.Export),
+		HelpText: fmt.Sprintf(`Export all %[1]ss under a path to a bundle
+
+Data, description and attributes of every %[1]s under the path are written to a JSON bundle.
+The bundle file is written with 0600 permissions. Without --out the bundle is written to stdout.
+With --encrypt or --passphrase the %[1]ss in the bundle are encrypted with AES-256-GCM using a key
+derived from the passphrase. If --encrypt is set without --passphrase, the passphrase is prompted for.
+
+Usage:
+   • secret %[2]s --path prod/ --out bundle.json
+   • secret %[2]s prod --out file:bundle.json --encrypt
+`, cst.NounSecret, cst.Export),
+		FlagsPredictor: []*predictor.Params{
+			{Name: cst.Path, Shorthand: "r", Usage: fmt.Sprintf("Path to the %ss to export (required)", cst.NounSecret), Predictor: predictor.NewSecretPathPredictorDefault()},
+			{Name: cst.Encrypt, Usage: "Encrypt the bundle with a passphrase", ValueType: "bool"},
+			{Name: cst.Passphrase, Usage: "Passphrase to encrypt the bundle with (implies --encrypt)"},
+		},
+		MinNumberArgs: 1,
+		RunFuncE:      handleSecretExportCmd,
+	})
+}
+
+func GetSecretImportCmd() (cli.Command, error) {
+	return NewCommand(CommandArgs{
+		Path:         []string{cst.NounSecret, cst.Import},
+		SynopsisText: fmt.Sprintf("%s (<file> | --input <file>) [--overwrite] [--dry-run] [--rewrite-prefix <from>=<to>] [--passphrase]", cst.Import),
+		HelpText: fmt.Sprintf(`Import %[1]ss from a bundle created by "%[1]s %[3]s"
+
+Missing %[1]ss are created. Existing %[1]ss are skipped unless --overwrite is set,
+in which case their data, description and attributes are replaced by the ones from the bundle.
+With --dry-run nothing is written, the report shows what would be done.
+With --rewrite-prefix paths starting with <from> are moved under <to>.
+If the bundle is encrypted and --passphrase is not set, the passphrase is prompted for.
+
+Usage:
+   • secret %[2]s bundle.json
+   • secret %[2]s --input bundle.json --overwrite
+   • secret %[2]s bundle.json --rewrite-prefix prod=staging --dry-run
+`, cst.NounSecret, cst.Import, cst.Export),
+		FlagsPredictor: []*predictor.Params{
+			{Name: cst.Input, Usage: "Path to the bundle file (required)", Predictor: predictor.NewPrefixFilePredictor("*")},
+			{Name: cst.Overwrite, Usage: fmt.Sprintf("Overwrite existing %ss", cst.NounSecret), ValueType: "bool"},
+			{Name: cst.DryRun, Usage: "Only report what would be done", ValueType: "bool"},
+			{Name: cst.RewritePrefix, Usage: "Rewrite path prefix, in the form <from>=<to>"},
+			{Name: cst.Passphrase, Usage: "Passphrase to decrypt the bundle with"},
+		},
+		MinNumberArgs: 1,
+		RunFuncE:      handleSecretImportCmd,
+	})
+}
+
+func handleSecretExportCmd(vcli vaultcli.CLI, args []string) error {
+	prefix := viper.GetString(cst.Path)
+	if prefix == "" && len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		prefix = args[0]
+	}
+	prefix = secretBundlePath(prefix)
+	if prefix == "" {
+		return errors.NewF("error: must specify --%s", cst.Path)
+	}
+
+	dest, err := outputFilePath(viper.GetString(cst.Output))
+	if err != nil {
+		return err
+	}
+	// The bundle is written by this command itself,
+	// the output client is used only for messages.
+	viper.Set(cst.Output, format.OutToStdout)
+
+	passphrase := viper.GetString(cst.Passphrase)
+	if passphrase == "" && viper.GetBool(cst.Encrypt) {
+		passphrase, err = secretBundlePassphrase(true)
+		if err != nil {
+			return err
+		}
+	}
+
+	found, apiErr := secretSearchAll(vcli, cst.NounSecret, prefix)
+	if apiErr != nil {
+		return apiErr
+	}
+
+	bundle := &secretBundle{Version: secretBundleVersion, Path: prefix}
+	for _, item := range found {
+		path := secretBundlePath(item.Path)
+		if !secretBundleHasPrefix(path, prefix) {
+			// Search matches the query anywhere in the path.
+			continue
+		}
+		item.Path = path
+		if apiErr := fillSecretSearchItem(vcli, item); apiErr != nil {
+			return apiErr
+		}
+		bundle.Secrets = append(bundle.Secrets, item)
+	}
+	count := len(bundle.Secrets)
+
+	if passphrase != "" {
+		if err := bundle.encrypt(passphrase); err != nil {
+			return errors.New(err).Grow("Failed to encrypt the bundle")
+		}
+	}
+	data, err := json.MarshalIndent(bundle, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if dest == "" {
+		vcli.Out().WriteResponse(data, nil)
+		return nil
+	}
+	if err := utils.WriteFileAtomic(dest, data, 0o600); err != nil {
+		return errors.New(err).Grow("Failed to write the bundle")
+	}
+	summary, _ := json.Marshal(map[string]interface{}{
+		"path":      prefix,
+		"file":      dest,
+		"exported":  count,
+		"encrypted": passphrase != "",
+	})
+	vcli.Out().WriteResponse(summary, nil)
+	return nil
+}
+
+func handleSecretImportCmd(vcli vaultcli.CLI, args []string) error {
+	input := viper.GetString(cst.Input)
+	if input == "" && len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		input = args[0]
+	}
+	if input == "" {
+		return errors.NewF("error: must specify --%s", cst.Input)
+	}
+	overwrite := viper.GetBool(cst.Overwrite)
+	dryRun := viper.GetBool(cst.DryRun)
+
+	from, to, err := parseRewritePrefix(viper.GetString(cst.RewritePrefix))
+	if err != nil {
+		return err
+	}
+
+	raw, err := os.ReadFile(input)
+	if err != nil {
+		return errors.New(err).Grow("Failed to read the bundle")
+	}
+	bundle, err := parseSecretBundle(raw)
+	if err != nil {
+		return err
+	}
+	if bundle.Ciphertext != "" {
+		passphrase := viper.GetString(cst.Passphrase)
+		if passphrase == "" {
+			passphrase, err = secretBundlePassphrase(false)
+			if err != nil {
+				return err
+			}
+		}
+		if err := bundle.decrypt(passphrase); err != nil {
+			return err
+		}
+	}
+
+	results := make([]*secretImportResult, 0, len(bundle.Secrets))
+	failed := 0
+	for _, item := range bundle.Secrets {
+		res := importSecret(vcli, item, from, to, overwrite, dryRun)
+		if res.Error != "" {
+			failed++
+		}
+		results = append(results, res)
+	}
+
+	report, err := json.Marshal(map[string]interface{}{
+		"dryRun":  dryRun,
+		"results": results,
+	})
+	if err != nil {
+		return err
+	}
+	vcli.Out().WriteResponse(report, nil)
+
+	if failed > 0 {
+		return errors.NewF("%d of %d %s(s) failed to import", failed, len(results), cst.NounSecret)
+	}
+	return nil
+}
+
+type secretImportResult struct {
+	Path   string `json:"path"`
+	Action string `json:"action"`
+	Error  string `json:"error,omitempty"`
+}
+
+func importSecret(vcli vaultcli.CLI, item *secretSearchItem, from, to string, overwrite, dryRun bool) *secretImportResult {
+	path := rewritePathPrefix(secretBundlePath(item.Path), from, to)
+	res := &secretImportResult{Path: path}
+	if path == "" {
+		res.Error = fmt.Sprintf("path %q is empty after rewrite", item.Path)
+		return res
+	}
+	if err := vaultcli.ValidatePath(path); err != nil {
+		res.Error = err.Error()
+		return res
+	}
+
+	_, apiErr := getSecretFromServer(vcli, cst.NounSecret, path, "", false, cst.SuffixDescription)
+	switch {
+	case apiErr == nil && !overwrite:
+		res.Action = "skip"
+		return res
+	case apiErr == nil:
+		res.Action = cst.Update
+	case apiErr.HttpResponse() != nil && apiErr.HttpResponse().StatusCode == http.StatusNotFound:
+		res.Action = cst.Create
+	default:
+		res.Error = apiErr.Error()
+		return res
+	}
+	if dryRun {
+		return res
+	}
+
+	uri, apiErr := paths.GetResourceURIFromResourcePath(cst.NounSecrets, path, "", "", nil)
+	if apiErr != nil {
+		res.Error = apiErr.Error()
+		return res
+	}
+	body := &secretUpsertBody{
+		Data:        item.Data,
+		Description: item.Description,
+		Attributes:  item.Attributes,
+		Overwrite:   true,
+	}
+	if _, apiErr := secretUpsert(vcli, res.Action, uri, body); apiErr != nil {
+		res.Error = apiErr.Error()
+	}
+	return res
+}
+
+// fillSecretSearchItem reads the secret itself if search results do not contain its data.
+func fillSecretSearchItem(vcli vaultcli.CLI, item *secretSearchItem) *errors.ApiError {
+	if item.Data != nil {
+		return nil
+	}
+	resp, apiErr := getSecretFromServer(vcli, cst.NounSecret, item.Path, "", false, "")
+	if apiErr != nil {
+		return apiErr.Grow(fmt.Sprintf("Failed to read %s %q", cst.NounSecret, item.Path))
+	}
+	secret := &secretGetResponse{}
+	if err := json.Unmarshal(resp, secret); err != nil {
+		return errors.New(err).Grow(fmt.Sprintf("Failed to parse %s %q", cst.NounSecret, item.Path))
+	}
+	item.Description, item.Attributes, item.Data = secret.Description, secret.Attributes, secret.Data
+	return nil
+}
+
+// secretBundle is the format of files written by "secret export". If the bundle is encrypted,
+// the list of secrets is stored as a cipher text and the salt is used to derive the key from a passphrase.
+type secretBundle struct {
+	Version    int                 `json:"version"`
+	Path       string              `json:"path"`
+	Secrets    []*secretSearchItem `json:"secrets,omitempty"`
+	Salt       string              `json:"salt,omitempty"`
+	Ciphertext string              `json:"ciphertext,omitempty"`
+}
+
+func parseSecretBundle(raw []byte) (*secretBundle, error) {
+	bundle := &secretBundle{}
+	if err := json.Unmarshal(raw, bundle); err != nil {
+		// Bundle could have been written to stdout with "--encoding yaml".
+		m := map[string]interface{}{}
+		if yamlErr := yaml.Unmarshal(raw, &m); yamlErr != nil {
+			return nil, errors.New(err).Grow("Failed to parse the bundle")
+		}
+		b, _ := json.Marshal(m)
+		if err := json.Unmarshal(b, bundle); err != nil {
+			return nil, errors.New(err).Grow("Failed to parse the bundle")
+		}
+	}
+	if bundle.Version != secretBundleVersion {
+		return nil, errors.NewF("error: unsupported bundle version %d", bundle.Version)
+	}
+	return bundle, nil
+}
+
+func (b *secretBundle) encrypt(passphrase string) error {
+	plaintext, err := json.Marshal(b.Secrets)
+	if err != nil {
+		return err
+	}
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return err
+	}
+	key, err := secretBundleKey(passphrase, salt)
+	if err != nil {
+		return err
+	}
+	ciphertext, _, err := auth.Encrypt(string(key), string(plaintext))
+	if err != nil {
+		return err
+	}
+	b.Salt = base64.StdEncoding.EncodeToString(salt)
+	b.Ciphertext = ciphertext
+	b.Secrets = nil
+	return nil
+}
+
+func (b *secretBundle) decrypt(passphrase string) error {
+	salt, err := base64.StdEncoding.DecodeString(b.Salt)
+	if err != nil {
+		return errors.New(err).Grow("Failed to decode the bundle salt")
+	}
+	key, err := secretBundleKey(passphrase, salt)
+	if err != nil {
+		return err
+	}
+	plaintext, err := auth.Decrypt(b.Ciphertext, string(key))
+	if err != nil {
+		return errors.NewS("error: failed to decrypt the bundle, the passphrase is wrong or the bundle is corrupted")
+	}
+	if err := json.Unmarshal([]byte(plaintext), &b.Secrets); err != nil {
+		return errors.New(err).Grow("Failed to parse the decrypted bundle")
+	}
+	b.Salt, b.Ciphertext = "", ""
+	return nil
+}
+
+// secretBundleKey derives an AES-256 key from the passphrase.
+func secretBundleKey(passphrase string, salt []byte) ([]byte, error) {
+	return scrypt.Key([]byte(passphrase), salt, 1<<15, 8, 1, 32)
+}
+
+func secretBundlePassphrase(confirm bool) (string, error) {
+	var passphrase string
+	prompt := &survey.Password{Message: "Please enter bundle passphrase:"}
+	if err := survey.AskOne(prompt, &passphrase, survey.WithValidator(vaultcli.SurveyRequired)); err != nil {
+		return "", err
+	}
+	if confirm {
+		var again string
+		prompt = &survey.Password{Message: "Please enter bundle passphrase (confirm):"}
+		if err := survey.AskOne(prompt, &again); err != nil {
+			return "", err
+		}
+		if again != passphrase {
+			return "", errors.NewS("error: passphrases do not match")
+		}
+	}
+	return passphrase, nil
+}
+
+// secretBundlePath normalizes a path so that paths in search results and user input can be compared.
+func secretBundlePath(path string) string {
+	return strings.Trim(strings.ReplaceAll(path, ":", "/"), "/")
+}
+
+func secretBundleHasPrefix(path, prefix string) bool {
+	return path == prefix || strings.HasPrefix(path, prefix+"/")
+}
+
+func parseRewritePrefix(rewrite string) (string, string, error) {
+	if rewrite == "" {
+		return "", "", nil
+	}
+	parts := strings.SplitN(rewrite, "=", 2)
+	if len(parts) != 2 || secretBundlePath(parts[0]) == "" {
+		return "", "", errors.NewS("error: --rewrite-prefix must be in the form <from>=<to>")
+	}
+	return secretBundlePath(parts[0]), secretBundlePath(parts[1]), nil
+}
+
+func rewritePathPrefix(path, from, to string) string {
+	if from == "" || !secretBundleHasPrefix(path, from) {
+		return path
+	}
+	rest := strings.TrimPrefix(strings.TrimPrefix(path, from), "/")
+	if to == "" {
+		return rest
+	}
+	if rest == "" {
+		return to
+	}
+	return to + "/" + rest
+}
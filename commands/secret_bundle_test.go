@@ -0,0 +1,219 @@
+package cmd
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	cst "github.com/DelineaXPM/dsv-cli/constants"
+	"github.com/DelineaXPM/dsv-cli/errors"
+	"github.com/DelineaXPM/dsv-cli/tests/fake"
+	"github.com/DelineaXPM/dsv-cli/vaultcli"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetSecretExportCmd(t *testing.T) {
+	_, err := GetSecretExportCmd()
+	assert.Nil(t, err)
+}
+
+func TestGetSecretImportCmd(t *testing.T) {
+	_, err := GetSecretImportCmd()
+	assert.Nil(t, err)
+}
+
+func TestRewritePathPrefix(t *testing.T) {
+	testCases := []struct {
+		name    string
+		rewrite string
+		path    string
+		want    string
+	}{
+		{"no rewrite", "", "prod/db", "prod/db"},
+		{"nested", "prod=staging", "prod/db/main", "staging/db/main"},
+		{"exact", "prod/=staging/", "prod", "staging"},
+		{"colons", "prod:db=staging:db", "prod/db/main", "staging/db/main"},
+		{"strip", "prod=", "prod/db", "db"},
+		{"other prefix", "prod=staging", "production/db", "production/db"},
+	}
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			from, to, err := parseRewritePrefix(tt.rewrite)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, rewritePathPrefix(tt.path, from, to))
+		})
+	}
+
+	_, _, err := parseRewritePrefix("prod")
+	assert.Error(t, err)
+	_, _, err = parseRewritePrefix("=staging")
+	assert.Error(t, err)
+}
+
+func TestSecretBundleEncryption(t *testing.T) {
+	bundle := &secretBundle{
+		Version: secretBundleVersion,
+		Path:    "prod",
+		Secrets: []*secretSearchItem{{Path: "prod/db", Data: map[string]interface{}{"password": "p@ss"}}},
+	}
+	assert.NoError(t, bundle.encrypt("correct horse"))
+	assert.Nil(t, bundle.Secrets)
+	assert.NotEmpty(t, bundle.Salt)
+	assert.NotContains(t, bundle.Ciphertext, "p@ss")
+
+	raw, err := json.Marshal(bundle)
+	assert.NoError(t, err)
+
+	wrong, err := parseSecretBundle(raw)
+	assert.NoError(t, err)
+	assert.Error(t, wrong.decrypt("wrong"))
+
+	parsed, err := parseSecretBundle(raw)
+	assert.NoError(t, err)
+	assert.NoError(t, parsed.decrypt("correct horse"))
+	assert.Len(t, parsed.Secrets, 1)
+	assert.Equal(t, "p@ss", parsed.Secrets[0].Data["password"])
+
+	_, err = parseSecretBundle([]byte("version: 1\npath: prod\nsecrets:\n  - path: prod/db\n"))
+	assert.NoError(t, err)
+
+	_, err = parseSecretBundle([]byte(`{"version":2}`))
+	assert.Error(t, err)
+}
+
+func TestHandleSecretExportCmd(t *testing.T) {
+	httpClient := &fake.FakeClient{}
+	httpClient.DoRequestStub = func(method string, uri string, body interface{}) ([]byte, *errors.ApiError) {
+		u, _ := url.Parse(uri)
+		switch {
+		case u.Path == "/v1/secrets" && u.Query().Get(cst.Cursor) == "":
+			return []byte(`{"data":[
+				{"path":"prod:db","description":"db","data":{"password":"p@ss"}},
+				{"path":"production:db","data":{"password":"other"}}
+			],"cursor":"MQ=="}`), nil
+		case u.Path == "/v1/secrets" && u.Query().Get(cst.Cursor) == "MQ==":
+			return []byte(`{"data":[{"path":"prod:api","attributes":{"ttl":60}}],"cursor":""}`), nil
+		case u.Path == "/v1/secrets/prod/api":
+			return []byte(`{"path":"prod:api","attributes":{"ttl":60},"data":{"token":"abc"}}`), nil
+		}
+		return nil, errors.NewS("unexpected request " + uri)
+	}
+
+	var out []byte
+	outClient := &fake.FakeOutClient{}
+	outClient.WriteResponseStub = func(data []byte, apiError *errors.ApiError) { out = data }
+
+	vcli, rerr := vaultcli.NewWithOpts(
+		vaultcli.WithHTTPClient(httpClient),
+		vaultcli.WithOutClient(outClient),
+	)
+	if rerr != nil {
+		t.Fatalf("Unexpected error during vaultCLI init: %v", rerr)
+	}
+
+	dest := filepath.Join(t.TempDir(), "bundle.json")
+
+	viper.Reset()
+	viper.Set(cst.Tenant, "tenant")
+	viper.Set(cst.Output, dest)
+	err := handleSecretExportCmd(vcli, []string{"prod/"})
+	assert.NoError(t, err)
+	assert.Contains(t, string(out), `"exported":2`)
+
+	raw, rerr := os.ReadFile(dest)
+	assert.NoError(t, rerr)
+	bundle, err := parseSecretBundle(raw)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, "prod", bundle.Path)
+	if assert.Len(t, bundle.Secrets, 2) {
+		assert.Equal(t, "prod/db", bundle.Secrets[0].Path)
+		assert.Equal(t, "db", bundle.Secrets[0].Description)
+		assert.Equal(t, "prod/api", bundle.Secrets[1].Path)
+		assert.Equal(t, "abc", bundle.Secrets[1].Data["token"])
+	}
+
+	viper.Reset()
+	err = handleSecretExportCmd(vcli, []string{})
+	assert.Error(t, err)
+}
+
+func TestHandleSecretImportCmd(t *testing.T) {
+	input := filepath.Join(t.TempDir(), "bundle.json")
+	assert.NoError(t, os.WriteFile(input, []byte(`{"version":1,"path":"prod","secrets":[
+		{"path":"prod/db","data":{"password":"p@ss"}},
+		{"path":"prod/api","data":{"token":"abc"}}
+	]}`), 0o600))
+
+	var requests []string
+	httpClient := &fake.FakeClient{}
+	httpClient.DoRequestStub = func(method string, uri string, body interface{}) ([]byte, *errors.ApiError) {
+		if method != http.MethodGet {
+			requests = append(requests, method+" "+uri[strings.Index(uri, "/secrets/"):])
+			return []byte(`{}`), nil
+		}
+		if strings.Contains(uri, "/secrets/staging/db::description") {
+			return []byte(`{"path":"staging:db"}`), nil
+		}
+		return nil, errors.NewS("not found").WithResponse(&http.Response{StatusCode: http.StatusNotFound, Status: "404 Not Found"})
+	}
+
+	var out []byte
+	outClient := &fake.FakeOutClient{}
+	outClient.WriteResponseStub = func(data []byte, apiError *errors.ApiError) { out = data }
+
+	vcli, rerr := vaultcli.NewWithOpts(
+		vaultcli.WithHTTPClient(httpClient),
+		vaultcli.WithOutClient(outClient),
+	)
+	if rerr != nil {
+		t.Fatalf("Unexpected error during vaultCLI init: %v", rerr)
+	}
+
+	testCases := []struct {
+		name      string
+		overwrite bool
+		dryRun    bool
+		requests  []string
+		report    string
+	}{
+		{
+			name:     "skip existing",
+			requests: []string{"POST /secrets/staging/api"},
+			report:   `{"path":"staging/db","action":"skip"}`,
+		},
+		{
+			name:      "overwrite existing",
+			overwrite: true,
+			requests:  []string{"PUT /secrets/staging/db", "POST /secrets/staging/api"},
+			report:    `{"path":"staging/db","action":"update"}`,
+		},
+		{
+			name:      "dry run",
+			overwrite: true,
+			dryRun:    true,
+			report:    `{"path":"staging/api","action":"create"}`,
+		},
+	}
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			requests = nil
+			viper.Reset()
+			viper.Set(cst.RewritePrefix, "prod=staging")
+			viper.Set(cst.Overwrite, tt.overwrite)
+			viper.Set(cst.DryRun, tt.dryRun)
+
+			err := handleSecretImportCmd(vcli, []string{input})
+			assert.NoError(t, err)
+			assert.Equal(t, tt.requests, requests)
+			assert.Contains(t, string(out), tt.report)
+		})
+	}
+}
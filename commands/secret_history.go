@@ -0,0 +1,406 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	cst "github.com/DelineaXPM/dsv-cli/constants"
+	"github.com/DelineaXPM/dsv-cli/errors"
+	"github.com/DelineaXPM/dsv-cli/internal/predictor"
+	"github.com/DelineaXPM/dsv-cli/paths"
+	"github.com/DelineaXPM/dsv-cli/vaultcli"
+
+	"github.com/mitchellh/cli"
+	"github.com/spf13/viper"
+)
+
+// maskedValue replaces values in a diff unless --show-values is set.
+const maskedValue = "******"
+
+// Fields compared by diff commands.
+var (
+	secretDiffFields = []string{"data", "attributes"}
+	policyDiffFields = []string{"permissionDocument"}
+)
+
+func GetSecretHistoryCmd() (cli.Command, error) {
+	return getSecretHistoryCmd(cst.NounSecret, cst.ExamplePath)
+}
+
+func GetSecretDiffCmd() (cli.Command, error) {
+	return getSecretDiffCmd(cst.NounSecret, cst.ExamplePath)
+}
+
+func getSecretHistoryCmd(secretType string, example string) (cli.Command, error) {
+	return NewCommand(CommandArgs{
+		Path:         []string{secretType, cst.History},
+		SynopsisText: fmt.Sprintf("%s %s (<path> | --path|-r) [--limit|-l <n>]", secretType, cst.History),
+		HelpText: fmt.Sprintf(`List versions of a %[4]s with the time and the author of every change, newest first
+
+Usage:
+   • %[1]s %[2]s %[3]s
+   • %[1]s %[2]s --path %[3]s --limit 5
+`, secretType, cst.History, example, cst.NounSecret),
+		FlagsPredictor: []*predictor.Params{
+			{Name: cst.Path, Shorthand: "r", Usage: fmt.Sprintf("Target %s to a %s (required)", cst.Path, cst.NounSecret), Predictor: predictor.NewSecretPathPredictorDefault()},
+			{Name: cst.Limit, Shorthand: "l", Usage: "Maximum number of versions to list [default:all]"},
+		},
+		ArgsPredictor: predictor.NewSecretPathPredictorDefault(),
+		MinNumberArgs: 1,
+		RunFuncE: func(vcli vaultcli.CLI, args []string) error {
+			path := viper.GetString(cst.Path)
+			if path == "" && len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+				path = args[0]
+			}
+			return handleVersionHistoryCmd(vcli, secretVersionSource(vcli, secretType, path, ""))
+		},
+	})
+}
+
+func getSecretDiffCmd(secretType string, example string) (cli.Command, error) {
+	return NewCommand(CommandArgs{
+		Path:         []string{secretType, cst.Diff},
+		SynopsisText: fmt.Sprintf("%s %s (<path> | --path|-r) [--from <n>] [--to <n>] [--show-values]", secretType, cst.Diff),
+		HelpText: fmt.Sprintf(`Show keys of data and attributes which differ between two versions of a %[4]s
+
+Values are masked unless --show-values is set. By default the latest version is compared with the one before it.
+
+Usage:
+   • %[1]s %[2]s %[3]s
+   • %[1]s %[2]s --path %[3]s --from 1 --to 3 --show-values
+`, secretType, cst.Diff, example, cst.NounSecret),
+		FlagsPredictor: []*predictor.Params{
+			{Name: cst.Path, Shorthand: "r", Usage: fmt.Sprintf("Target %s to a %s (required)", cst.Path, cst.NounSecret), Predictor: predictor.NewSecretPathPredictorDefault()},
+			{Name: cst.From, Usage: "Version to compare from [default:the version before --to]"},
+			{Name: cst.To, Usage: "Version to compare to [default:latest]"},
+			{Name: cst.ShowValues, Usage: "Show values instead of masking them", ValueType: "bool"},
+		},
+		ArgsPredictor: predictor.NewSecretPathPredictorDefault(),
+		MinNumberArgs: 1,
+		RunFuncE: func(vcli vaultcli.CLI, args []string) error {
+			path := viper.GetString(cst.Path)
+			if path == "" && len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+				path = args[0]
+			}
+			return handleVersionDiffCmd(vcli, secretVersionSource(vcli, secretType, path, ""), secretDiffFields, !viper.GetBool(cst.ShowValues))
+		},
+	})
+}
+
+// versionSource reads versions of a versioned item such as a secret or a policy.
+type versionSource struct {
+	// current reads the latest version.
+	current func() ([]byte, *errors.ApiError)
+	// last reads the latest version together with the n versions before it.
+	last func(n int) ([]byte, *errors.ApiError)
+}
+
+func secretVersionSource(vcli vaultcli.CLI, secretType string, path string, id string) versionSource {
+	return versionSource{
+		current: func() ([]byte, *errors.ApiError) {
+			return getSecretFromServer(vcli, secretType, path, id, false, "")
+		},
+		last: func(n int) ([]byte, *errors.ApiError) {
+			return getSecretFromServer(vcli, secretType, path, id, false, fmt.Sprint("/", cst.Version, "/", n))
+		},
+	}
+}
+
+func policyVersionSource(vcli vaultcli.CLI, path string) versionSource {
+	path = paths.ProcessResource(path)
+	return versionSource{
+		current: func() ([]byte, *errors.ApiError) {
+			return policyRead(vcli, path)
+		},
+		last: func(n int) ([]byte, *errors.ApiError) {
+			return policyRead(vcli, fmt.Sprint(path, "/", cst.Version, "/", n))
+		},
+	}
+}
+
+func handleVersionHistoryCmd(vcli vaultcli.CLI, src versionSource) error {
+	limit := viper.GetInt(cst.Limit)
+	if limit < 0 {
+		return errors.NewS("--limit must not be negative")
+	}
+	items, apiErr := readVersionHistory(src, limit)
+	if apiErr != nil {
+		return apiErr
+	}
+
+	history := make([]map[string]interface{}, 0, len(items))
+	for _, item := range items {
+		entry := map[string]interface{}{}
+		for _, k := range []string{"version", "lastModified", "lastModifiedBy"} {
+			if v, ok := item[k]; ok {
+				entry[k] = v
+			}
+		}
+		history = append(history, entry)
+	}
+	data, err := json.Marshal(map[string]interface{}{"data": history})
+	if err != nil {
+		return err
+	}
+	vcli.Out().WriteResponse(data, nil)
+	return nil
+}
+
+func handleVersionDiffCmd(vcli vaultcli.CLI, src versionSource, fields []string, mask bool) error {
+	current, apiErr := src.current()
+	if apiErr != nil {
+		return apiErr
+	}
+	latest, err := itemVersion(current)
+	if err != nil {
+		return err
+	}
+
+	to := latest
+	if s := strings.TrimSpace(viper.GetString(cst.To)); s != "" {
+		if to, err = parseVersion(s); err != nil {
+			return err
+		}
+	}
+	from := to - 1
+	if s := strings.TrimSpace(viper.GetString(cst.From)); s != "" {
+		if from, err = parseVersion(s); err != nil {
+			return err
+		}
+	}
+	if from < 0 {
+		return errors.NewF("version %d has no previous version, set --%s", to, cst.From)
+	}
+
+	toItem, apiErr := readItemVersion(src, current, to)
+	if apiErr != nil {
+		return apiErr
+	}
+	fromItem, apiErr := readItemVersion(src, current, from)
+	if apiErr != nil {
+		return apiErr
+	}
+
+	data, err := json.Marshal(map[string]interface{}{
+		"from":    strconv.Itoa(from),
+		"to":      strconv.Itoa(to),
+		"changes": diffVersions(fromItem, toItem, fields, mask),
+	})
+	if err != nil {
+		return err
+	}
+	vcli.Out().WriteResponse(data, nil)
+	return nil
+}
+
+// readVersion returns the given version of an item.
+func readVersion(src versionSource, version string) ([]byte, *errors.ApiError) {
+	v, err := parseVersion(version)
+	if err != nil {
+		return nil, errors.New(err)
+	}
+	current, apiErr := src.current()
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	item, apiErr := readItemVersion(src, current, v)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	data, err := json.Marshal(item)
+	if err != nil {
+		return nil, errors.New(err)
+	}
+	return data, nil
+}
+
+// readItemVersion returns the given version of an item which latest version is current.
+func readItemVersion(src versionSource, current []byte, version int) (map[string]interface{}, *errors.ApiError) {
+	latest, err := itemVersion(current)
+	if err != nil {
+		return nil, errors.New(err)
+	}
+	if version > latest {
+		return nil, errors.NewF("version %d not found, the latest version is %d", version, latest)
+	}
+	if version == latest {
+		var item map[string]interface{}
+		if err := json.Unmarshal(current, &item); err != nil {
+			return nil, errors.New(err)
+		}
+		return item, nil
+	}
+
+	resp, apiErr := src.last(latest - version)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	items, err := parseVersionList(resp)
+	if err != nil {
+		return nil, errors.New(err)
+	}
+	for _, item := range items {
+		if fmt.Sprint(item["version"]) == strconv.Itoa(version) {
+			return item, nil
+		}
+	}
+	return nil, errors.NewF("version %d not found", version)
+}
+
+// readVersionHistory returns up to limit latest versions of an item, newest first. Zero limit means all versions.
+func readVersionHistory(src versionSource, limit int) ([]map[string]interface{}, *errors.ApiError) {
+	current, apiErr := src.current()
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	latest, err := itemVersion(current)
+	if err != nil {
+		return nil, errors.New(err)
+	}
+
+	n := latest
+	if limit > 0 && limit-1 < n {
+		n = limit - 1
+	}
+	resp := current
+	if n > 0 {
+		if resp, apiErr = src.last(n); apiErr != nil {
+			return nil, apiErr
+		}
+	}
+	items, err := parseVersionList(resp)
+	if err != nil {
+		return nil, errors.New(err)
+	}
+
+	sort.SliceStable(items, func(i, j int) bool {
+		vi, _ := strconv.Atoi(fmt.Sprint(items[i]["version"]))
+		vj, _ := strconv.Atoi(fmt.Sprint(items[j]["version"]))
+		return vi > vj
+	})
+	if limit > 0 && len(items) > limit {
+		items = items[:limit]
+	}
+	return items, nil
+}
+
+// parseVersionList parses a list of versions returned either as an array, as an object
+// with the array in the data field or as a single item.
+func parseVersionList(resp []byte) ([]map[string]interface{}, error) {
+	var items []map[string]interface{}
+	if err := json.Unmarshal(resp, &items); err == nil {
+		return items, nil
+	}
+
+	var obj map[string]interface{}
+	if err := json.Unmarshal(resp, &obj); err != nil {
+		return nil, fmt.Errorf("failed to parse versions: %v", err)
+	}
+	if list, ok := obj["data"].([]interface{}); ok {
+		for _, v := range list {
+			if item, ok := v.(map[string]interface{}); ok {
+				items = append(items, item)
+			}
+		}
+		return items, nil
+	}
+	return []map[string]interface{}{obj}, nil
+}
+
+// itemVersion returns the version of an item in a JSON response.
+func itemVersion(resp []byte) (int, error) {
+	var item map[string]interface{}
+	if err := json.Unmarshal(resp, &item); err != nil {
+		return 0, err
+	}
+	v, ok := item["version"]
+	if !ok {
+		return 0, fmt.Errorf("version not found")
+	}
+	return parseVersion(fmt.Sprint(v))
+}
+
+func parseVersion(s string) (int, error) {
+	v, err := strconv.Atoi(s)
+	if err != nil || v < 0 {
+		return 0, fmt.Errorf("invalid version %q: must be a non-negative integer", s)
+	}
+	return v, nil
+}
+
+// versionChange is a key which differs between two versions.
+type versionChange struct {
+	Key    string      `json:"key"`
+	Action string      `json:"action"`
+	From   interface{} `json:"from,omitempty"`
+	To     interface{} `json:"to,omitempty"`
+}
+
+// diffVersions compares the given fields of two versions key by key. Nested keys are joined with dots.
+func diffVersions(from, to map[string]interface{}, fields []string, mask bool) []versionChange {
+	before, after := map[string]interface{}{}, map[string]interface{}{}
+	for _, f := range fields {
+		flattenValue(f, from[f], before)
+		flattenValue(f, to[f], after)
+	}
+
+	keys := make([]string, 0, len(before)+len(after))
+	for k := range before {
+		keys = append(keys, k)
+	}
+	for k := range after {
+		if _, ok := before[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	value := func(v interface{}) interface{} {
+		if mask {
+			return maskedValue
+		}
+		return v
+	}
+	changes := []versionChange{}
+	for _, k := range keys {
+		b, inBefore := before[k]
+		a, inAfter := after[k]
+		switch {
+		case !inBefore:
+			changes = append(changes, versionChange{Key: k, Action: "added", To: value(a)})
+		case !inAfter:
+			changes = append(changes, versionChange{Key: k, Action: "removed", From: value(b)})
+		case !reflect.DeepEqual(a, b):
+			changes = append(changes, versionChange{Key: k, Action: "changed", From: value(b), To: value(a)})
+		}
+	}
+	return changes
+}
+
+// flattenValue adds leaf values of v to out with keys prefixed by key. Missing fields are skipped.
+func flattenValue(key string, v interface{}, out map[string]interface{}) {
+	switch val := v.(type) {
+	case nil:
+		return
+	case map[string]interface{}:
+		if len(val) == 0 {
+			out[key] = val
+		}
+		for k, item := range val {
+			flattenValue(key+"."+k, item, out)
+		}
+	case []interface{}:
+		if len(val) == 0 {
+			out[key] = val
+		}
+		for i, item := range val {
+			flattenValue(key+"."+strconv.Itoa(i), item, out)
+		}
+	default:
+		out[key] = val
+	}
+}
@@ -0,0 +1,236 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"testing"
+
+	cst "github.com/DelineaXPM/dsv-cli/constants"
+	"github.com/DelineaXPM/dsv-cli/errors"
+	"github.com/DelineaXPM/dsv-cli/tests/fake"
+	"github.com/DelineaXPM/dsv-cli/vaultcli"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetSecretHistoryCmd(t *testing.T) {
+	for _, f := range []func() (interface{}, error){
+		func() (interface{}, error) { return GetSecretHistoryCmd() },
+		func() (interface{}, error) { return GetSecretDiffCmd() },
+		func() (interface{}, error) { return GetHomeHistoryCmd() },
+		func() (interface{}, error) { return GetHomeDiffCmd() },
+		func() (interface{}, error) { return GetPolicyHistoryCmd() },
+		func() (interface{}, error) { return GetPolicyDiffCmd() },
+	} {
+		_, err := f()
+		assert.Nil(t, err)
+	}
+}
+
+// fakeVersions is a versionSource over versions 0..n-1 of an item. It records the requested counts.
+type fakeVersions struct {
+	items    []string
+	requests []int
+}
+
+func newFakeVersions(items ...string) *fakeVersions {
+	return &fakeVersions{items: items}
+}
+
+func (f *fakeVersions) source() versionSource {
+	return versionSource{
+		current: func() ([]byte, *errors.ApiError) {
+			return []byte(f.items[len(f.items)-1]), nil
+		},
+		last: func(n int) ([]byte, *errors.ApiError) {
+			f.requests = append(f.requests, n)
+			// Return versions oldest first to check that callers do not rely on the order.
+			list := f.items[len(f.items)-1-n:]
+			return []byte(`{"data":[` + strings.Join(list, ",") + `]}`), nil
+		},
+	}
+}
+
+func versionItem(v int, data string) string {
+	return fmt.Sprintf(`{"version":"%d","lastModified":"2024-01-0%dT00:00:00Z","lastModifiedBy":"user%d","data":%s}`, v, v+1, v, data)
+}
+
+func TestReadVersion(t *testing.T) {
+	f := newFakeVersions(versionItem(0, `{"a":"0"}`), versionItem(1, `{"a":"1"}`), versionItem(2, `{"a":"2"}`), versionItem(3, `{"a":"3"}`))
+
+	data, apiErr := readVersion(f.source(), "1")
+	assert.Nil(t, apiErr)
+	assert.JSONEq(t, versionItem(1, `{"a":"1"}`), string(data))
+	assert.Equal(t, []int{2}, f.requests)
+
+	f.requests = nil
+	data, apiErr = readVersion(f.source(), "3")
+	assert.Nil(t, apiErr)
+	assert.JSONEq(t, versionItem(3, `{"a":"3"}`), string(data))
+	assert.Empty(t, f.requests, "the latest version must not request the history")
+
+	_, apiErr = readVersion(f.source(), "4")
+	assert.EqualError(t, apiErr, "version 4 not found, the latest version is 3")
+
+	_, apiErr = readVersion(f.source(), "-1")
+	assert.ErrorContains(t, apiErr, "must be a non-negative integer")
+}
+
+func TestReadVersionHistory(t *testing.T) {
+	f := newFakeVersions(versionItem(0, `{}`), versionItem(1, `{}`), versionItem(2, `{}`), versionItem(3, `{}`))
+
+	versionsOf := func(items []map[string]interface{}) []string {
+		var versions []string
+		for _, item := range items {
+			versions = append(versions, fmt.Sprint(item["version"]))
+		}
+		return versions
+	}
+
+	items, apiErr := readVersionHistory(f.source(), 0)
+	assert.Nil(t, apiErr)
+	assert.Equal(t, []string{"3", "2", "1", "0"}, versionsOf(items))
+	assert.Equal(t, []int{3}, f.requests)
+
+	f.requests = nil
+	items, apiErr = readVersionHistory(f.source(), 2)
+	assert.Nil(t, apiErr)
+	assert.Equal(t, []string{"3", "2"}, versionsOf(items))
+	assert.Equal(t, []int{1}, f.requests)
+
+	f = newFakeVersions(versionItem(0, `{}`))
+	items, apiErr = readVersionHistory(f.source(), 0)
+	assert.Nil(t, apiErr)
+	assert.Equal(t, []string{"0"}, versionsOf(items))
+	assert.Empty(t, f.requests)
+}
+
+func TestParseVersionList(t *testing.T) {
+	testCases := []struct {
+		name string
+		resp string
+		want int
+	}{
+		{"array", `[{"version":"1"},{"version":"0"}]`, 2},
+		{"data array", `{"data":[{"version":"1"},{"version":"0"}]}`, 2},
+		{"single item", `{"version":"0","data":{"a":"b"}}`, 1},
+	}
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			items, err := parseVersionList([]byte(tt.resp))
+			assert.NoError(t, err)
+			assert.Len(t, items, tt.want)
+		})
+	}
+
+	_, err := parseVersionList([]byte(`not json`))
+	assert.Error(t, err)
+}
+
+func TestDiffVersions(t *testing.T) {
+	from := map[string]interface{}{}
+	to := map[string]interface{}{}
+	assert.NoError(t, json.Unmarshal([]byte(`{
+		"data": {"user": "admin", "password": "old", "removed": "x", "nested": {"a": 1, "b": [1, 2]}},
+		"attributes": {"ttl": 60},
+		"description": "ignored"
+	}`), &from))
+	assert.NoError(t, json.Unmarshal([]byte(`{
+		"data": {"user": "admin", "password": "new", "added": true, "nested": {"a": 1, "b": [1, 3]}},
+		"description": "changed"
+	}`), &to))
+
+	changes := diffVersions(from, to, secretDiffFields, false)
+	assert.Equal(t, []versionChange{
+		{Key: "attributes.ttl", Action: "removed", From: float64(60)},
+		{Key: "data.added", Action: "added", To: true},
+		{Key: "data.nested.b.1", Action: "changed", From: float64(2), To: float64(3)},
+		{Key: "data.password", Action: "changed", From: "old", To: "new"},
+		{Key: "data.removed", Action: "removed", From: "x"},
+	}, changes)
+
+	for _, c := range diffVersions(from, to, secretDiffFields, true) {
+		for _, v := range []interface{}{c.From, c.To} {
+			if v != nil {
+				assert.Equal(t, maskedValue, v, c.Key)
+			}
+		}
+	}
+
+	assert.Empty(t, diffVersions(from, from, secretDiffFields, true))
+}
+
+func TestHandleSecretDiffCmd(t *testing.T) {
+	versions := []string{
+		versionItem(0, `{"password":"first","user":"admin"}`),
+		versionItem(1, `{"password":"second","user":"admin"}`),
+		versionItem(2, `{"password":"second","user":"root"}`),
+	}
+	httpClient := &fake.FakeClient{}
+	httpClient.DoRequestStub = func(method string, uri string, body interface{}) ([]byte, *errors.ApiError) {
+		if i := strings.Index(uri, "/version/"); i >= 0 {
+			n, _ := strconv.Atoi(strings.SplitN(uri[i+len("/version/"):], "?", 2)[0])
+			return []byte(`[` + strings.Join(versions[len(versions)-1-n:], ",") + `]`), nil
+		}
+		return []byte(versions[len(versions)-1]), nil
+	}
+	var out []byte
+	var outErr *errors.ApiError
+	outClient := &fake.FakeOutClient{}
+	outClient.WriteResponseStub = func(data []byte, apiError *errors.ApiError) { out, outErr = data, apiError }
+	outClient.FailStub = func(err error) { outErr = errors.New(err) }
+	vcli, err := vaultcli.NewWithOpts(vaultcli.WithHTTPClient(httpClient), vaultcli.WithOutClient(outClient))
+	if err != nil {
+		t.Fatalf("Unexpected error during vaultCLI init: %v", err)
+	}
+	cmd, _ := GetSecretDiffCmd()
+	run := cmd.(*baseCommand).runFuncE
+
+	testCases := []struct {
+		name  string
+		flags map[string]interface{}
+		want  string
+		err   string
+	}{
+		{
+			name: "latest with previous",
+			want: `{"from":"1","to":"2","changes":[{"key":"data.user","action":"changed","from":"******","to":"******"}]}`,
+		},
+		{
+			name:  "show values",
+			flags: map[string]interface{}{cst.From: "0", cst.To: "1", cst.ShowValues: true},
+			want:  `{"from":"0","to":"1","changes":[{"key":"data.password","action":"changed","from":"first","to":"second"}]}`,
+		},
+		{
+			name:  "no previous version",
+			flags: map[string]interface{}{cst.To: "0"},
+			err:   "version 0 has no previous version",
+		},
+		{
+			name:  "unknown version",
+			flags: map[string]interface{}{cst.To: "7"},
+			err:   "version 7 not found",
+		},
+	}
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			viper.Reset()
+			defer viper.Reset()
+			for k, v := range tt.flags {
+				viper.Set(k, v)
+			}
+			out = nil
+			err := run(vcli, []string{"db/prod"})
+			if tt.err != "" {
+				assert.ErrorContains(t, err, tt.err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Nil(t, outErr)
+			assert.JSONEq(t, tt.want, string(out))
+		})
+	}
+}
@@ -0,0 +1,410 @@
+package cmd
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"os/user"
+	"runtime"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	cst "github.com/DelineaXPM/dsv-cli/constants"
+	"github.com/DelineaXPM/dsv-cli/errors"
+	"github.com/DelineaXPM/dsv-cli/internal/predictor"
+	"github.com/DelineaXPM/dsv-cli/utils"
+	"github.com/DelineaXPM/dsv-cli/vaultcli"
+
+	"github.com/mitchellh/cli"
+	"github.com/spf13/viper"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	defaultSecretSyncInterval = 5 * time.Minute
+	defaultSecretSinkMode     = 0o600
+)
+
+func GetSecretSyncCmd() (cli.Command, error) {
+	return NewCommand(CommandArgs{
+		Path: []string{cst.NounSecret, cst.Sync},
+		SynopsisText: fmt.Sprintf("%s (--path|-r <path> --dest <file> [--key <key>] | --file <sinks.yml>) [--interval <duration>] [--once]",
+			cst.Sync),
+		HelpText: fmt.Sprintf(`Keep %[1]ss written to files and refresh them when they change
+
+Every interval the version of each %[1]s is checked. When it changes, the %[1]s is read and written to
+the destination file if its content differs from the file. Files are written atomically with the given
+mode and owner, so readers never observe a partially written file. The --on-change command is run by
+the shell only after the file was changed, e.g. to reload a server.
+
+With --key the value of that key of the %[1]s data is written, otherwise the whole data is written as JSON.
+
+Many sinks can be listed in a YAML file:
+
+   interval: 5m
+   sinks:
+     - path: tls/web
+       key: cert
+       dest: /etc/ssl/web.pem
+       mode: "0640"
+       owner: root:nginx
+       onChange: nginx -s reload
+
+The command runs until it is stopped with SIGINT or SIGTERM. With --once it syncs all sinks once,
+prints a report and exits.
+
+Usage:
+   • secret %[2]s --path tls/web --key cert --dest /etc/ssl/web.pem --interval 5m --on-change "nginx -s reload"
+   • secret %[2]s --file sinks.yml
+   • secret %[2]s --path %[3]s --dest ./db.json --once
+`, cst.NounSecret, cst.Sync, cst.ExamplePath),
+		FlagsPredictor: []*predictor.Params{
+			{Name: cst.Path, Shorthand: "r", Usage: fmt.Sprintf("Path to the %s to write", cst.NounSecret), Predictor: predictor.NewSecretPathPredictorDefault()},
+			{Name: cst.Key, Usage: fmt.Sprintf("Key of the %s data to write, the whole data is written as JSON if not set", cst.NounSecret)},
+			{Name: cst.Dest, Usage: "Path to the destination file"},
+			{Name: cst.Mode, Usage: fmt.Sprintf("Permissions of the destination file [default:%04o]", defaultSecretSinkMode)},
+			{Name: cst.Owner, Usage: "Owner of the destination file as <user>[:<group>], names or numeric IDs"},
+			{Name: cst.OnChange, Usage: "Command run by the shell after the destination file is changed"},
+			{Name: cst.File, Usage: "YAML file listing sinks"},
+			{Name: cst.Interval, Usage: fmt.Sprintf("How often to check %ss for changes [default:%s]", cst.NounSecret, defaultSecretSyncInterval)},
+			{Name: cst.Once, Usage: "Sync once, print a report and exit", ValueType: "bool"},
+		},
+		RunFuncE: handleSecretSyncCmd,
+	})
+}
+
+func handleSecretSyncCmd(vcli vaultcli.CLI, args []string) error {
+	sinks, interval, err := secretSyncSinks()
+	if err != nil {
+		return err
+	}
+
+	if viper.GetBool(cst.Once) {
+		results := syncSecretSinks(vcli, sinks)
+		report, err := json.Marshal(map[string]interface{}{"results": results})
+		if err != nil {
+			return err
+		}
+		vcli.Out().WriteResponse(report, nil)
+
+		failed := 0
+		for _, res := range results {
+			if res.Error != "" {
+				failed++
+			}
+		}
+		if failed > 0 {
+			return errors.NewF("%d of %d sink(s) failed to sync", failed, len(results))
+		}
+		return nil
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	fmt.Fprintf(os.Stderr, "Syncing %d sink(s) every %s.\n", len(sinks), interval)
+	for {
+		runSecretSync(vcli, sinks)
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(interval):
+		}
+	}
+}
+
+// runSecretSync syncs all sinks and prints what changed. The token obtained before the command started
+// expires while it runs, so a fresh one is requested first.
+func runSecretSync(vcli vaultcli.CLI, sinks []*secretSink) {
+	tr, apiErr := vcli.Authenticator().GetToken()
+	if apiErr == nil && (tr == nil || tr.Token == "") {
+		apiErr = errors.NewS("empty token")
+	}
+	if apiErr != nil {
+		fmt.Fprintf(os.Stderr, "Failed to refresh token: %v\n", apiErr)
+		return
+	}
+	viper.Set(cst.NounToken, tr.Token)
+
+	for _, res := range syncSecretSinks(vcli, sinks) {
+		switch {
+		case res.Error != "":
+			fmt.Fprintf(os.Stderr, "%s -> %s: %s\n", res.Path, res.Dest, res.Error)
+		case res.Action == secretSinkWritten:
+			fmt.Fprintf(os.Stderr, "%s -> %s: written version %s\n", res.Path, res.Dest, res.Version)
+		}
+	}
+}
+
+// secretSinkFile is the format of the YAML file listing sinks.
+type secretSinkFile struct {
+	Interval string        `yaml:"interval"`
+	Sinks    []*secretSink `yaml:"sinks"`
+}
+
+// secretSink writes a secret to a file.
+type secretSink struct {
+	Path     string `yaml:"path"`
+	Key      string `yaml:"key"`
+	Dest     string `yaml:"dest"`
+	Mode     string `yaml:"mode"`
+	Owner    string `yaml:"owner"`
+	OnChange string `yaml:"onChange"`
+
+	perm os.FileMode
+	uid  int
+	gid  int
+
+	// version and hash of the content written last.
+	version string
+	hash    string
+}
+
+const (
+	secretSinkWritten   = "written"
+	secretSinkUnchanged = "unchanged"
+)
+
+type secretSyncResult struct {
+	Path    string `json:"path"`
+	Dest    string `json:"dest"`
+	Version string `json:"version,omitempty"`
+	Action  string `json:"action,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+// secretSyncSinks returns sinks and the interval either from the file set with --file or from flags.
+func secretSyncSinks() ([]*secretSink, time.Duration, error) {
+	cfg := &secretSinkFile{}
+	if file := viper.GetString(cst.File); file != "" {
+		raw, err := os.ReadFile(file)
+		if err != nil {
+			return nil, 0, errors.New(err).Grow("Failed to read the sinks file")
+		}
+		if err := yaml.Unmarshal(raw, cfg); err != nil {
+			return nil, 0, errors.New(err).Grow("Failed to parse the sinks file")
+		}
+		if len(cfg.Sinks) == 0 {
+			return nil, 0, errors.NewF("error: no sinks found in %s", file)
+		}
+	} else {
+		cfg.Sinks = []*secretSink{{
+			Path:     viper.GetString(cst.Path),
+			Key:      viper.GetString(cst.Key),
+			Dest:     viper.GetString(cst.Dest),
+			Mode:     viper.GetString(cst.Mode),
+			Owner:    viper.GetString(cst.Owner),
+			OnChange: viper.GetString(cst.OnChange),
+		}}
+	}
+	if v := viper.GetString(cst.Interval); v != "" {
+		cfg.Interval = v
+	}
+
+	interval := defaultSecretSyncInterval
+	if cfg.Interval != "" {
+		d, err := time.ParseDuration(cfg.Interval)
+		if err != nil || d <= 0 {
+			return nil, 0, errors.NewF("error: invalid interval %q, must be a positive duration, e.g. 30s or 5m", cfg.Interval)
+		}
+		interval = d
+	}
+
+	dests := make(map[string]bool, len(cfg.Sinks))
+	for i, sink := range cfg.Sinks {
+		if err := sink.init(); err != nil {
+			return nil, 0, errors.NewF("error: sink %d: %v", i+1, err)
+		}
+		if dests[sink.Dest] {
+			return nil, 0, errors.NewF("error: sink %d: destination %s is used more than once", i+1, sink.Dest)
+		}
+		dests[sink.Dest] = true
+	}
+	return cfg.Sinks, interval, nil
+}
+
+// init validates the sink and parses its mode and owner.
+func (s *secretSink) init() error {
+	if s.Path == "" {
+		return fmt.Errorf("--%s must be set", cst.Path)
+	}
+	if s.Dest == "" {
+		return fmt.Errorf("--%s must be set", cst.Dest)
+	}
+
+	s.perm = defaultSecretSinkMode
+	if s.Mode != "" {
+		mode, err := strconv.ParseUint(s.Mode, 8, 32)
+		if err != nil || mode > 0o777 {
+			return fmt.Errorf("invalid mode %q, must be octal permissions, e.g. 0640", s.Mode)
+		}
+		s.perm = os.FileMode(mode)
+	}
+
+	var err error
+	s.uid, s.gid, err = parseFileOwner(s.Owner)
+	return err
+}
+
+// parseFileOwner parses <user>[:<group>] where both parts are names or numeric IDs. An empty part is returned as -1.
+func parseFileOwner(owner string) (int, int, error) {
+	if owner == "" {
+		return -1, -1, nil
+	}
+	if runtime.GOOS == "windows" {
+		return -1, -1, fmt.Errorf("changing owner of files is not supported on windows")
+	}
+	userName, groupName, _ := strings.Cut(owner, ":")
+
+	uid, gid := -1, -1
+	if userName != "" {
+		id, err := strconv.Atoi(userName)
+		if err != nil {
+			u, lookupErr := user.Lookup(userName)
+			if lookupErr != nil {
+				return -1, -1, fmt.Errorf("invalid owner %q: %v", owner, lookupErr)
+			}
+			id, _ = strconv.Atoi(u.Uid)
+		}
+		uid = id
+	}
+	if groupName != "" {
+		id, err := strconv.Atoi(groupName)
+		if err != nil {
+			g, lookupErr := user.LookupGroup(groupName)
+			if lookupErr != nil {
+				return -1, -1, fmt.Errorf("invalid owner %q: %v", owner, lookupErr)
+			}
+			id, _ = strconv.Atoi(g.Gid)
+		}
+		gid = id
+	}
+	return uid, gid, nil
+}
+
+func syncSecretSinks(vcli vaultcli.CLI, sinks []*secretSink) []*secretSyncResult {
+	results := make([]*secretSyncResult, 0, len(sinks))
+	for _, sink := range sinks {
+		results = append(results, sink.sync(vcli))
+	}
+	return results
+}
+
+// sync writes the secret to the destination file if it changed.
+func (s *secretSink) sync(vcli vaultcli.CLI) *secretSyncResult {
+	res := &secretSyncResult{Path: s.Path, Dest: s.Dest}
+
+	// The description is small, so it is used to check the version before reading the secret.
+	desc, apiErr := getSecretFromServer(vcli, cst.NounSecret, s.Path, "", false, cst.SuffixDescription)
+	if apiErr != nil {
+		res.Error = apiErr.Error()
+		return res
+	}
+	version := utils.GetVersion(desc)
+	res.Version = version
+
+	if version != "" && version == s.version && fileHash(s.Dest) == s.hash {
+		s.unchanged(res)
+		return res
+	}
+
+	resp, apiErr := getSecretFromServer(vcli, cst.NounSecret, s.Path, "", false, "")
+	if apiErr != nil {
+		res.Error = apiErr.Error()
+		return res
+	}
+	content, err := secretSinkContent(resp, s.Key)
+	if err != nil {
+		res.Error = err.Error()
+		return res
+	}
+	if v := utils.GetVersion(resp); v != "" {
+		res.Version = v
+	}
+
+	hash := contentHash(content)
+	if hash == fileHash(s.Dest) {
+		s.version, s.hash = res.Version, hash
+		s.unchanged(res)
+		return res
+	}
+
+	if err := utils.WriteFileAtomicOwned(s.Dest, content, s.perm, s.uid, s.gid); err != nil {
+		res.Error = fmt.Sprintf("failed to write file: %v", err)
+		return res
+	}
+	s.version, s.hash = res.Version, hash
+	res.Action = secretSinkWritten
+
+	if s.OnChange != "" {
+		if out, err := shellCommand(s.OnChange).CombinedOutput(); err != nil {
+			res.Error = fmt.Sprintf("on-change command failed: %v: %s", err, strings.TrimSpace(string(out)))
+		}
+	}
+	return res
+}
+
+// unchanged marks the result as unchanged and restores the mode and the owner of the file
+// if they were changed since the file was written.
+func (s *secretSink) unchanged(res *secretSyncResult) {
+	res.Action = secretSinkUnchanged
+	info, err := os.Stat(s.Dest)
+	if err == nil && info.Mode().Perm() != s.perm {
+		err = os.Chmod(s.Dest, s.perm)
+	}
+	if err == nil && (s.uid != -1 || s.gid != -1) {
+		err = os.Chown(s.Dest, s.uid, s.gid)
+	}
+	if err != nil {
+		res.Error = fmt.Sprintf("failed to set mode or owner of file: %v", err)
+	}
+}
+
+// secretSinkContent returns the value of the key of the secret data or the whole data as JSON.
+func secretSinkContent(resp []byte, key string) ([]byte, error) {
+	var s struct {
+		Data map[string]interface{} `json:"data"`
+	}
+	if err := json.Unmarshal(resp, &s); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %v", cst.NounSecret, err)
+	}
+	if key == "" {
+		return json.MarshalIndent(s.Data, "", "  ")
+	}
+	val, ok := s.Data[key]
+	if !ok {
+		return nil, fmt.Errorf("key %q not found in %s data", key, cst.NounSecret)
+	}
+	if str, ok := val.(string); ok {
+		return []byte(str), nil
+	}
+	return json.Marshal(val)
+}
+
+func contentHash(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// fileHash returns the hash of the file content or an empty string if the file cannot be read.
+func fileHash(path string) string {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	return contentHash(data)
+}
+
+func shellCommand(command string) *exec.Cmd {
+	if runtime.GOOS == "windows" {
+		return exec.Command("cmd", "/C", command)
+	}
+	return exec.Command("sh", "-c", command)
+}
@@ -0,0 +1,305 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DelineaXPM/dsv-cli/auth"
+	cst "github.com/DelineaXPM/dsv-cli/constants"
+	"github.com/DelineaXPM/dsv-cli/errors"
+	"github.com/DelineaXPM/dsv-cli/tests/fake"
+	"github.com/DelineaXPM/dsv-cli/vaultcli"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetSecretSyncCmd(t *testing.T) {
+	_, err := GetSecretSyncCmd()
+	assert.Nil(t, err)
+}
+
+func TestSecretSyncSinks(t *testing.T) {
+	viper.Reset()
+	defer viper.Reset()
+	dir := t.TempDir()
+
+	viper.Set(cst.Path, "tls/web")
+	viper.Set(cst.Dest, filepath.Join(dir, "web.pem"))
+	viper.Set(cst.Mode, "0640")
+	sinks, interval, err := secretSyncSinks()
+	assert.NoError(t, err)
+	assert.Equal(t, defaultSecretSyncInterval, interval)
+	if assert.Len(t, sinks, 1) {
+		assert.Equal(t, os.FileMode(0o640), sinks[0].perm)
+		assert.Equal(t, -1, sinks[0].uid)
+	}
+
+	file := filepath.Join(dir, "sinks.yml")
+	assert.NoError(t, os.WriteFile(file, []byte(`
+interval: 30s
+sinks:
+  - path: tls/web
+    key: cert
+    dest: /etc/ssl/web.pem
+    mode: 0644
+    onChange: nginx -s reload
+  - path: tls/web
+    key: key
+    dest: /etc/ssl/web.key
+`), 0o600))
+	viper.Reset()
+	viper.Set(cst.File, file)
+	sinks, interval, err = secretSyncSinks()
+	assert.NoError(t, err)
+	assert.Equal(t, 30*time.Second, interval)
+	if assert.Len(t, sinks, 2) {
+		assert.Equal(t, os.FileMode(0o644), sinks[0].perm)
+		assert.Equal(t, "nginx -s reload", sinks[0].OnChange)
+		assert.Equal(t, os.FileMode(defaultSecretSinkMode), sinks[1].perm)
+	}
+
+	testCases := []struct {
+		name  string
+		flags map[string]string
+		err   string
+	}{
+		{"no path", map[string]string{cst.Dest: "a"}, "--path must be set"},
+		{"no dest", map[string]string{cst.Path: "a"}, "--dest must be set"},
+		{"bad mode", map[string]string{cst.Path: "a", cst.Dest: "a", cst.Mode: "rw"}, "invalid mode"},
+		{"bad interval", map[string]string{cst.Path: "a", cst.Dest: "a", cst.Interval: "5"}, "invalid interval"},
+		{"missing file", map[string]string{cst.File: filepath.Join(dir, "missing.yml")}, "Failed to read the sinks file"},
+	}
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			viper.Reset()
+			for k, v := range tt.flags {
+				viper.Set(k, v)
+			}
+			_, _, err := secretSyncSinks()
+			if assert.Error(t, err) {
+				assert.Contains(t, err.Error(), tt.err)
+			}
+		})
+	}
+}
+
+func TestParseFileOwner(t *testing.T) {
+	uid, gid, err := parseFileOwner("")
+	assert.NoError(t, err)
+	assert.Equal(t, []int{-1, -1}, []int{uid, gid})
+
+	if runtime.GOOS == "windows" {
+		_, _, err = parseFileOwner("1000")
+		assert.Error(t, err)
+		return
+	}
+
+	uid, gid, err = parseFileOwner("1000:50")
+	assert.NoError(t, err)
+	assert.Equal(t, []int{1000, 50}, []int{uid, gid})
+
+	uid, gid, err = parseFileOwner(":50")
+	assert.NoError(t, err)
+	assert.Equal(t, []int{-1, 50}, []int{uid, gid})
+
+	_, _, err = parseFileOwner("no-such-user-for-dsv-tests")
+	assert.Error(t, err)
+}
+
+func TestSecretSinkContent(t *testing.T) {
+	resp := []byte(`{"data":{"cert":"-----BEGIN CERTIFICATE-----","port":5432}}`)
+
+	content, err := secretSinkContent(resp, "cert")
+	assert.NoError(t, err)
+	assert.Equal(t, "-----BEGIN CERTIFICATE-----", string(content))
+
+	content, err = secretSinkContent(resp, "port")
+	assert.NoError(t, err)
+	assert.Equal(t, "5432", string(content))
+
+	content, err = secretSinkContent(resp, "")
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"cert":"-----BEGIN CERTIFICATE-----","port":5432}`, string(content))
+
+	_, err = secretSinkContent(resp, "key")
+	assert.ErrorContains(t, err, `key "key" not found`)
+}
+
+func TestSecretSinkSync(t *testing.T) {
+	dir := t.TempDir()
+	dest := filepath.Join(dir, "web.pem")
+	marker := filepath.Join(dir, "reloaded")
+
+	version, cert := 1, "first"
+	var requests []string
+	httpClient := &fake.FakeClient{}
+	httpClient.DoRequestStub = func(method string, uri string, body interface{}) ([]byte, *errors.ApiError) {
+		if strings.Contains(uri, cst.SuffixDescription) {
+			requests = append(requests, "describe")
+			return []byte(fmt.Sprintf(`{"path":"tls:web","version":"%d"}`, version)), nil
+		}
+		requests = append(requests, "read")
+		return []byte(fmt.Sprintf(`{"path":"tls:web","version":"%d","data":{"cert":%q}}`, version, cert)), nil
+	}
+	vcli, err := vaultcli.NewWithOpts(vaultcli.WithHTTPClient(httpClient))
+	if err != nil {
+		t.Fatalf("Unexpected error during vaultCLI init: %v", err)
+	}
+
+	sink := &secretSink{Path: "tls/web", Key: "cert", Dest: dest}
+	if runtime.GOOS != "windows" {
+		sink.OnChange = "echo x >> " + marker
+	}
+	assert.NoError(t, sink.init())
+
+	reloads := func() int {
+		b, _ := os.ReadFile(marker)
+		return strings.Count(string(b), "x")
+	}
+
+	viper.Reset()
+	defer viper.Reset()
+
+	res := sink.sync(vcli)
+	assert.Equal(t, &secretSyncResult{Path: "tls/web", Dest: dest, Version: "1", Action: secretSinkWritten}, res)
+	b, _ := os.ReadFile(dest)
+	assert.Equal(t, "first", string(b))
+
+	// Same version, the secret is not read again.
+	requests = nil
+	res = sink.sync(vcli)
+	assert.Equal(t, secretSinkUnchanged, res.Action)
+	assert.Equal(t, []string{"describe"}, requests)
+
+	// New version with the same content, the file is not rewritten.
+	version = 2
+	res = sink.sync(vcli)
+	assert.Equal(t, secretSinkUnchanged, res.Action)
+	assert.Equal(t, "2", res.Version)
+
+	if runtime.GOOS != "windows" {
+		// A changed mode is restored even if the content is unchanged.
+		assert.NoError(t, os.Chmod(dest, 0o644))
+		res = sink.sync(vcli)
+		assert.Equal(t, secretSinkUnchanged, res.Action)
+		assert.Empty(t, res.Error)
+		info, _ := os.Stat(dest)
+		assert.Equal(t, os.FileMode(defaultSecretSinkMode), info.Mode().Perm())
+	}
+
+	// New content.
+	version, cert = 3, "second"
+	res = sink.sync(vcli)
+	assert.Equal(t, secretSinkWritten, res.Action)
+	b, _ = os.ReadFile(dest)
+	assert.Equal(t, "second", string(b))
+
+	// File changed on disk is restored.
+	assert.NoError(t, os.WriteFile(dest, []byte("tampered"), 0o600))
+	res = sink.sync(vcli)
+	assert.Equal(t, secretSinkWritten, res.Action)
+
+	if runtime.GOOS != "windows" {
+		assert.Equal(t, 3, reloads(), "on-change command must run only when the file changed")
+		info, _ := os.Stat(dest)
+		assert.Equal(t, os.FileMode(defaultSecretSinkMode), info.Mode().Perm())
+
+		sink.OnChange = "exit 3"
+		assert.NoError(t, os.Remove(dest))
+		res = sink.sync(vcli)
+		assert.Equal(t, secretSinkWritten, res.Action)
+		assert.Contains(t, res.Error, "on-change command failed")
+	}
+}
+
+func TestHandleSecretSyncCmd_Once(t *testing.T) {
+	dir := t.TempDir()
+	httpClient := &fake.FakeClient{}
+	httpClient.DoRequestStub = func(method string, uri string, body interface{}) ([]byte, *errors.ApiError) {
+		if strings.Contains(uri, "missing") {
+			return nil, errors.NewS("not found")
+		}
+		return []byte(`{"version":"1","data":{"a":"b"}}`), nil
+	}
+	var out []byte
+	outClient := &fake.FakeOutClient{}
+	outClient.WriteResponseStub = func(data []byte, apiError *errors.ApiError) { out = data }
+	vcli, err := vaultcli.NewWithOpts(vaultcli.WithHTTPClient(httpClient), vaultcli.WithOutClient(outClient))
+	if err != nil {
+		t.Fatalf("Unexpected error during vaultCLI init: %v", err)
+	}
+
+	file := filepath.Join(dir, "sinks.yml")
+	assert.NoError(t, os.WriteFile(file, []byte(fmt.Sprintf(`
+sinks:
+  - path: app/one
+    dest: %s
+  - path: app/missing
+    dest: %s
+`, filepath.Join(dir, "one.json"), filepath.Join(dir, "missing.json"))), 0o600))
+
+	viper.Reset()
+	defer viper.Reset()
+	viper.Set(cst.File, file)
+	viper.Set(cst.Once, true)
+
+	err = handleSecretSyncCmd(vcli, nil)
+	assert.ErrorContains(t, err, "1 of 2 sink(s) failed to sync")
+
+	report := struct {
+		Results []*secretSyncResult `json:"results"`
+	}{}
+	assert.NoError(t, json.Unmarshal(out, &report))
+	if assert.Len(t, report.Results, 2) {
+		assert.Equal(t, secretSinkWritten, report.Results[0].Action)
+		assert.Equal(t, "not found", report.Results[1].Error)
+	}
+	b, _ := os.ReadFile(filepath.Join(dir, "one.json"))
+	assert.JSONEq(t, `{"a":"b"}`, string(b))
+}
+
+func TestRunSecretSync_TokenRefresh(t *testing.T) {
+	dir := t.TempDir()
+	viper.Reset()
+	defer viper.Reset()
+	viper.Set(cst.NounToken, "expired-token")
+
+	// Every iteration asks for a token, the authenticator returns a new one once the old one expired.
+	tokens := []string{"token-1", "token-1", "token-2"}
+	authenticator := &fake.FakeAuthenticator{}
+	authenticator.GetTokenStub = func() (*auth.TokenResponse, *errors.ApiError) {
+		if len(tokens) == 0 {
+			return nil, errors.NewS("refresh token expired")
+		}
+		tr := &auth.TokenResponse{Token: tokens[0], ExpiresIn: 3600, Granted: time.Now()}
+		tokens = tokens[1:]
+		return tr, nil
+	}
+	var used []string
+	httpClient := &fake.FakeClient{}
+	httpClient.DoRequestStub = func(method string, uri string, body interface{}) ([]byte, *errors.ApiError) {
+		if strings.Contains(uri, cst.SuffixDescription) {
+			used = append(used, viper.GetString(cst.NounToken))
+		}
+		return []byte(`{"version":"1","data":{"a":"b"}}`), nil
+	}
+	vcli, err := vaultcli.NewWithOpts(vaultcli.WithHTTPClient(httpClient), vaultcli.WithAuthenticator(authenticator))
+	if err != nil {
+		t.Fatalf("Unexpected error during vaultCLI init: %v", err)
+	}
+
+	sink := &secretSink{Path: "app/one", Dest: filepath.Join(dir, "one.json")}
+	assert.NoError(t, sink.init())
+	for i := 0; i < 4; i++ {
+		runSecretSync(vcli, []*secretSink{sink})
+	}
+	assert.Equal(t, []string{"token-1", "token-1", "token-2"}, used, "sinks must not be synced without a valid token")
+	assert.Equal(t, 4, authenticator.GetTokenCallCount())
+}
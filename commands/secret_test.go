@@ -2,11 +2,13 @@ package cmd
 
 import (
 	"fmt"
+	"strings"
 	"testing"
 	"time"
 
 	cst "github.com/DelineaXPM/dsv-cli/constants"
 	"github.com/DelineaXPM/dsv-cli/errors"
+	"github.com/DelineaXPM/dsv-cli/internal/store"
 	"github.com/DelineaXPM/dsv-cli/tests/fake"
 	"github.com/DelineaXPM/dsv-cli/vaultcli"
 
@@ -900,3 +902,57 @@ func TestHandleSecretEditCmd(t *testing.T) {
 		})
 	}
 }
+
+func TestGetSecretRevalidate(t *testing.T) {
+	secret := `{"version":"1","data":{"certificate":"large"}}`
+	desc := `{"version":"1"}`
+	down := false
+	var requests []string
+	httpClient := &fake.FakeClient{}
+	httpClient.DoRequestStub = func(method string, uri string, body interface{}) ([]byte, *errors.ApiError) {
+		requests = append(requests, strings.SplitN(uri, "?", 2)[0])
+		if down {
+			return nil, errors.NewS("connection refused")
+		}
+		if strings.Contains(uri, cst.SuffixDescription) {
+			return []byte(desc), nil
+		}
+		return []byte(secret), nil
+	}
+	vcli, err := vaultcli.NewWithOpts(
+		vaultcli.WithHTTPClient(httpClient),
+		vaultcli.WithStore(store.NewFileStore(t.TempDir())),
+	)
+	if err != nil {
+		t.Fatalf("Unexpected error during vaultCLI init: %v", err)
+	}
+
+	viper.Reset()
+	defer viper.Reset()
+	viper.Set(cst.Tenant, "tenant")
+	viper.Set(cst.StoreType, store.File)
+	viper.Set(cst.CacheStrategy, cst.CacheStrategyRevalidate)
+	viper.Set(cst.CacheAge, 5)
+
+	read := func() string {
+		t.Helper()
+		requests = nil
+		data, apiErr := getSecret(vcli, cst.NounSecret, "certs/web", "", "")
+		assert.Nil(t, apiErr)
+		return string(data)
+	}
+
+	assert.Equal(t, secret, read())
+	assert.Len(t, requests, 1)
+
+	assert.Equal(t, secret, read())
+	assert.Len(t, requests, 1, "an unchanged secret must be revalidated with the description only")
+	assert.Contains(t, requests[0], cst.SuffixDescription)
+
+	secret, desc = `{"version":"2","data":{"certificate":"renewed"}}`, `{"version":"2"}`
+	assert.Equal(t, secret, read())
+	assert.Len(t, requests, 2)
+
+	down = true
+	assert.Equal(t, secret, read(), "the cached secret is used while the server is unreachable")
+}
@@ -7,6 +7,7 @@ import (
 
 	cst "github.com/DelineaXPM/dsv-cli/constants"
 	"github.com/DelineaXPM/dsv-cli/errors"
+	"github.com/DelineaXPM/dsv-cli/format"
 	"github.com/DelineaXPM/dsv-cli/internal/predictor"
 	"github.com/DelineaXPM/dsv-cli/paths"
 	"github.com/DelineaXPM/dsv-cli/utils"
@@ -111,12 +112,12 @@ func GetSiemSearchCmd() (cli.Command, error) {
    • %[1]s %[2]s %[3]s
    • %[1]s %[2]s --query %[3]s
 `, cst.NounSiem, cst.Search, cst.ExampleSiemSearch),
-		FlagsPredictor: []*predictor.Params{
+		FlagsPredictor: append([]*predictor.Params{
 			{Name: cst.Query, Shorthand: "q", Usage: fmt.Sprintf("Filter %s of items to fetch (required)", cst.Query)},
 			{Name: cst.Limit, Shorthand: "l", Usage: cst.LimitHelpMessage},
-			{Name: cst.Cursor, Usage: cst.CursorHelpMessage},
-		},
-		RunFunc: handleSiemSearchCmd,
+		}, paginationFlags()...),
+		Encodings: format.ListEncodings,
+		RunFunc:   handleSiemSearchCmd,
 	})
 }
 
@@ -128,8 +129,14 @@ func handleSiemSearchCmd(vcli vaultcli.CLI, args []string) int {
 		query = args[0]
 	}
 
-	data, apiErr := siemSearch(vcli, &siemSearchParams{query: query, limit: limit, cursor: cursor})
-	vcli.Out().WriteResponse(data, apiErr)
+	params := &siemSearchParams{query: query, limit: limit}
+	apiErr := writePages(vcli, cursor, func(cursor string) ([]byte, *errors.ApiError) {
+		params.cursor = cursor
+		return siemSearch(vcli, params)
+	})
+	if apiErr != nil {
+		vcli.Out().WriteResponse(nil, apiErr)
+	}
 	return utils.GetExecStatus(apiErr)
 }
 
@@ -0,0 +1,289 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/DelineaXPM/dsv-cli/auth"
+	cst "github.com/DelineaXPM/dsv-cli/constants"
+	"github.com/DelineaXPM/dsv-cli/errors"
+	"github.com/DelineaXPM/dsv-cli/internal/predictor"
+	"github.com/DelineaXPM/dsv-cli/internal/store"
+	"github.com/DelineaXPM/dsv-cli/vaultcli"
+
+	"github.com/mitchellh/cli"
+	"github.com/spf13/viper"
+)
+
+const securePasswordSetting = "securePassword"
+
+func GetStoreCmd() (cli.Command, error) {
+	return NewCommand(CommandArgs{
+		Path:         []string{cst.NounStore},
+		SynopsisText: "Manage the local store",
+		HelpText:     "Execute an action on the local store which keeps tokens, cached secrets and secure settings",
+		NoPreAuth:    true,
+	})
+}
+
+func GetStoreMigrateCmd() (cli.Command, error) {
+	return NewCommand(CommandArgs{
+		Path:         []string{cst.NounStore, cst.Migrate},
+		SynopsisText: fmt.Sprintf("%s %s --to <store type> [--from <store type>] [--wipe] [--dry-run]", cst.NounStore, cst.Migrate),
+		HelpText: fmt.Sprintf(`Move tokens, cached %[1]ss and secure settings from one store type to another
+
+Every entry is read back from the new store to verify it. Entries which only make sense in a file
+store, such as password encryption key files, are skipped.
+
+If the profile uses the source store type, its store.type is switched to the target store type.
+The password and the client secret of the profile are moved along: secure stores (%[2]s, %[3]s)
+keep them as store entries, file stores keep them in the configuration file.
+
+Entries of all profiles are copied. --wipe is refused while another profile in the configuration
+file still uses the source store, migrate those profiles first.
+
+Usage:
+   • %[4]s %[5]s --from %[6]s --to %[2]s
+   • %[4]s %[5]s --to %[3]s --profile staging --wipe
+   • %[4]s %[5]s --to %[6]s --dry-run
+`, cst.NounSecret, store.PassLinux, store.WinCred, cst.NounStore, cst.Migrate, store.File),
+		FlagsPredictor: []*predictor.Params{
+			{Name: cst.From, Usage: "Store type to move data from (default: store.type of the profile)"},
+			{Name: cst.To, Usage: fmt.Sprintf("Store type to move data to: %s, %s, %s or %s (required)", store.File, store.FileEncrypted, store.PassLinux, store.WinCred)},
+			{Name: cst.Wipe, Usage: "Delete migrated entries from the source store", ValueType: "bool"},
+			{Name: cst.DryRun, Usage: "Only report what would be migrated", ValueType: "bool"},
+		},
+		NoPreAuth: true,
+		RunFuncE:  handleStoreMigrateCmd,
+	})
+}
+
+func handleStoreMigrateCmd(vcli vaultcli.CLI, args []string) error {
+	profile := viper.GetString(cst.Profile)
+	if profile == "" {
+		profile = cst.DefaultProfile
+	}
+	current := viper.GetString(cst.StoreType)
+	if current == store.Unset {
+		current = store.File
+	}
+	from := viper.GetString(cst.From)
+	if from == "" {
+		from = current
+	}
+	to := viper.GetString(cst.To)
+	if to == "" {
+		return errors.NewF("error: must specify --%s", cst.To)
+	}
+	if err := validateStoreMigration(from, to); err != nil {
+		return err
+	}
+
+	src, err := store.New(from)
+	if err != nil {
+		return errors.New(err)
+	}
+	dst, err := store.New(to)
+	if err != nil {
+		return errors.New(err)
+	}
+
+	wipe := viper.GetBool(cst.Wipe)
+	var cf *vaultcli.ConfigFile
+	var prf *vaultcli.Profile
+	if from == current || wipe {
+		cf, err = vaultcli.ReadConfigFile(viper.GetString(cst.Config))
+		if err != nil {
+			return errors.NewF("error: failed to read config: %v", err)
+		}
+	}
+	if wipe {
+		if others := profilesUsingStore(cf, from, profile); len(others) > 0 {
+			return errors.NewF("error: cannot --%s the %s store, it is still used by profile(s) %s; migrate them first or run without --%s",
+				cst.Wipe, from, strings.Join(others, ", "), cst.Wipe)
+		}
+	}
+	if from == current {
+		var ok bool
+		if prf, ok = cf.GetProfile(profile); !ok {
+			return errors.NewF("error: profile %q not found in configuration file %q", profile, cf.GetPath())
+		}
+	}
+
+	dryRun := viper.GetBool(cst.DryRun)
+	resp, err := migrateStore(src, dst, from, to, prf, dryRun, wipe)
+	if err != nil {
+		return err
+	}
+	if prf != nil && !dryRun {
+		cf.SetProfile(prf)
+		if err := cf.Save(); err != nil {
+			return errors.NewF("error: entries are migrated, but the configuration cannot be saved: %v", err)
+		}
+	}
+
+	data, err := json.Marshal(resp)
+	if err != nil {
+		return err
+	}
+	vcli.Out().WriteResponse(data, nil)
+	return nil
+}
+
+func validateStoreMigration(from string, to string) error {
+	for _, st := range []string{from, to} {
+		if st == store.None {
+			return errors.NewF("error: cannot migrate from or to store type %q", st)
+		}
+		if err := store.ValidateStoreType(st); err != nil {
+			return errors.New(err)
+		}
+	}
+	if from == to {
+		return errors.NewF("error: --%s and --%s must be different store types", cst.From, cst.To)
+	}
+	if !store.IsSecure(from) && !store.IsSecure(to) {
+		return errors.NewF("error: %s and %s stores share store.path, set store.type with %s %s instead; the %s store encrypts existing entries when it is used first",
+			store.File, store.FileEncrypted, cst.NounCliConfig, cst.Update, store.FileEncrypted)
+	}
+	return nil
+}
+
+// profilesUsingStore returns names of profiles other than except which keep their entries in the store
+// of type storeType. File and encrypted file stores share store.path, so they count as one store.
+func profilesUsingStore(cf *vaultcli.ConfigFile, storeType string, except string) []string {
+	normalize := func(st string) string {
+		if st == store.Unset || st == store.FileEncrypted {
+			return store.File
+		}
+		return st
+	}
+	var names []string
+	for _, p := range cf.ListProfiles() {
+		if p.Name != except && normalize(p.Get(cst.Store, cst.Type)) == normalize(storeType) {
+			names = append(names, p.Name)
+		}
+	}
+	return names
+}
+
+// storeMigration is the result of a store migration.
+type storeMigration struct {
+	From           string   `json:"from"`
+	To             string   `json:"to"`
+	DryRun         bool     `json:"dryRun"`
+	Migrated       []string `json:"migrated"`
+	Skipped        []string `json:"skipped"`
+	Settings       []string `json:"settings"`
+	ProfileUpdated bool     `json:"profileUpdated"`
+	Wiped          bool     `json:"wiped"`
+}
+
+// migrateStore copies entries from src to dst. If prf is not nil, the profile is switched to the target store type.
+func migrateStore(src, dst store.Store, from, to string, prf *vaultcli.Profile, dryRun bool, wipe bool) (*storeMigration, error) {
+	resp := &storeMigration{From: from, To: to, DryRun: dryRun, Settings: []string{}}
+
+	// Secure settings are never written to file stores, which keep them in the configuration file.
+	skip := func(key string) bool {
+		return !store.IsSecure(to) && strings.HasPrefix(key, cst.CliConfigRoot+"-")
+	}
+	res, err := store.Migrate(src, dst, skip, dryRun)
+	if err != nil {
+		return nil, errors.New(err)
+	}
+	resp.Migrated = res.Migrated
+	resp.Skipped = res.Skipped
+
+	wipeKeys := res.Migrated
+	if prf != nil {
+		settingKeys, err := migrateSecureSettings(prf, src, dst, to, dryRun, resp)
+		if err != nil {
+			return nil, err
+		}
+		wipeKeys = append(wipeKeys, settingKeys...)
+		if !dryRun {
+			prf.Set(to, cst.Store, cst.Type)
+		}
+		resp.ProfileUpdated = true
+	}
+
+	if wipe && !dryRun {
+		for _, key := range wipeKeys {
+			if err := src.Delete(key); err != nil {
+				return nil, errors.NewF("error: failed to delete '%s' from the %s store: %v", key, from, err)
+			}
+		}
+		resp.Wiped = true
+	}
+	return resp, nil
+}
+
+// migrateSecureSettings moves the password and the client secret of the profile between the configuration
+// file and a secure store. It returns the keys of source store entries which are not needed anymore.
+func migrateSecureSettings(prf *vaultcli.Profile, src, dst store.Store, to string, dryRun bool, resp *storeMigration) ([]string, error) {
+	passwordKey := store.SecureSettingKey(prf.Name + "." + cst.Password)
+	clientSecretKey := store.SecureSettingKey(prf.Name + "." + cst.AuthClientSecret)
+	keyFile := auth.GetEncryptionKeyFilename(prf.Get(cst.Tenant), prf.Get(cst.NounAuth, cst.DataUsername))
+
+	if store.IsSecure(to) {
+		if encrypted := prf.Get(cst.NounAuth, securePasswordSetting); encrypted != "" {
+			key, err := store.ReadFileInDefaultPath(keyFile)
+			if err != nil || key == "" {
+				return nil, auth.KeyfileNotFoundError
+			}
+			password, err := auth.Decrypt(encrypted, key)
+			if err != nil {
+				return nil, errors.NewS("error: failed to decrypt the password with key")
+			}
+			if !dryRun {
+				if err := dst.Store(passwordKey, password); err != nil {
+					return nil, errors.New(err)
+				}
+				prf.Del(cst.NounAuth, securePasswordSetting)
+			}
+			resp.Settings = append(resp.Settings, cst.Password)
+		}
+		if secret := prf.Get(cst.NounAuth, cst.NounClient, cst.NounSecret); secret != "" {
+			if !dryRun {
+				if err := dst.Store(clientSecretKey, secret); err != nil {
+					return nil, errors.New(err)
+				}
+				prf.Del(cst.NounAuth, cst.NounClient, cst.NounSecret)
+			}
+			resp.Settings = append(resp.Settings, cst.AuthClientSecret)
+		}
+		return nil, nil
+	}
+
+	var keys []string
+	var password, secret string
+	if err := src.Get(passwordKey, &password); err != nil {
+		return nil, errors.New(err)
+	}
+	if password != "" {
+		if !dryRun {
+			encrypted, key, err := auth.StorePassword(keyFile, password)
+			if err != nil {
+				return nil, errors.New(err)
+			}
+			if err := dst.StoreString(keyFile, key); err != nil {
+				return nil, errors.New(err)
+			}
+			prf.Set(encrypted, cst.NounAuth, securePasswordSetting)
+		}
+		resp.Settings = append(resp.Settings, cst.Password)
+		keys = append(keys, passwordKey)
+	}
+	if err := src.Get(clientSecretKey, &secret); err != nil {
+		return nil, errors.New(err)
+	}
+	if secret != "" {
+		if !dryRun {
+			prf.Set(secret, cst.NounAuth, cst.NounClient, cst.NounSecret)
+		}
+		resp.Settings = append(resp.Settings, cst.AuthClientSecret)
+		keys = append(keys, clientSecretKey)
+	}
+	return keys, nil
+}
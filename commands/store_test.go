@@ -0,0 +1,117 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/DelineaXPM/dsv-cli/auth"
+	cst "github.com/DelineaXPM/dsv-cli/constants"
+	"github.com/DelineaXPM/dsv-cli/internal/store"
+	"github.com/DelineaXPM/dsv-cli/vaultcli"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetStoreCmd(t *testing.T) {
+	_, err := GetStoreCmd()
+	assert.Nil(t, err)
+	_, err = GetStoreMigrateCmd()
+	assert.Nil(t, err)
+}
+
+func TestValidateStoreMigration(t *testing.T) {
+	assert.ErrorContains(t, validateStoreMigration(store.File, store.None), `cannot migrate from or to store type "none"`)
+	assert.ErrorContains(t, validateStoreMigration(store.File, "vault"), "'vault' key store not supported")
+	assert.ErrorContains(t, validateStoreMigration(store.File, store.File), "must be different store types")
+	assert.ErrorContains(t, validateStoreMigration(store.File, store.FileEncrypted), "share store.path")
+}
+
+func TestMigrateStore(t *testing.T) {
+	viper.Reset()
+	defer viper.Reset()
+
+	fileDir := t.TempDir()
+	viper.Set(cst.StoreType, store.File)
+	viper.Set(cst.StorePath, fileDir)
+
+	prf := vaultcli.NewProfile("default")
+	prf.Set("tenant", cst.Tenant)
+	prf.Set("user", cst.NounAuth, cst.DataUsername)
+	prf.Set("client-secret", cst.NounAuth, cst.NounClient, cst.NounSecret)
+	prf.Set(store.File, cst.Store, cst.Type)
+
+	keyFile := auth.GetEncryptionKeyFilename("tenant", "user")
+	encrypted, key, err := auth.StorePassword(keyFile, "p@ssw0rd")
+	assert.NoError(t, err)
+	prf.Set(encrypted, cst.NounAuth, securePasswordSetting)
+
+	fileStore := store.NewFileStore(fileDir)
+	assert.NoError(t, fileStore.StoreString(keyFile, key))
+	assert.NoError(t, fileStore.Store("token-password-tenant-default", map[string]string{"accessToken": "aaa"}))
+
+	// A file store stands in for the secure store, since only the store type decides how settings are moved.
+	secureStore := store.NewFileStore(t.TempDir())
+
+	resp, err := migrateStore(fileStore, secureStore, store.File, store.PassLinux, prf, true, true)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"token-password-tenant-default"}, resp.Migrated)
+	assert.Equal(t, []string{keyFile}, resp.Skipped)
+	assert.Equal(t, []string{cst.Password, cst.AuthClientSecret}, resp.Settings)
+	assert.False(t, resp.Wiped)
+	assert.Equal(t, store.File, prf.Get(cst.Store, cst.Type))
+	keys, _ := secureStore.List("")
+	assert.Empty(t, keys)
+
+	resp, err = migrateStore(fileStore, secureStore, store.File, store.PassLinux, prf, false, true)
+	assert.NoError(t, err)
+	assert.True(t, resp.ProfileUpdated)
+	assert.True(t, resp.Wiped)
+	assert.Equal(t, store.PassLinux, prf.Get(cst.Store, cst.Type))
+	assert.Empty(t, prf.Get(cst.NounAuth, securePasswordSetting))
+	assert.Empty(t, prf.Get(cst.NounAuth, cst.NounClient, cst.NounSecret))
+	var password string
+	assert.NoError(t, secureStore.Get("config-default-auth-password", &password))
+	assert.Equal(t, "p@ssw0rd", password)
+	keys, _ = fileStore.List("")
+	assert.Equal(t, []string{keyFile}, keys)
+
+	// And back to a file store in another directory.
+	newFileDir := t.TempDir()
+	viper.Set(cst.StoreType, store.PassLinux)
+	viper.Set(cst.StorePath, newFileDir)
+	newFileStore := store.NewFileStore(newFileDir)
+
+	resp, err = migrateStore(secureStore, newFileStore, store.PassLinux, store.File, prf, false, false)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"token-password-tenant-default"}, resp.Migrated)
+	assert.Equal(t, []string{"config-default-auth-client-secret", "config-default-auth-password"}, resp.Skipped)
+	assert.Equal(t, []string{cst.Password, cst.AuthClientSecret}, resp.Settings)
+	assert.Equal(t, store.File, prf.Get(cst.Store, cst.Type))
+	assert.Equal(t, "client-secret", prf.Get(cst.NounAuth, cst.NounClient, cst.NounSecret))
+	newKey, err := os.ReadFile(filepath.Join(newFileDir, keyFile))
+	assert.NoError(t, err)
+	decrypted, err := auth.Decrypt(prf.Get(cst.NounAuth, securePasswordSetting), string(newKey))
+	assert.NoError(t, err)
+	assert.Equal(t, "p@ssw0rd", decrypted)
+	keys, _ = secureStore.List("")
+	assert.Len(t, keys, 3)
+}
+
+func TestProfilesUsingStore(t *testing.T) {
+	cf, err := vaultcli.NewConfigFile(filepath.Join(t.TempDir(), "config.yml"))
+	assert.NoError(t, err)
+	for name, st := range map[string]string{"default": store.File, "staging": store.Unset, "prod": store.FileEncrypted, "ci": store.PassLinux} {
+		prf := vaultcli.NewProfile(name)
+		if st != store.Unset {
+			prf.Set(st, cst.Store, cst.Type)
+		}
+		cf.SetProfile(prf)
+	}
+
+	assert.Equal(t, []string{"prod", "staging"}, profilesUsingStore(cf, store.File, "default"))
+	assert.Equal(t, []string{"default", "prod"}, profilesUsingStore(cf, store.Unset, "staging"))
+	assert.Empty(t, profilesUsingStore(cf, store.PassLinux, "ci"))
+	assert.Equal(t, []string{"ci"}, profilesUsingStore(cf, store.PassLinux, "default"))
+}
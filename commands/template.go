@@ -0,0 +1,246 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+
+	cst "github.com/DelineaXPM/dsv-cli/constants"
+	"github.com/DelineaXPM/dsv-cli/errors"
+	"github.com/DelineaXPM/dsv-cli/format"
+	"github.com/DelineaXPM/dsv-cli/internal/predictor"
+	"github.com/DelineaXPM/dsv-cli/utils"
+	"github.com/DelineaXPM/dsv-cli/vaultcli"
+
+	"github.com/mitchellh/cli"
+	"github.com/spf13/viper"
+)
+
+func GetTemplateCmd() (cli.Command, error) {
+	return NewCommand(CommandArgs{
+		Path:         []string{cst.NounTemplate},
+		SynopsisText: "Render templates with secret placeholders",
+		HelpText: `Work with templates which reference secrets
+
+Usage:
+   • template render --input app.conf.tmpl --out app.conf
+`,
+		NoConfigRead: true,
+		NoPreAuth:    true,
+	})
+}
+
+func GetTemplateRenderCmd() (cli.Command, error) {
+	return NewCommand(CommandArgs{
+		Path:         []string{cst.NounTemplate, cst.Render},
+		SynopsisText: fmt.Sprintf("%s %s (<file> | --input <file>) [--out <file>] [--check]", cst.NounTemplate, cst.Render),
+		HelpText: `Render a Go text/template which references secrets
+
+The following functions are available in the template:
+   • secret <path> [key...]  returns a value from the data of a secret. Without a key the whole data object is returned.
+   • home <path> [key...]    same as "secret" for a secret in the Home Vault.
+Several keys walk into nested data objects. Each referenced secret is fetched once per render.
+
+The rendered file is written with 0600 permissions. Without --out the result is written to stdout.
+With --check all references are resolved and reported, but nothing is written.
+
+Template example:
+   password = {{ secret "databases/mongo-db01" "password" }}
+   token    = {{ home "notes" "token" }}
+   {{ range $k, $v := secret "databases/mongo-db01" }}{{ $k }}={{ $v }}
+   {{ end }}
+
+Usage:
+   • template render --input app.conf.tmpl --out app.conf
+   • template render app.conf.tmpl --check
+`,
+		FlagsPredictor: []*predictor.Params{
+			{Name: cst.Input, Usage: "Path to the template file (required)", Predictor: predictor.NewPrefixFilePredictor("*")},
+			{Name: cst.Check, Usage: "Only report unresolved references, do not write anything", ValueType: "bool"},
+		},
+		MinNumberArgs: 1,
+		RunFuncE:      handleTemplateRenderCmd,
+	})
+}
+
+func handleTemplateRenderCmd(vcli vaultcli.CLI, args []string) error {
+	input := viper.GetString(cst.Input)
+	if input == "" && len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		input = args[0]
+	}
+	if input == "" {
+		return errors.NewF("error: must specify --%s", cst.Input)
+	}
+	check := viper.GetBool(cst.Check)
+
+	dest, err := outputFilePath(viper.GetString(cst.Output))
+	if err != nil {
+		return err
+	}
+	// The rendered template is written by this command itself,
+	// the output client is used only for messages and reports.
+	viper.Set(cst.Output, format.OutToStdout)
+
+	src, err := os.ReadFile(input)
+	if err != nil {
+		return errors.New(err).Grow("Failed to read template")
+	}
+
+	r := newTemplateRenderer(vcli)
+	rendered, err := r.render(filepath.Base(input), string(src))
+	if err != nil {
+		return err
+	}
+
+	if check {
+		report, err := json.Marshal(map[string]interface{}{
+			"references": r.references(),
+			"unresolved": r.unresolved,
+		})
+		if err != nil {
+			return err
+		}
+		vcli.Out().WriteResponse(report, nil)
+		if len(r.unresolved) > 0 {
+			return errors.NewF("%d unresolved reference(s)", len(r.unresolved))
+		}
+		return nil
+	}
+
+	if len(r.unresolved) > 0 {
+		return errors.NewF("Unresolved references:\n   • %s", strings.Join(r.unresolved, "\n   • "))
+	}
+
+	if dest == "" {
+		_, err = os.Stdout.Write(rendered)
+		return err
+	}
+	if err := utils.WriteFileAtomic(dest, rendered, 0o600); err != nil {
+		return errors.New(err).Grow("Failed to write rendered template")
+	}
+	return nil
+}
+
+type templateSecret struct {
+	data map[string]interface{}
+	err  error
+}
+
+type templateRenderer struct {
+	vcli       vaultcli.CLI
+	secrets    map[string]*templateSecret
+	refs       map[string]struct{}
+	unresolved []string
+}
+
+func newTemplateRenderer(vcli vaultcli.CLI) *templateRenderer {
+	return &templateRenderer{
+		vcli:    vcli,
+		secrets: make(map[string]*templateSecret),
+		refs:    make(map[string]struct{}),
+	}
+}
+
+func (r *templateRenderer) render(name string, src string) ([]byte, error) {
+	funcs := template.FuncMap{
+		"secret": func(path string, keys ...string) interface{} {
+			return r.lookup(cst.NounSecret, path, keys)
+		},
+		"home": func(path string, keys ...string) interface{} {
+			return r.lookup(cst.NounHome, path, keys)
+		},
+	}
+	t, err := template.New(name).Funcs(funcs).Parse(src)
+	if err != nil {
+		return nil, errors.New(err).Grow("Failed to parse template")
+	}
+	var b bytes.Buffer
+	if err := t.Execute(&b, nil); err != nil {
+		return nil, errors.New(err).Grow("Failed to render template")
+	}
+	return b.Bytes(), nil
+}
+
+// references returns a sorted list of all references found in the template.
+func (r *templateRenderer) references() []string {
+	refs := make([]string, 0, len(r.refs))
+	for ref := range r.refs {
+		refs = append(refs, ref)
+	}
+	sort.Strings(refs)
+	return refs
+}
+
+// lookup never fails so that all unresolved references are collected during a single render.
+func (r *templateRenderer) lookup(secretType string, path string, keys []string) interface{} {
+	ref := strings.Join(append([]string{secretType, path}, keys...), " ")
+	r.refs[ref] = struct{}{}
+
+	s := r.fetch(secretType, path)
+	if s.err != nil {
+		r.unresolve(ref, s.err.Error())
+		return ""
+	}
+
+	var val interface{} = s.data
+	for _, key := range keys {
+		m, ok := val.(map[string]interface{})
+		if !ok {
+			r.unresolve(ref, fmt.Sprintf("%q is not an object", key))
+			return ""
+		}
+		if val, ok = m[key]; !ok {
+			r.unresolve(ref, fmt.Sprintf("key %q not found", key))
+			return ""
+		}
+	}
+	return val
+}
+
+func (r *templateRenderer) unresolve(ref string, reason string) {
+	msg := fmt.Sprintf("%s: %s", ref, reason)
+	for _, u := range r.unresolved {
+		if u == msg {
+			return
+		}
+	}
+	r.unresolved = append(r.unresolved, msg)
+}
+
+func (r *templateRenderer) fetch(secretType string, path string) *templateSecret {
+	cacheKey := secretType + " " + path
+	if s, ok := r.secrets[cacheKey]; ok {
+		return s
+	}
+
+	s := &templateSecret{}
+	r.secrets[cacheKey] = s
+
+	resp, apiErr := getSecret(r.vcli, secretType, path, "", "")
+	if apiErr != nil {
+		if httpResp := apiErr.HttpResponse(); httpResp != nil {
+			s.err = fmt.Errorf("%s", httpResp.Status)
+		} else {
+			s.err = fmt.Errorf("%s", strings.ReplaceAll(apiErr.Error(), "\n", "; "))
+		}
+		return s
+	}
+
+	secret := struct {
+		Data map[string]interface{} `json:"data"`
+	}{}
+	dec := json.NewDecoder(bytes.NewReader(resp))
+	// Keep numbers as they are, otherwise large integers are rendered in exponent notation.
+	dec.UseNumber()
+	if err := dec.Decode(&secret); err != nil {
+		s.err = fmt.Errorf("failed to parse %s: %w", cst.NounSecret, err)
+		return s
+	}
+	s.data = secret.Data
+	return s
+}
@@ -0,0 +1,124 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	cst "github.com/DelineaXPM/dsv-cli/constants"
+	"github.com/DelineaXPM/dsv-cli/errors"
+	"github.com/DelineaXPM/dsv-cli/tests/fake"
+	"github.com/DelineaXPM/dsv-cli/vaultcli"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetTemplateCmd(t *testing.T) {
+	_, err := GetTemplateCmd()
+	assert.Nil(t, err)
+}
+
+func TestGetTemplateRenderCmd(t *testing.T) {
+	_, err := GetTemplateRenderCmd()
+	assert.Nil(t, err)
+}
+
+func newTemplateTestCLI(t *testing.T, requests *int, out *[]byte, outErr **errors.ApiError) vaultcli.CLI {
+	t.Helper()
+
+	httpClient := &fake.FakeClient{}
+	httpClient.DoRequestStub = func(method string, uri string, body interface{}) ([]byte, *errors.ApiError) {
+		*requests++
+		if strings.Contains(uri, "/secrets/db/prod?") {
+			return []byte(`{"data":{"password":"p@ss","port":5432,"big":12345678901,"nested":{"user":"admin"}}}`), nil
+		}
+		return nil, errors.NewS("not found")
+	}
+
+	outClient := &fake.FakeOutClient{}
+	outClient.WriteResponseStub = func(data []byte, apiError *errors.ApiError) {
+		*out = data
+		*outErr = apiError
+	}
+
+	vcli, err := vaultcli.NewWithOpts(
+		vaultcli.WithHTTPClient(httpClient),
+		vaultcli.WithOutClient(outClient),
+	)
+	if err != nil {
+		t.Fatalf("Unexpected error during vaultCLI init: %v", err)
+	}
+	return vcli
+}
+
+func TestTemplateRenderer(t *testing.T) {
+	var requests int
+	var out []byte
+	var outErr *errors.ApiError
+	vcli := newTemplateTestCLI(t, &requests, &out, &outErr)
+
+	viper.Reset()
+
+	r := newTemplateRenderer(vcli)
+	rendered, err := r.render("test", `{{ secret "db/prod" "password" }}:{{ secret "db/prod" "port" }}:{{ secret "db/prod" "big" }}:{{ secret "db/prod" "nested" "user" }}`)
+	assert.NoError(t, err)
+	assert.Equal(t, "p@ss:5432:12345678901:admin", string(rendered))
+	assert.Empty(t, r.unresolved)
+	assert.Equal(t, 1, requests, "secret must be fetched only once")
+
+	requests = 0
+	r = newTemplateRenderer(vcli)
+	_, err = r.render("test", `{{ secret "db/prod" "missing" }}{{ secret "other" "key" }}{{ secret "other" "key2" }}`)
+	assert.NoError(t, err)
+	assert.Len(t, r.unresolved, 3)
+	assert.Equal(t, 2, requests)
+
+	r = newTemplateRenderer(vcli)
+	_, err = r.render("test", `{{ secret "db/prod" `)
+	assert.Error(t, err)
+}
+
+func TestHandleTemplateRenderCmd(t *testing.T) {
+	dir := t.TempDir()
+	input := filepath.Join(dir, "app.conf.tmpl")
+	output := filepath.Join(dir, "app.conf")
+
+	var requests int
+	var out []byte
+	var outErr *errors.ApiError
+	vcli := newTemplateTestCLI(t, &requests, &out, &outErr)
+
+	assert.NoError(t, os.WriteFile(input, []byte(`password={{ secret "db/prod" "password" }}`), 0o600))
+
+	viper.Reset()
+	viper.Set(cst.Output, "file:"+output)
+	err := handleTemplateRenderCmd(vcli, []string{input})
+	assert.NoError(t, err)
+
+	b, rerr := os.ReadFile(output)
+	assert.NoError(t, rerr)
+	assert.Equal(t, "password=p@ss", string(b))
+
+	// Check mode must not write the output file.
+	assert.NoError(t, os.WriteFile(input, []byte(`password={{ secret "db/prod" "nope" }}`), 0o600))
+	assert.NoError(t, os.Remove(output))
+
+	viper.Reset()
+	viper.Set(cst.Output, output)
+	viper.Set(cst.Check, true)
+	err = handleTemplateRenderCmd(vcli, []string{input})
+	assert.Error(t, err)
+	assert.Contains(t, string(out), `"unresolved":["secret db/prod nope: key \"nope\" not found"]`)
+	_, statErr := os.Stat(output)
+	assert.True(t, os.IsNotExist(statErr))
+
+	// Unresolved references fail the render.
+	viper.Reset()
+	viper.Set(cst.Output, output)
+	err = handleTemplateRenderCmd(vcli, []string{input})
+	assert.Error(t, err)
+	_, statErr = os.Stat(output)
+	assert.True(t, os.IsNotExist(statErr))
+}
@@ -9,6 +9,7 @@ import (
 
 	cst "github.com/DelineaXPM/dsv-cli/constants"
 	"github.com/DelineaXPM/dsv-cli/errors"
+	"github.com/DelineaXPM/dsv-cli/format"
 	"github.com/DelineaXPM/dsv-cli/internal/predictor"
 	"github.com/DelineaXPM/dsv-cli/paths"
 	"github.com/DelineaXPM/dsv-cli/utils"
@@ -82,14 +83,14 @@ Usage:
    • user search --query adm --limit 10
    • user search --sort asc --sorted-by created
 `,
-		FlagsPredictor: []*predictor.Params{
+		FlagsPredictor: append([]*predictor.Params{
 			{Name: cst.Query, Shorthand: "q", Usage: fmt.Sprintf("%s of %ss to fetch (optional)", strings.Title(cst.Query), cst.NounUser)},
 			{Name: cst.Limit, Shorthand: "l", Usage: cst.LimitHelpMessage},
-			{Name: cst.Cursor, Usage: cst.CursorHelpMessage},
 			{Name: cst.Sort, Usage: cst.SortHelpMessage},
 			{Name: cst.SortedBy, Usage: "Sort by name, created or lastModified field (optional)", Default: "lastModified"},
-		},
-		RunFuncE: handleUserSearchCmd,
+		}, paginationFlags()...),
+		Encodings: format.ListEncodings,
+		RunFuncE:  handleUserSearchCmd,
 	})
 }
 
@@ -204,17 +205,19 @@ func handleUserSearchCmd(vcli vaultcli.CLI, args []string) error {
 		query = args[0]
 	}
 
-	data, apiErr := userSearch(vcli, &userSearchParams{
+	params := &userSearchParams{
 		query:    query,
 		limit:    limit,
-		cursor:   cursor,
 		sort:     sort,
 		sortedBy: sortedBy,
+	}
+	apiErr := writePages(vcli, cursor, func(cursor string) ([]byte, *errors.ApiError) {
+		params.cursor = cursor
+		return userSearch(vcli, params)
 	})
 	if apiErr != nil {
 		return apiErr
 	}
-	vcli.Out().WriteResponse(data, nil)
 	return nil
 }
 
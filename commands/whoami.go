@@ -0,0 +1,208 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/DelineaXPM/dsv-cli/auth"
+	cst "github.com/DelineaXPM/dsv-cli/constants"
+	"github.com/DelineaXPM/dsv-cli/errors"
+	"github.com/DelineaXPM/dsv-cli/vaultcli"
+
+	"github.com/spf13/viper"
+)
+
+// timeClaims are registered JWT claims holding seconds since the Unix epoch.
+var timeClaims = []string{"exp", "iat", "nbf", "auth_time"}
+
+type identityInfo struct {
+	Subject      string                 `json:"subject"`
+	Issuer       string                 `json:"issuer,omitempty"`
+	Tenant       string                 `json:"tenant,omitempty"`
+	AuthType     string                 `json:"authType"`
+	Claims       map[string]interface{} `json:"claims"`
+	Token        *tokenLifetime         `json:"token"`
+	RefreshToken *tokenLifetime         `json:"refreshToken,omitempty"`
+	Groups       []string               `json:"groups"`
+	Policies     []*identityStatement   `json:"policies"`
+	Warnings     []string               `json:"warnings,omitempty"`
+}
+
+type tokenLifetime struct {
+	ExpiresAt string `json:"expiresAt"`
+	ExpiresIn string `json:"expiresIn"`
+	Expired   bool   `json:"expired"`
+}
+
+// identityStatement is a policy statement which subjects match the identity or one of its groups.
+type identityStatement struct {
+	Path      string   `json:"path"`
+	Statement int      `json:"statement"`
+	Subject   string   `json:"subject"`
+	Effect    string   `json:"effect"`
+	Actions   []string `json:"actions"`
+	Resources []string `json:"resources,omitempty"`
+}
+
+func handleWhoAmIDetailedCmd(vcli vaultcli.CLI, args []string) error {
+	tr, apiErr := vcli.Authenticator().GetToken()
+	if apiErr != nil {
+		return apiErr
+	}
+	if tr == nil || tr.Token == "" {
+		return errors.NewS("error: no access token, try re-authenticating")
+	}
+	claims, err := auth.ParseClaimsFromToken(tr.Token)
+	if err != nil {
+		return errors.NewS("Failed to parse the auth token, try re-authenticating")
+	}
+	viper.Set(cst.NounToken, tr.Token)
+
+	authType := viper.GetString(cst.AuthType)
+	if authType == "" {
+		authType = string(auth.Password)
+	}
+	info := &identityInfo{
+		Tenant:   viper.GetString(cst.Tenant),
+		AuthType: authType,
+		Claims:   claims,
+		Token:    newTokenLifetime(tr.ExpiresAt()),
+		Groups:   []string{},
+		Policies: []*identityStatement{},
+	}
+	info.Subject, _ = claims["sub"].(string)
+	info.Issuer, _ = claims["iss"].(string)
+	for _, name := range timeClaims {
+		if v, ok := claims[name].(float64); ok {
+			claims[name] = time.Unix(int64(v), 0).UTC().Format(time.RFC3339)
+		}
+	}
+	if expires := tr.RefreshExpiresAt(); !expires.IsZero() {
+		info.RefreshToken = newTokenLifetime(expires)
+	}
+
+	if username, ok := strings.CutPrefix(info.Subject, cst.NounUsers+":"); ok {
+		groups, apiErr := readIdentityGroups(vcli, username)
+		if apiErr != nil {
+			info.Warnings = append(info.Warnings, fmt.Sprintf("Failed to list groups: %v", apiErr))
+		} else {
+			info.Groups = groups
+		}
+	}
+
+	subjects := []string{info.Subject}
+	for _, g := range info.Groups {
+		subjects = append(subjects, cst.NounGroups+":"+g)
+	}
+	statements, apiErr := readIdentityStatements(vcli, subjects)
+	if apiErr != nil {
+		info.Warnings = append(info.Warnings, fmt.Sprintf("Failed to search policies: %v", apiErr))
+	} else {
+		info.Policies = statements
+	}
+
+	data, err := json.Marshal(info)
+	if err != nil {
+		return err
+	}
+	vcli.Out().WriteResponse(data, nil)
+	return nil
+}
+
+func newTokenLifetime(expires time.Time) *tokenLifetime {
+	remaining := time.Until(expires).Round(time.Second)
+	if remaining < 0 {
+		remaining = 0
+	}
+	return &tokenLifetime{
+		ExpiresAt: expires.UTC().Format(time.RFC3339),
+		ExpiresIn: remaining.String(),
+		Expired:   remaining == 0,
+	}
+}
+
+// readIdentityGroups returns names of all groups of the user.
+func readIdentityGroups(vcli vaultcli.CLI, username string) ([]string, *errors.ApiError) {
+	params := &userGroupsSearchParams{username: username}
+	items, apiErr := readPages(func(cursor string) ([]byte, *errors.ApiError) {
+		params.cursor = cursor
+		return userGroupsRead(vcli, params)
+	})
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	groups := make([]string, 0, len(items))
+	for _, item := range items {
+		var name string
+		if json.Unmarshal(item, &name) != nil {
+			var group struct {
+				GroupName string `json:"groupName"`
+				Name      string `json:"name"`
+			}
+			if err := json.Unmarshal(item, &group); err != nil {
+				return nil, errors.New(err).Grow("Failed to parse groups")
+			}
+			name = group.GroupName
+			if name == "" {
+				name = group.Name
+			}
+		}
+		if name != "" {
+			groups = append(groups, name)
+		}
+	}
+	sort.Strings(groups)
+	return groups, nil
+}
+
+// readIdentityStatements returns statements of all policies which subjects match one of the given subjects.
+func readIdentityStatements(vcli vaultcli.CLI, subjects []string) ([]*identityStatement, *errors.ApiError) {
+	params := &policySearchParams{}
+	items, apiErr := readPages(func(cursor string) ([]byte, *errors.ApiError) {
+		params.cursor = cursor
+		return policySearch(vcli, params)
+	})
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	statements := []*identityStatement{}
+	for _, item := range items {
+		doc, err := parsePolicyDocument(item)
+		if err != nil {
+			return nil, errors.New(err).Grow("Failed to parse policies")
+		}
+		for i, st := range doc.Permissions {
+			if subject := matchingSubject(st.Subjects, subjects); subject != "" {
+				statements = append(statements, &identityStatement{
+					Path:      doc.Path,
+					Statement: i + 1,
+					Subject:   subject,
+					Effect:    strings.ToLower(st.Effect),
+					Actions:   st.Actions,
+					Resources: st.Resources,
+				})
+			}
+		}
+	}
+	sort.SliceStable(statements, func(i, j int) bool {
+		return statements[i].Path < statements[j].Path
+	})
+	return statements, nil
+}
+
+// matchingSubject returns the first subject matched by one of the patterns.
+func matchingSubject(patterns []string, subjects []string) string {
+	for _, s := range subjects {
+		for _, p := range patterns {
+			if ok, err := matchPolicyPattern(p, s); err == nil && ok {
+				return s
+			}
+		}
+	}
+	return ""
+}
@@ -0,0 +1,114 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DelineaXPM/dsv-cli/auth"
+	cst "github.com/DelineaXPM/dsv-cli/constants"
+	"github.com/DelineaXPM/dsv-cli/errors"
+	"github.com/DelineaXPM/dsv-cli/tests/fake"
+	"github.com/DelineaXPM/dsv-cli/vaultcli"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHandleWhoAmIDetailedCmd(t *testing.T) {
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"sub": "users:alice",
+		"iss": "https://mytenant.secretsvaultcloud.com/",
+		"exp": 1767348000,
+	}).SignedString([]byte("key"))
+	assert.NoError(t, err)
+
+	testCases := []struct {
+		name      string
+		groupsErr bool
+		groups    []string
+		policies  []string
+		warnings  int
+	}{
+		{
+			name:     "groups and policies",
+			groups:   []string{"dba", "ops"},
+			policies: []string{"secrets:databases#1 groups:dba", "secrets:databases#3 users:alice", "secrets:prod#1 users:alice"},
+		},
+		{
+			name:      "groups not allowed",
+			groupsErr: true,
+			groups:    []string{},
+			policies:  []string{"secrets:databases#3 users:alice", "secrets:prod#1 users:alice"},
+			warnings:  1,
+		},
+	}
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			viper.Reset()
+			defer viper.Reset()
+			viper.Set(cst.Tenant, "mytenant")
+			viper.Set(cst.Detailed, true)
+
+			authenticator := &fake.FakeAuthenticator{}
+			authenticator.GetTokenStub = func() (*auth.TokenResponse, *errors.ApiError) {
+				return &auth.TokenResponse{Token: token, ExpiresIn: 3600, RefreshToken: "refresh", Granted: time.Now().Add(-time.Hour)}, nil
+			}
+			httpClient := &fake.FakeClient{}
+			httpClient.DoRequestStub = func(method string, uri string, body interface{}) ([]byte, *errors.ApiError) {
+				switch {
+				case strings.Contains(uri, "/users/alice/groups") && tt.groupsErr:
+					return nil, errors.NewS("forbidden").WithResponse(&http.Response{StatusCode: http.StatusForbidden})
+				case strings.Contains(uri, "/users/alice/groups") && !strings.Contains(uri, "cursor"):
+					return []byte(`{"data":[{"groupName":"ops"}],"cursor":"c1"}`), nil
+				case strings.Contains(uri, "/users/alice/groups"):
+					return []byte(`{"data":[{"groupName":"dba"}]}`), nil
+				case strings.Contains(uri, "/config/policies"):
+					return []byte(`{"data":[
+						{"path":"secrets:prod","permissionDocument":[{"subjects":["users:<alice|bob>"],"actions":["read"],"effect":"allow"}]},
+						{"path":"secrets:databases","permissionDocument":[
+							{"subjects":["groups:dba"],"actions":["<.*>"],"effect":"allow","resources":["secrets:databases:<.*>"]},
+							{"subjects":["users:bob"],"actions":["read"],"effect":"allow"},
+							{"subjects":["users:alice"],"actions":["delete"],"effect":"Deny"}
+						]}]}`), nil
+				}
+				return nil, errors.NewS("not found").WithResponse(&http.Response{StatusCode: http.StatusNotFound})
+			}
+
+			var data []byte
+			outClient := &fake.FakeOutClient{}
+			outClient.WriteResponseStub = func(b []byte, apiErr *errors.ApiError) { data = b }
+			vcli, err := vaultcli.NewWithOpts(
+				vaultcli.WithAuthenticator(authenticator),
+				vaultcli.WithHTTPClient(httpClient),
+				vaultcli.WithOutClient(outClient),
+			)
+			assert.NoError(t, err)
+
+			assert.Equal(t, 0, handleWhoAmICmd(vcli, nil))
+			var info identityInfo
+			assert.NoError(t, json.Unmarshal(data, &info))
+			assert.Equal(t, "users:alice", info.Subject)
+			assert.Equal(t, "https://mytenant.secretsvaultcloud.com/", info.Issuer)
+			assert.Equal(t, "mytenant", info.Tenant)
+			assert.Equal(t, "password", info.AuthType)
+			assert.Equal(t, "2026-01-02T10:00:00Z", info.Claims["exp"])
+			assert.True(t, info.Token.Expired)
+			assert.Equal(t, "0s", info.Token.ExpiresIn)
+			assert.False(t, info.RefreshToken.Expired)
+			assert.Equal(t, tt.groups, info.Groups)
+			assert.Len(t, info.Warnings, tt.warnings)
+
+			policies := []string{}
+			for _, p := range info.Policies {
+				policies = append(policies, fmt.Sprintf("%s#%d %s", p.Path, p.Statement, p.Subject))
+			}
+			assert.Equal(t, tt.policies, policies)
+			assert.Equal(t, token, viper.GetString(cst.NounToken))
+		})
+	}
+}
@@ -25,6 +25,25 @@ const (
 	Apply        = "apply"
 	Status       = "status"
 	UseProfile   = "use-profile"
+	Run          = "run"
+	Render       = "render"
+	Start        = "start"
+	Sync         = "sync"
+	Export       = "export"
+	Import       = "import"
+	Plan         = "plan"
+	History      = "history"
+	Diff         = "diff"
+	Copy         = "copy"
+	Move         = "move"
+	Rekey        = "rekey"
+	Inspect      = "inspect"
+	Prefetch     = "prefetch"
+	Purge        = "purge"
+	Stats        = "stats"
+	Migrate      = "migrate"
+	Test         = "test"
+	Lint         = "lint"
 )
 
 // Nouns
@@ -74,6 +93,10 @@ const (
 	NounBYOK            = "byok"
 	NounCert            = "certificate"
 	NounPrivateKey      = "privateKey"
+	NounTemplate        = "template"
+	NounAgent           = "agent"
+	NounCache           = "cache"
+	NounStore           = "store"
 )
 
 // Cli-Config only
@@ -101,6 +124,8 @@ const (
 	Beautify                = "beautify"
 	Plain                   = "plain"
 	Filter                  = "filter"
+	RawOutput               = "raw.output"
+	Template                = "template"
 	Verbose                 = "verbose"
 	Config                  = "config"
 	Dev                     = "dev"
@@ -110,12 +135,16 @@ const (
 	GcpToken                = "auth.gcp.token"
 	GcpServiceAccount       = "auth.gcp.service"
 	GcpAuthType             = "auth.gcp.type"
+	AuthJwtFile             = "auth.jwt.file"
+	AuthJwtEnv              = "auth.jwt.env"
+	AuthJwtReread           = "auth.jwt.reread"
 	AuthClientSecret        = "auth.client.secret"
 	AuthClientID            = "auth.client.id"
 	Callback                = "auth.callback"
 	AuthCert                = "auth.certificate"
 	AuthPrivateKey          = "auth.privateKey"
 	ThyOne                  = "thycoticone"
+	JwtProvider             = "jwt"
 	ThyOneAuthClientBaseUri = "baseUri"
 	ThyOneAuthClientID      = "clientId"
 	ThyOneAuthClientSecret  = "clientSecret"
@@ -141,6 +170,7 @@ const (
 	StartDate         = "startdate"
 	EndDate           = "enddate"
 	Force             = "force"
+	AutoApprove       = "auto-approve"
 	Sort              = "sort"
 	SortedBy          = "sorted-by"
 	NewAdmins         = "new-admins"
@@ -149,6 +179,46 @@ const (
 	SendToEngine      = "send-to-engine"
 	PrimaryKey        = "primary-key"
 	SecondaryKey      = "secondary-key"
+	EnvPrefix         = "env.prefix"
+	EnvTemplate       = "env.template"
+	Input             = "input"
+	Socket            = "socket"
+	Dest              = "dest"
+	Interval          = "interval"
+	Mode              = "mode"
+	Owner             = "owner"
+	OnChange          = "on.change"
+	Once              = "once"
+	Check             = "check"
+	Passphrase        = "passphrase"
+	DryRun            = "dry.run"
+	RewritePrefix     = "rewrite.prefix"
+	File              = "file"
+	From              = "from"
+	To                = "to"
+	ShowValues        = "show.values"
+	AtVersion         = "at.version"
+	Recursive         = "recursive"
+	Workers           = "workers"
+	StopOnError       = "stop.on.error"
+	KeyPrefix         = "key.prefix"
+	KeySeparator      = "key.separator"
+	KeyCase           = "key.case"
+	K8sName           = "k8s.name"
+	K8sNamespace      = "k8s.namespace"
+	NewPassphrase     = "new.passphrase"
+	OlderThan         = "older.than"
+	Expired           = "expired"
+	Wipe              = "wipe"
+	All               = "all"
+	MaxResults        = "max.results"
+	Columns           = "columns"
+	Subject           = "subject"
+	Action            = "action"
+	Resource          = "resource"
+	IP                = "ip"
+	PolicyFile        = "policy.file"
+	Detailed          = "detailed"
 )
 
 // Data Flags
@@ -176,11 +246,15 @@ const (
 	DataPoolName = "pool.name"
 
 	// auth provider
-	DataType      = "type"
-	DataTenantID  = "azure.tenant.id"
-	DataAccountID = "aws.account.id"
-	DataProjectID = "gcp.project.id"
-	DataCallback  = "callback"
+	DataType          = "type"
+	DataTenantID      = "azure.tenant.id"
+	DataAccountID     = "aws.account.id"
+	DataProjectID     = "gcp.project.id"
+	DataCallback      = "callback"
+	DataIssuer        = "jwt.issuer"
+	DataAudience      = "jwt.audience"
+	DataJwksURI       = "jwt.jwks.uri"
+	DataUsernameClaim = "jwt.username.claim"
 
 	// group
 	DataGroupName = "group.name"
@@ -196,9 +270,13 @@ const (
 
 // Security
 const (
-	StoreType = "store.type"
-	Type      = "type"
-	Store     = "store"
+	StoreType        = "store.type"
+	StoreKeySource   = "store.key.source"
+	StoreKeyFile     = "store.key.file"
+	StorePassphrase  = "store.passphrase"
+	StoreLockTimeout = "store.lock.timeout"
+	Type             = "type"
+	Store            = "store"
 )
 
 // Hidden Flags
@@ -211,6 +289,13 @@ const (
 	Yaml      = "yaml"
 	Json      = "json"
 	YamlShort = "yml"
+	Dotenv    = "dotenv"
+	Shell     = "shell"
+	K8s       = "k8s"
+	DockerEnv = "docker-env"
+	NDJson    = "ndjson"
+	Table     = "table"
+	CSV       = "csv"
 )
 
 // Control authentication cache usage.
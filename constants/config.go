@@ -7,4 +7,6 @@ const (
 	DefaultProfile    = "default"
 	DefaultThyOneName = "thy-one"
 	DefaultCallback   = "localhost:8072"
+	AgentSocketEnv    = "DSV_AGENT_SOCK"
+	AgentSocketFile   = "agent.sock"
 )
@@ -18,4 +18,12 @@ const (
 	APIVersionKey        = "api.version"
 	APIVersion           = "v1"
 	PasswordKey          = "password"
+	NetworkTimeout       = "network.timeout"
+	NetworkRetries       = "network.retries"
+	NetworkProxy         = "network.proxy"
+	NetworkNoProxy       = "network.noProxy"
+	TLSCAFile            = "tls.caFile"
+	TLSCertFile          = "tls.certFile"
+	TLSKeyFile           = "tls.keyFile"
+	TLSInsecure          = "tls.insecureSkipVerify"
 )
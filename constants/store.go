@@ -25,4 +25,5 @@ const (
 	CacheStrategyServerThenCache            = "server.cache"
 	CacheStrategyCacheThenServer            = "cache.server"
 	CacheStrategyCacheThenServerThenExpired = "cache.server.expired"
+	CacheStrategyRevalidate                 = "revalidate"
 )
@@ -0,0 +1,49 @@
+package format
+
+import (
+	"sort"
+	"sync"
+)
+
+// Encoder converts JSON data to an output encoding other than JSON and YAML.
+type Encoder interface {
+	Encode(data []byte) ([]byte, error)
+}
+
+// EncoderFunc is an adapter to allow the use of ordinary functions as encoders.
+type EncoderFunc func(data []byte) ([]byte, error)
+
+// Encode calls f(data).
+func (f EncoderFunc) Encode(data []byte) ([]byte, error) { return f(data) }
+
+var (
+	encodersMu sync.RWMutex
+	encoders   = map[string]Encoder{}
+)
+
+// RegisterEncoder makes an encoder available by name for the --encoding flag.
+// Commands opt in to encoders they support, see CommandArgs.Encodings.
+func RegisterEncoder(name string, enc Encoder) {
+	encodersMu.Lock()
+	defer encodersMu.Unlock()
+	encoders[name] = enc
+}
+
+// GetEncoder returns the encoder registered with the name or nil if there is none.
+func GetEncoder(name string) Encoder {
+	encodersMu.RLock()
+	defer encodersMu.RUnlock()
+	return encoders[name]
+}
+
+// EncoderNames returns names of all registered encoders in alphabetical order.
+func EncoderNames() []string {
+	encodersMu.RLock()
+	defer encodersMu.RUnlock()
+	names := make([]string, 0, len(encoders))
+	for name := range encoders {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,127 @@
+package format
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	cst "github.com/DelineaXPM/dsv-cli/constants"
+	"github.com/DelineaXPM/dsv-cli/errors"
+
+	"github.com/itchyny/gojq"
+	"github.com/spf13/viper"
+)
+
+// legacyArrayIndex matches an index or an inclusive range of the dot separated filter syntax
+// supported before filters became jq programs, e.g. "[1]" or "[0:2]".
+var legacyArrayIndex = regexp.MustCompile(`^\s*\[\s*(\d+)(\s*:\s*(\d+))?\s*]\s*$`)
+
+// FilterResponse applies the jq program given with --filter to the data. Every result of the program
+// is written on a separate line. With --raw-output strings are written without quotes.
+func FilterResponse(data []byte) ([]byte, *errors.ApiError) {
+	filter := viper.GetString(cst.Filter)
+	rawOutput := viper.GetBool(cst.RawOutput)
+	if filter == "" {
+		var s string
+		if rawOutput && json.Unmarshal(data, &s) == nil {
+			return []byte(s), nil
+		}
+		return data, nil
+	}
+
+	code, err := compileFilter(filter)
+	if err != nil {
+		return nil, errors.New(err).Grow(fmt.Sprintf("Invalid filter (%s) on data:\n%s", filter, string(data)))
+	}
+
+	var input interface{}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(&input); err != nil {
+		return nil, errors.New(err).Grow("Failed to apply the filter to the data")
+	}
+
+	results := [][]byte{}
+	iter := code.Run(input)
+	for {
+		v, ok := iter.Next()
+		if !ok {
+			break
+		}
+		if err, ok := v.(error); ok {
+			return nil, errors.New(err).Grow("Failed to apply the filter to the data")
+		}
+		if s, ok := v.(string); ok && rawOutput {
+			results = append(results, []byte(s))
+			continue
+		}
+		b, err := gojq.Marshal(v)
+		if err != nil {
+			return nil, errors.New(err).Grow("Failed to apply the filter to the data")
+		}
+		results = append(results, b)
+	}
+	return bytes.Join(results, []byte("\n")), nil
+}
+
+// compileFilter compiles the filter as a jq program. Filters which are not valid jq programs,
+// such as "data.name", are read with the dot separated syntax supported before.
+func compileFilter(filter string) (*gojq.Code, error) {
+	query, err := gojq.Parse(filter)
+	if err == nil {
+		var code *gojq.Code
+		if code, err = gojq.Compile(query); err == nil {
+			return code, nil
+		}
+	}
+	legacy, ok := legacyFilter(filter)
+	if !ok {
+		return nil, err
+	}
+	query, legacyErr := gojq.Parse(legacy)
+	if legacyErr != nil {
+		return nil, err
+	}
+	code, legacyErr := gojq.Compile(query)
+	if legacyErr != nil {
+		return nil, err
+	}
+	return code, nil
+}
+
+// legacyFilter translates a dot separated filter, e.g. "data.items.[0:1].name", to a jq program.
+func legacyFilter(filter string) (string, bool) {
+	var sb strings.Builder
+	sb.WriteString(".")
+	for _, segment := range strings.Split(filter, ".") {
+		key := strings.TrimSpace(segment)
+		if key == "" {
+			continue
+		}
+		if match := legacyArrayIndex.FindStringSubmatch(key); match != nil {
+			if match[3] == "" {
+				fmt.Fprintf(&sb, "[%s]", match[1])
+				continue
+			}
+			// Ranges of the dot separated syntax include the end index.
+			to, err := strconv.Atoi(match[3])
+			if err != nil {
+				return "", false
+			}
+			fmt.Fprintf(&sb, "[%s:%d]", match[1], to+1)
+			continue
+		}
+		if strings.ContainsAny(key, "[]|()\"") {
+			return "", false
+		}
+		quoted, err := json.Marshal(key)
+		if err != nil {
+			return "", false
+		}
+		fmt.Fprintf(&sb, "[%s]", quoted)
+	}
+	return sb.String(), true
+}
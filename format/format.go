@@ -14,7 +14,6 @@ import (
 
 	"github.com/fatih/color"
 	"github.com/hokaccha/go-prettyjson"
-	"github.com/savaki/jq"
 	"github.com/spf13/viper"
 	"github.com/tidwall/pretty"
 	yaml "gopkg.in/yaml.v3"
@@ -122,6 +121,32 @@ func (c *outClient) WriteResponse(data []byte, err *errors.ApiError) {
 		data, errFilter = FilterResponse(data)
 		err = err.Or(errFilter)
 	}
+	if len(data) > 0 && err == nil && viper.GetString(cst.Template) != "" {
+		rendered, errTemplate := TemplateResponse(data)
+		if errTemplate != nil {
+			data, err = nil, errTemplate
+		} else {
+			// Rendered templates are written as is, they are not JSON anymore.
+			if _, printErr := c.outWriter.Write(rendered); printErr != nil {
+				fmt.Fprint(c.errWriter, formatError(printErr))
+			}
+			return
+		}
+	}
+	if len(data) > 0 && err == nil {
+		if encoding := viper.GetString(cst.Encoding); GetEncoder(encoding) != nil {
+			encoded, errEncode := GetEncoder(encoding).Encode(data)
+			if errEncode != nil {
+				data, err = nil, errors.New(errEncode).Grow(fmt.Sprintf("Failed to encode data as %s", encoding))
+			} else {
+				// Encoded data is written as is, so that e.g. a single line of NDJSON is not beautified.
+				if _, printErr := c.outWriter.Write(encoded); printErr != nil {
+					fmt.Fprint(c.errWriter, formatError(printErr))
+				}
+				return
+			}
+		}
+	}
 	dataFmted, errFmted := FormatResponse(data, err, isBeautify)
 
 	if _, printErr := fmt.Fprint(c.outWriter, dataFmted); printErr != nil && len(errFmted) == 0 {
@@ -130,22 +155,6 @@ func (c *outClient) WriteResponse(data []byte, err *errors.ApiError) {
 	fmt.Fprint(c.errWriter, errFmted)
 }
 
-func FilterResponse(data []byte) ([]byte, *errors.ApiError) {
-	filter := viper.GetString(cst.Filter)
-	if filter == "" {
-		return data, nil
-	}
-	// TODO : NH - this jq library doesnt support advanced operations. would be nice to make one that does
-	op, err := jq.Parse(filter)
-	if err != nil {
-		// TODO : Should we return data or original if filter fails? If we do should only write if stderr and stdout
-		//  destinations not the same (console)
-		return nil, errors.New(err).Grow(fmt.Sprintf("Invalid filter (%s) on data:\n%s", filter, string(data)))
-	}
-	data, err = op.Apply(data)
-	return data, errors.New(err).Grow("Failed to apply the filter to the data")
-}
-
 func IsJson(b []byte) bool {
 	var j interface{}
 	return json.Unmarshal(b, &j) == nil
@@ -164,7 +173,10 @@ func FormatResponse(data []byte, err *errors.ApiError, isBeautify bool) (dataStr
 		}
 	}
 
-	data = bytes.Trim(data, `"`)
+	// Output of --raw-output is already unquoted and may start or end with a quote on purpose.
+	if !viper.GetBool(cst.RawOutput) {
+		data = bytes.Trim(data, `"`)
+	}
 	if bytes.Equal(data, []byte("{}")) {
 		data = []byte{}
 	}
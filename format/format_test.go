@@ -110,6 +110,68 @@ func TestFilterResponse_Array(t *testing.T) {
 	assert.Equal(t, "\"validjson2\"", string(filtered))
 }
 
+func TestFilterResponse(t *testing.T) {
+	unfiltered := []byte(`{"data":[` +
+		`{"path":"db/prod","attributes":{"env":"prod"},"data":{"token":"c2VjcmV0"},"version":12345678901234567890},` +
+		`{"path":"db/dev","attributes":{"env":"dev"},"data":{"token":"ZGV2"},"version":2}]}`)
+
+	testCases := []struct {
+		name      string
+		data      string
+		filter    string
+		rawOutput bool
+		expected  string
+		err       bool
+	}{
+		{name: "select", filter: `.data[] | select(.attributes.env=="prod") | .path`, expected: `"db/prod"`},
+		{name: "raw output", filter: `.data[] | .path`, rawOutput: true, expected: "db/prod\ndb/dev"},
+		{name: "map", filter: `.data | map(.version)`, expected: `[12345678901234567890,2]`},
+		{name: "keys", filter: `.data[0] | keys`, expected: `["attributes","data","path","version"]`},
+		{name: "string interpolation", filter: `.data[] | "\(.path)=\(.attributes.env)"`, rawOutput: true, expected: "db/prod=prod\ndb/dev=dev"},
+		{name: "base64d", filter: `.data[0].data.token | @base64d`, rawOutput: true, expected: "secret"},
+		{name: "no results", filter: `.data[] | select(.path=="none")`, expected: ""},
+		{name: "raw output without filter", rawOutput: true, expected: string(unfiltered)},
+		{name: "raw output of string without filter", data: `"a\tb"`, rawOutput: true, expected: "a\tb"},
+		{name: "legacy key", filter: "data.[1].path", expected: `"db/dev"`},
+		{name: "legacy range", data: `{"A":[1,2,3]}`, filter: "A.[0:1]", expected: `[1,2]`},
+		{name: "invalid", filter: ".data[", err: true},
+		{name: "runtime error", filter: ".data[0].path + 1", err: true},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			viper.Reset()
+			defer viper.Reset()
+			viper.Set(cst.Filter, tt.filter)
+			viper.Set(cst.RawOutput, tt.rawOutput)
+
+			data := unfiltered
+			if tt.data != "" {
+				data = []byte(tt.data)
+			}
+			filtered, err := format.FilterResponse(data)
+			if tt.err {
+				assert.NotNil(t, err)
+				return
+			}
+			assert.Nil(t, err)
+			assert.Equal(t, tt.expected, string(filtered))
+		})
+	}
+}
+
+func TestWriteResponseRawOutput(t *testing.T) {
+	viper.Reset()
+	defer viper.Reset()
+	viper.Set(cst.Filter, `.data[] | [.path, .version] | @csv`)
+	viper.Set(cst.RawOutput, true)
+
+	var outBuf, errBuf bytes.Buffer
+	format.NewOutClient(&outBuf, &errBuf).WriteResponse([]byte(`{"data":[{"path":"db","version":1},{"path":"cache","version":2}]}`), nil)
+	assert.Equal(t, "\"db\",1\n\"cache\",2", outBuf.String())
+	assert.Empty(t, errBuf.String())
+}
+
 func TestFormatResponse_Windows(t *testing.T) {
 	utils.GetEnvProviderFunc = func() utils.EnvProvider {
 		return utils.EnvFunc(func() string {
@@ -0,0 +1,43 @@
+package format
+
+import (
+	"bytes"
+	"encoding/json"
+
+	cst "github.com/DelineaXPM/dsv-cli/constants"
+)
+
+// ListEncodings are encodings for lists of results. Search and list commands opt in to them.
+var ListEncodings = []string{cst.NDJson, cst.Table, cst.CSV}
+
+func init() {
+	RegisterEncoder(cst.NDJson, EncoderFunc(encodeNDJson))
+}
+
+// encodeNDJson writes one compact JSON document per line. Items of an array or of the "data"
+// array of a search or list response become separate lines, anything else is a single line.
+func encodeNDJson(data []byte) ([]byte, error) {
+	var doc interface{}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if err := dec.Decode(&doc); err != nil {
+		return nil, err
+	}
+	items, ok := doc.([]interface{})
+	if obj, isObj := doc.(map[string]interface{}); isObj {
+		items, ok = obj["data"].([]interface{})
+	}
+	if !ok {
+		items = []interface{}{doc}
+	}
+
+	buf := &bytes.Buffer{}
+	enc := json.NewEncoder(buf)
+	enc.SetEscapeHTML(false)
+	for _, item := range items {
+		if err := enc.Encode(item); err != nil {
+			return nil, err
+		}
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,43 @@
+package format_test
+
+import (
+	"bytes"
+	"testing"
+
+	cst "github.com/DelineaXPM/dsv-cli/constants"
+	"github.com/DelineaXPM/dsv-cli/format"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNDJsonEncoder(t *testing.T) {
+	enc := format.GetEncoder(cst.NDJson)
+
+	out, err := enc.Encode([]byte(`{"data":[{"id":12345678901234567890,"html":"<b>"},{"id":2}],"cursor":"x"}`))
+	assert.NoError(t, err)
+	assert.Equal(t, "{\"html\":\"<b>\",\"id\":12345678901234567890}\n{\"id\":2}\n", string(out))
+
+	out, err = enc.Encode([]byte(`[1, "two"]`))
+	assert.NoError(t, err)
+	assert.Equal(t, "1\n\"two\"\n", string(out))
+
+	out, err = enc.Encode([]byte(`{"name": "a"}`))
+	assert.NoError(t, err)
+	assert.Equal(t, "{\"name\":\"a\"}\n", string(out))
+
+	_, err = enc.Encode([]byte(`not json`))
+	assert.Error(t, err)
+}
+
+func TestWriteResponseNDJsonNotBeautified(t *testing.T) {
+	viper.Reset()
+	defer viper.Reset()
+	viper.Set(cst.Beautify, true)
+	viper.Set(cst.Encoding, cst.NDJson)
+
+	var outBuf, errBuf bytes.Buffer
+	format.NewOutClient(&outBuf, &errBuf).WriteResponse([]byte(`{"data":[{"name":"a"}]}`), nil)
+	assert.Equal(t, "{\"name\":\"a\"}\n", outBuf.String())
+	assert.Empty(t, errBuf.String())
+}
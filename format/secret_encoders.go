@@ -0,0 +1,241 @@
+package format
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	cst "github.com/DelineaXPM/dsv-cli/constants"
+
+	"github.com/spf13/viper"
+	yaml "gopkg.in/yaml.v3"
+)
+
+// SecretEncodings are encodings for secret data. Commands which read a single secret opt in to them.
+var SecretEncodings = []string{cst.Dotenv, cst.Shell, cst.K8s, cst.DockerEnv}
+
+// List of key case transformations.
+const (
+	KeyCaseUpper = "upper"
+	KeyCaseLower = "lower"
+	KeyCaseNone  = "none"
+)
+
+const defaultKeySeparator = "_"
+
+func init() {
+	RegisterEncoder(cst.Dotenv, EncoderFunc(encodeDotenv))
+	RegisterEncoder(cst.Shell, EncoderFunc(encodeShell))
+	RegisterEncoder(cst.DockerEnv, EncoderFunc(encodeDockerEnv))
+	RegisterEncoder(cst.K8s, EncoderFunc(encodeK8sSecret))
+}
+
+var (
+	envNameInvalidChars = regexp.MustCompile(`[^A-Za-z0-9_]`)
+	k8sKeyInvalidChars  = regexp.MustCompile(`[^A-Za-z0-9._-]`)
+	k8sNameInvalidChars = regexp.MustCompile(`[^a-z0-9.-]+`)
+)
+
+// secretEntry is a flattened key of secret data with its value converted to a string.
+type secretEntry struct {
+	key   string
+	value string
+}
+
+func encodeDotenv(data []byte) ([]byte, error) {
+	entries, err := envEntries(data)
+	if err != nil {
+		return nil, err
+	}
+	replacer := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "\r", `\r`)
+	var buf bytes.Buffer
+	for _, e := range entries {
+		fmt.Fprintf(&buf, "%s=\"%s\"\n", e.key, replacer.Replace(e.value))
+	}
+	return buf.Bytes(), nil
+}
+
+func encodeShell(data []byte) ([]byte, error) {
+	entries, err := envEntries(data)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	for _, e := range entries {
+		fmt.Fprintf(&buf, "export %s='%s'\n", e.key, strings.ReplaceAll(e.value, "'", `'\''`))
+	}
+	return buf.Bytes(), nil
+}
+
+func encodeDockerEnv(data []byte) ([]byte, error) {
+	entries, err := envEntries(data)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	for _, e := range entries {
+		// Docker env-files have no quoting or escaping, so a value cannot span lines.
+		if strings.ContainsAny(e.value, "\r\n") {
+			return nil, fmt.Errorf("value of %s contains a line break which the %s encoding cannot represent", e.key, cst.DockerEnv)
+		}
+		fmt.Fprintf(&buf, "%s=%s\n", e.key, e.value)
+	}
+	return buf.Bytes(), nil
+}
+
+type k8sSecret struct {
+	APIVersion string            `yaml:"apiVersion"`
+	Kind       string            `yaml:"kind"`
+	Metadata   k8sSecretMetadata `yaml:"metadata"`
+	Type       string            `yaml:"type"`
+	Data       map[string]string `yaml:"data"`
+}
+
+type k8sSecretMetadata struct {
+	Name      string `yaml:"name"`
+	Namespace string `yaml:"namespace,omitempty"`
+}
+
+func encodeK8sSecret(data []byte) ([]byte, error) {
+	obj, values, err := secretValues(data)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := secretEntries(values, KeyCaseNone, func(s string) string {
+		return k8sKeyInvalidChars.ReplaceAllString(s, "_")
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	name := viper.GetString(cst.K8sName)
+	if name == "" {
+		p, _ := obj["path"].(string)
+		name = k8sName(p)
+	}
+	if name == "" {
+		return nil, fmt.Errorf("cannot derive a Kubernetes Secret name, use --%s", strings.ReplaceAll(cst.K8sName, ".", "-"))
+	}
+
+	secret := k8sSecret{
+		APIVersion: "v1",
+		Kind:       "Secret",
+		Metadata:   k8sSecretMetadata{Name: name, Namespace: viper.GetString(cst.K8sNamespace)},
+		Type:       "Opaque",
+		Data:       make(map[string]string, len(entries)),
+	}
+	for _, e := range entries {
+		secret.Data[e.key] = base64.StdEncoding.EncodeToString([]byte(e.value))
+	}
+	return yaml.Marshal(secret)
+}
+
+// k8sName converts a secret path to a DNS subdomain name which Kubernetes requires for object names.
+func k8sName(p string) string {
+	name := k8sNameInvalidChars.ReplaceAllString(strings.ToLower(p), "-")
+	if len(name) > 253 {
+		name = name[:253]
+	}
+	return strings.Trim(name, "-.")
+}
+
+// envEntries returns flattened secret data with keys converted to environment variable names.
+func envEntries(data []byte) ([]secretEntry, error) {
+	_, values, err := secretValues(data)
+	if err != nil {
+		return nil, err
+	}
+	return secretEntries(values, KeyCaseUpper, func(s string) string {
+		s = envNameInvalidChars.ReplaceAllString(s, "_")
+		if s != "" && s[0] >= '0' && s[0] <= '9' {
+			s = "_" + s
+		}
+		return s
+	})
+}
+
+// secretValues returns the secret object and the data to encode. The data is the "data" field
+// of the secret or, if there is no such field, the whole object.
+func secretValues(data []byte) (map[string]interface{}, map[string]interface{}, error) {
+	obj := map[string]interface{}{}
+	if err := json.Unmarshal(data, &obj); err != nil {
+		return nil, nil, fmt.Errorf("expected a JSON object: %w", err)
+	}
+	if values, ok := obj["data"].(map[string]interface{}); ok {
+		return obj, values, nil
+	}
+	return obj, obj, nil
+}
+
+// secretEntries flattens nested values and transforms keys using --key-prefix, --key-separator
+// and --key-case. Keys are sorted. Two keys which transform to the same name are an error.
+func secretEntries(values map[string]interface{}, defaultCase string, sanitize func(string) string) ([]secretEntry, error) {
+	sep := defaultKeySeparator
+	if viper.IsSet(cst.KeySeparator) {
+		sep = viper.GetString(cst.KeySeparator)
+	}
+	keyCase := strings.ToLower(viper.GetString(cst.KeyCase))
+	if keyCase == "" {
+		keyCase = defaultCase
+	}
+	var transform func(string) string
+	switch keyCase {
+	case KeyCaseUpper:
+		transform = strings.ToUpper
+	case KeyCaseLower:
+		transform = strings.ToLower
+	case KeyCaseNone:
+		transform = func(s string) string { return s }
+	default:
+		return nil, fmt.Errorf("invalid key case %q, expected one of %s, %s, %s", keyCase, KeyCaseUpper, KeyCaseLower, KeyCaseNone)
+	}
+	prefix := viper.GetString(cst.KeyPrefix)
+
+	flat := map[string]string{}
+	if err := flattenSecretData(flat, "", sep, values); err != nil {
+		return nil, err
+	}
+	origins := make(map[string]string, len(flat))
+	entries := make([]secretEntry, 0, len(flat))
+	for key, value := range flat {
+		name := sanitize(transform(prefix + key))
+		if other, ok := origins[name]; ok {
+			return nil, fmt.Errorf("keys %q and %q both map to %q", other, key, name)
+		}
+		origins[name] = key
+		entries = append(entries, secretEntry{key: name, value: value})
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].key < entries[j].key })
+	return entries, nil
+}
+
+// flattenSecretData joins keys of nested objects with sep. Strings are used as is,
+// other values are encoded as JSON.
+func flattenSecretData(flat map[string]string, prefix string, sep string, val interface{}) error {
+	if m, ok := val.(map[string]interface{}); ok && (prefix == "" || len(m) > 0) {
+		for k, v := range m {
+			key := k
+			if prefix != "" {
+				key = prefix + sep + k
+			}
+			if err := flattenSecretData(flat, key, sep, v); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if s, ok := val.(string); ok {
+		flat[prefix] = s
+		return nil
+	}
+	b, err := json.Marshal(val)
+	if err != nil {
+		return err
+	}
+	flat[prefix] = string(b)
+	return nil
+}
@@ -0,0 +1,124 @@
+package format_test
+
+import (
+	"bytes"
+	"testing"
+
+	cst "github.com/DelineaXPM/dsv-cli/constants"
+	"github.com/DelineaXPM/dsv-cli/format"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+)
+
+const secretEncoderInput = `{
+	"path": "apps:Billing DB",
+	"data": {"user": "admin", "password": "it's \"q\"\nx", "port": 5432, "tls": {"enabled": true, "ca": "pem"}}
+}`
+
+func TestSecretEncoders(t *testing.T) {
+	testCases := []struct {
+		name     string
+		encoding string
+		flags    map[string]interface{}
+		input    string
+		expected string
+		err      string
+	}{
+		{
+			name:     "dotenv",
+			encoding: cst.Dotenv,
+			input:    secretEncoderInput,
+			expected: "PASSWORD=\"it's \\\"q\\\"\\nx\"\nPORT=\"5432\"\nTLS_CA=\"pem\"\nTLS_ENABLED=\"true\"\nUSER=\"admin\"\n",
+		},
+		{
+			name:     "shell with prefix and separator",
+			encoding: cst.Shell,
+			flags:    map[string]interface{}{cst.KeyPrefix: "db.", cst.KeySeparator: "__", cst.KeyCase: format.KeyCaseLower},
+			input:    `{"data": {"password": "it's", "tls": {"ca": "pem"}}}`,
+			expected: "export db_password='it'\\''s'\nexport db_tls__ca='pem'\n",
+		},
+		{
+			name:     "docker env from object without data",
+			encoding: cst.DockerEnv,
+			input:    `{"1st": "a=b", "key": "value"}`,
+			expected: "KEY=value\n_1ST=a=b\n",
+		},
+		{
+			name:     "docker env rejects line breaks",
+			encoding: cst.DockerEnv,
+			input:    secretEncoderInput,
+			err:      "value of PASSWORD contains a line break",
+		},
+		{
+			name:     "duplicate keys",
+			encoding: cst.Dotenv,
+			input:    `{"data": {"a-b": "1", "a_b": "2"}}`,
+			err:      `both map to "A_B"`,
+		},
+		{
+			name:     "invalid key case",
+			encoding: cst.Shell,
+			flags:    map[string]interface{}{cst.KeyCase: "camel"},
+			input:    secretEncoderInput,
+			err:      `invalid key case "camel"`,
+		},
+		{
+			name:     "k8s",
+			encoding: cst.K8s,
+			flags:    map[string]interface{}{cst.K8sNamespace: "billing"},
+			input:    `{"path": "apps:Billing DB", "data": {"user": "admin", "tls": {"ca": "pem"}}}`,
+			expected: "apiVersion: v1\nkind: Secret\nmetadata:\n    name: apps-billing-db\n    namespace: billing\ntype: Opaque\ndata:\n    tls_ca: cGVt\n    user: YWRtaW4=\n",
+		},
+		{
+			name:     "k8s without name",
+			encoding: cst.K8s,
+			input:    `{"data": {"user": "admin"}}`,
+			err:      "use --k8s-name",
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			viper.Reset()
+			defer viper.Reset()
+			for k, v := range tt.flags {
+				viper.Set(k, v)
+			}
+
+			out, err := format.GetEncoder(tt.encoding).Encode([]byte(tt.input))
+			if tt.err != "" {
+				assert.ErrorContains(t, err, tt.err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, string(out))
+		})
+	}
+}
+
+func TestWriteResponseWithEncoder(t *testing.T) {
+	viper.Reset()
+	defer viper.Reset()
+	viper.Set(cst.Beautify, true)
+	viper.Set(cst.Encoding, cst.Shell)
+
+	var outBuf, errBuf bytes.Buffer
+	out := format.NewOutClient(&outBuf, &errBuf)
+
+	viper.Set(cst.Filter, "data")
+	out.WriteResponse([]byte(`{"path":"db","data":{"user":"admin"}}`), nil)
+	assert.Equal(t, "export USER='admin'\n", outBuf.String())
+	assert.Empty(t, errBuf.String())
+
+	outBuf.Reset()
+	viper.Set(cst.Filter, "")
+	out.WriteResponse([]byte(`[1, 2]`), nil)
+	assert.Empty(t, outBuf.String())
+	assert.Contains(t, errBuf.String(), "Failed to encode data as shell")
+}
+
+func TestEncoderNames(t *testing.T) {
+	assert.Subset(t, format.EncoderNames(), format.SecretEncodings)
+	assert.Nil(t, format.GetEncoder(cst.Json))
+}
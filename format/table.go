@@ -0,0 +1,253 @@
+package format
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	cst "github.com/DelineaXPM/dsv-cli/constants"
+
+	"github.com/spf13/viper"
+)
+
+func init() {
+	RegisterEncoder(cst.Table, EncoderFunc(encodeTable))
+	RegisterEncoder(cst.CSV, EncoderFunc(encodeCSV))
+}
+
+// defaultColumns are columns shown for a kind of resource. The first set whose key field is present
+// in the first row is used, only columns present in at least one row are shown.
+var defaultColumns = []struct {
+	key     string
+	columns []string
+}{
+	// Audit records also have a path, so they go first.
+	{key: "action", columns: []string{"created", "principal", "action", "path", "status", "ipAddress"}},
+	{key: "path", columns: []string{"path", "version", "description", "created", "lastModified", "lastModifiedBy"}},
+	{key: "userName", columns: []string{"userName", "displayName", "provider", "created", "lastModified"}},
+	{key: "groupName", columns: []string{"groupName", "created", "createdBy", "lastModified"}},
+	{key: "clientId", columns: []string{"clientId", "role", "description", "created"}},
+	{key: "poolName", columns: []string{"name", "poolName", "hostName", "lastConnected", "created"}},
+	{key: "name", columns: []string{"name", "type", "description", "provider", "created", "lastModified"}},
+}
+
+// encodeTable writes rows of a search or list response as a table with aligned columns.
+func encodeTable(data []byte) ([]byte, error) {
+	columns, rows, err := tabulate(data)
+	if err != nil || len(columns) == 0 {
+		return nil, err
+	}
+	replacer := strings.NewReplacer("\t", " ", "\r", " ", "\n", " ")
+	buf := &bytes.Buffer{}
+	w := tabwriter.NewWriter(buf, 0, 0, 2, ' ', 0)
+	header := make([]string, len(columns))
+	for i, c := range columns {
+		header[i] = strings.ToUpper(c)
+	}
+	w.Write([]byte(strings.Join(header, "\t") + "\n"))
+	for _, row := range rows {
+		for i := range row {
+			row[i] = replacer.Replace(row[i])
+		}
+		w.Write([]byte(strings.Join(row, "\t") + "\n"))
+	}
+	if err := w.Flush(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// encodeCSV writes rows of a search or list response as comma separated values with a header.
+func encodeCSV(data []byte) ([]byte, error) {
+	columns, rows, err := tabulate(data)
+	if err != nil || len(columns) == 0 {
+		return nil, err
+	}
+	buf := &bytes.Buffer{}
+	w := csv.NewWriter(buf)
+	if err := w.Write(columns); err != nil {
+		return nil, err
+	}
+	if err := w.WriteAll(rows); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// tabulate returns columns and cell values of rows found in the data. Columns can be set with
+// --columns, nested fields are separated with a dot, e.g. "attributes.env".
+func tabulate(data []byte) ([]string, [][]string, error) {
+	var doc interface{}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if err := dec.Decode(&doc); err != nil {
+		return nil, nil, err
+	}
+	items := listItems(doc)
+
+	columns := []string{}
+	for _, c := range strings.Split(viper.GetString(cst.Columns), ",") {
+		if c = strings.TrimSpace(c); c != "" {
+			columns = append(columns, c)
+		}
+	}
+	if len(columns) == 0 {
+		columns = guessColumns(items)
+	}
+
+	rows := make([][]string, 0, len(items))
+	for _, item := range items {
+		row := make([]string, len(columns))
+		for i, c := range columns {
+			row[i] = cellValue(lookupField(item, c))
+		}
+		rows = append(rows, row)
+	}
+	return columns, rows, nil
+}
+
+// listItems returns items of an array or of the "data" array of a search or list response. If "data"
+// is an object, e.g. in reports, items of all arrays of objects in it are returned. Anything else is
+// a single item.
+func listItems(doc interface{}) []interface{} {
+	if items, ok := doc.([]interface{}); ok {
+		return items
+	}
+	obj, ok := doc.(map[string]interface{})
+	if !ok {
+		return []interface{}{doc}
+	}
+	switch v := obj["data"].(type) {
+	case []interface{}:
+		return v
+	case map[string]interface{}:
+		if items := nestedItems(v); len(items) > 0 {
+			return items
+		}
+		return []interface{}{v}
+	}
+	return []interface{}{doc}
+}
+
+// nestedItems collects items of arrays of objects in the object and in objects nested in it.
+func nestedItems(obj map[string]interface{}) []interface{} {
+	keys := make([]string, 0, len(obj))
+	for k := range obj {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	items := []interface{}{}
+	for _, k := range keys {
+		switch v := obj[k].(type) {
+		case []interface{}:
+			if len(v) > 0 {
+				if _, ok := v[0].(map[string]interface{}); ok {
+					items = append(items, v...)
+				}
+			}
+		case map[string]interface{}:
+			items = append(items, nestedItems(v)...)
+		}
+	}
+	return items
+}
+
+func guessColumns(items []interface{}) []string {
+	if len(items) == 0 {
+		return []string{}
+	}
+	first, ok := items[0].(map[string]interface{})
+	if !ok {
+		return []string{cst.Value}
+	}
+	for _, d := range defaultColumns {
+		if _, ok := findKey(first, d.key); !ok {
+			continue
+		}
+		columns := []string{}
+		for _, c := range d.columns {
+			for _, item := range items {
+				if obj, ok := item.(map[string]interface{}); ok {
+					if key, ok := findKey(obj, c); ok {
+						columns = append(columns, key)
+						break
+					}
+				}
+			}
+		}
+		return columns
+	}
+
+	// Unknown resource, show all fields which are not objects or arrays.
+	columns := []string{}
+	for k, v := range first {
+		switch v.(type) {
+		case map[string]interface{}, []interface{}:
+		default:
+			columns = append(columns, k)
+		}
+	}
+	sort.Strings(columns)
+	return columns
+}
+
+// findKey returns the key of the object which matches the name ignoring case.
+func findKey(obj map[string]interface{}, name string) (string, bool) {
+	if _, ok := obj[name]; ok {
+		return name, true
+	}
+	for k := range obj {
+		if strings.EqualFold(k, name) {
+			return k, true
+		}
+	}
+	return "", false
+}
+
+func lookupField(item interface{}, column string) interface{} {
+	obj, ok := item.(map[string]interface{})
+	if !ok {
+		if column == cst.Value {
+			return item
+		}
+		return nil
+	}
+	if key, ok := findKey(obj, column); ok {
+		return obj[key]
+	}
+	head, rest, found := strings.Cut(column, ".")
+	if !found {
+		return nil
+	}
+	key, ok := findKey(obj, head)
+	if !ok {
+		return nil
+	}
+	return lookupField(obj[key], rest)
+}
+
+func cellValue(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case json.Number:
+		return v.String()
+	case bool:
+		if v {
+			return "true"
+		}
+		return "false"
+	default:
+		b, err := JsonMarshal(v)
+		if err != nil {
+			return ""
+		}
+		return string(b)
+	}
+}
@@ -0,0 +1,87 @@
+package format_test
+
+import (
+	"testing"
+
+	cst "github.com/DelineaXPM/dsv-cli/constants"
+	"github.com/DelineaXPM/dsv-cli/format"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTableEncoders(t *testing.T) {
+	secrets := `{"data":[` +
+		`{"id":"1","path":"db:prod","version":"3","attributes":{"env":"prod"},"created":"2026-01-02T00:00:00Z"},` +
+		`{"id":"2","path":"db:dev","version":"1","attributes":{"env":"dev, test"},"description":"dev\tdb","created":"2026-01-01T00:00:00Z"}` +
+		`],"cursor":"abc","length":2}`
+
+	testCases := []struct {
+		name     string
+		encoding string
+		columns  string
+		data     string
+		expected string
+	}{
+		{
+			name:     "secrets table",
+			encoding: cst.Table,
+			data:     secrets,
+			expected: "PATH     VERSION  DESCRIPTION  CREATED\n" +
+				"db:prod  3                     2026-01-02T00:00:00Z\n" +
+				"db:dev   1        dev db       2026-01-01T00:00:00Z\n",
+		},
+		{
+			name:     "secrets csv with columns",
+			encoding: cst.CSV,
+			columns:  "path, attributes.env,attributes,missing",
+			data:     secrets,
+			expected: "path,attributes.env,attributes,missing\n" +
+				"db:prod,prod,\"{\"\"env\"\":\"\"prod\"\"}\",\n" +
+				"db:dev,\"dev, test\",\"{\"\"env\"\":\"\"dev, test\"\"}\",\n",
+		},
+		{
+			name:     "audit",
+			encoding: cst.CSV,
+			data:     `{"data":[{"id":"1","action":"READ","path":"secrets:db","principal":"admin","status":"success","created":"2026"}]}`,
+			expected: "created,principal,action,path,status\n2026,admin,READ,secrets:db,success\n",
+		},
+		{
+			name:     "report",
+			encoding: cst.CSV,
+			data: `{"data":{"Name":"me","Home":{"Secrets":[{"Path":"home:a","Version":"1"}]},` +
+				`"Secrets":{"Secrets":[{"Path":"b","Version":"2"}],"Pagination":{"Limit":5}}}}`,
+			expected: "Path,Version\nhome:a,1\nb,2\n",
+		},
+		{
+			name:     "unknown resource",
+			encoding: cst.CSV,
+			data:     `[{"b":1,"a":true,"c":{"d":1}},{"a":false,"b":2.5}]`,
+			expected: "a,b\ntrue,1\nfalse,2.5\n",
+		},
+		{
+			name:     "values",
+			encoding: cst.CSV,
+			data:     `["x","y"]`,
+			expected: "value\nx\ny\n",
+		},
+		{
+			name:     "empty",
+			encoding: cst.Table,
+			data:     `{"data":[],"cursor":""}`,
+			expected: "",
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			viper.Reset()
+			defer viper.Reset()
+			viper.Set(cst.Columns, tt.columns)
+
+			out, err := format.GetEncoder(tt.encoding).Encode([]byte(tt.data))
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, string(out))
+		})
+	}
+}
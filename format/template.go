@@ -0,0 +1,124 @@
+package format
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"text/template"
+	"time"
+
+	cst "github.com/DelineaXPM/dsv-cli/constants"
+	"github.com/DelineaXPM/dsv-cli/errors"
+
+	"github.com/spf13/viper"
+)
+
+// templateFuncs are functions available in templates given with --template.
+var templateFuncs = template.FuncMap{
+	"json":    templateJson,
+	"yaml":    templateYaml,
+	"b64enc":  func(s string) string { return base64.StdEncoding.EncodeToString([]byte(s)) },
+	"b64dec":  templateB64dec,
+	"date":    templateDate,
+	"upper":   strings.ToUpper,
+	"lower":   strings.ToLower,
+	"default": templateDefault,
+}
+
+// TemplateResponse executes the Go template given with --template with the data as its argument.
+func TemplateResponse(data []byte) ([]byte, *errors.ApiError) {
+	src := viper.GetString(cst.Template)
+	if src == "" {
+		return data, nil
+	}
+	t, err := template.New(cst.Template).Funcs(templateFuncs).Parse(src)
+	if err != nil {
+		return nil, errors.New(err).Grow("Invalid template")
+	}
+
+	var doc interface{}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	// Keep numbers as they are, otherwise large integers are rendered in exponent notation.
+	dec.UseNumber()
+	if dec.Decode(&doc) != nil {
+		doc = string(data)
+	}
+
+	var b bytes.Buffer
+	if err := t.Execute(&b, doc); err != nil {
+		return nil, errors.New(err).Grow("Failed to apply the template to the data")
+	}
+	return b.Bytes(), nil
+}
+
+func templateJson(v interface{}) (string, error) {
+	b, err := JsonMarshal(v)
+	return string(b), err
+}
+
+func templateYaml(v interface{}) (string, error) {
+	b, err := JsonMarshal(v)
+	if err != nil {
+		return "", err
+	}
+	out, apiErr := toYaml(b)
+	if apiErr != nil {
+		return "", apiErr
+	}
+	return strings.TrimSuffix(string(out), "\n"), nil
+}
+
+func templateB64dec(s string) (string, error) {
+	b, err := base64.StdEncoding.DecodeString(s)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+// templateDate formats a time given as an RFC 3339 string or as seconds since the Unix epoch
+// using a Go layout, e.g. {{ date "2006-01-02" .created }}.
+func templateDate(layout string, v interface{}) (string, error) {
+	var t time.Time
+	switch v := v.(type) {
+	case time.Time:
+		t = v
+	case string:
+		parsed, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return "", err
+		}
+		t = parsed
+	case json.Number:
+		sec, err := v.Int64()
+		if err != nil {
+			return "", err
+		}
+		t = time.Unix(sec, 0).UTC()
+	default:
+		return "", fmt.Errorf("cannot format %v as a date", v)
+	}
+	return t.Format(layout), nil
+}
+
+// templateDefault returns the given value unless it is empty, e.g. {{ .description | default "-" }}.
+func templateDefault(def interface{}, given ...interface{}) interface{} {
+	if len(given) == 0 || given[0] == nil {
+		return def
+	}
+	v := reflect.ValueOf(given[0])
+	switch v.Kind() {
+	case reflect.String, reflect.Map, reflect.Slice, reflect.Array:
+		if v.Len() == 0 {
+			return def
+		}
+	case reflect.Bool:
+		if !v.Bool() {
+			return def
+		}
+	}
+	return given[0]
+}
@@ -0,0 +1,64 @@
+package format_test
+
+import (
+	"bytes"
+	"testing"
+
+	cst "github.com/DelineaXPM/dsv-cli/constants"
+	"github.com/DelineaXPM/dsv-cli/format"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTemplateResponse(t *testing.T) {
+	data := []byte(`{"data":[` +
+		`{"path":"db:prod","version":12345678901234567890,"data":{"token":"c2VjcmV0"},"created":"2026-01-02T10:00:00Z"},` +
+		`{"path":"db:dev","version":2,"description":"dev db","created":1767225600}]}`)
+
+	testCases := []struct {
+		name     string
+		template string
+		expected string
+		err      bool
+	}{
+		{name: "range", template: `{{range .data}}{{.path}}@{{.version}}{{"\n"}}{{end}}`, expected: "db:prod@12345678901234567890\ndb:dev@2\n"},
+		{name: "json", template: `{{json (index .data 0).data}}`, expected: `{"token":"c2VjcmV0"}`},
+		{name: "yaml", template: `{{yaml (index .data 0).data}}`, expected: "token: c2VjcmV0"},
+		{name: "b64", template: `{{(index .data 0).data.token | b64dec}} {{"secret" | b64enc}}`, expected: "secret c2VjcmV0"},
+		{name: "date", template: `{{range .data}}{{date "2006-01-02" .created}} {{end}}`, expected: "2026-01-02 2026-01-01 "},
+		{name: "upper and default", template: `{{range .data}}{{.description | default "-" | upper}} {{end}}`, expected: "- DEV DB "},
+		{name: "invalid", template: `{{.data`, err: true},
+		{name: "invalid base64", template: `{{b64dec "%"}}`, err: true},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			viper.Reset()
+			defer viper.Reset()
+			viper.Set(cst.Template, tt.template)
+
+			out, err := format.TemplateResponse(data)
+			if tt.err {
+				assert.NotNil(t, err)
+				return
+			}
+			assert.Nil(t, err)
+			assert.Equal(t, tt.expected, string(out))
+		})
+	}
+}
+
+func TestWriteResponseTemplate(t *testing.T) {
+	viper.Reset()
+	defer viper.Reset()
+	viper.Set(cst.Beautify, true)
+	viper.Set(cst.Encoding, cst.Table)
+	viper.Set(cst.Filter, ".data")
+	viper.Set(cst.Template, `{{range .}}{{.name}}{{"\n"}}{{end}}`)
+
+	var outBuf, errBuf bytes.Buffer
+	format.NewOutClient(&outBuf, &errBuf).WriteResponse([]byte(`{"data":[{"name":"a"},{"name":"b"}]}`), nil)
+	assert.Equal(t, "a\nb\n", outBuf.String())
+	assert.Empty(t, errBuf.String())
+}
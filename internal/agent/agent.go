@@ -0,0 +1,227 @@
+// Package agent implements a local daemon which serves secret reads over a Unix socket.
+package agent
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	cst "github.com/DelineaXPM/dsv-cli/constants"
+	"github.com/DelineaXPM/dsv-cli/errors"
+	"github.com/DelineaXPM/dsv-cli/utils"
+)
+
+// SecretsPath is the URI path prefix of requests served by the agent.
+const SecretsPath = "/" + cst.APIVersion + "/" + cst.PrefixEntity
+
+// Headers which tell the agent the tenant host and the profile a request is for.
+const (
+	HostHeader    = "X-Dsv-Agent-Host"
+	ProfileHeader = "X-Dsv-Agent-Profile"
+)
+
+// Identity is the tenant host and the profile of an agent. Requests for another tenant or profile
+// are rejected, so they never get data read with the token of the agent.
+type Identity struct {
+	Host    string
+	Profile string
+}
+
+// FetchFunc retrieves a resource from DSV by its URI path relative to the API version,
+// e.g. "secrets/db/prod?edit=false".
+type FetchFunc func(path string) ([]byte, *errors.ApiError)
+
+// Server serves secret reads from an in-memory cache or DSV depending on the cache strategy.
+type Server struct {
+	fetch    FetchFunc
+	identity Identity
+	strategy string
+	age      time.Duration
+	now      func() time.Time
+
+	mu    sync.Mutex
+	cache map[string]cacheEntry
+}
+
+type cacheEntry struct {
+	data []byte
+	date time.Time
+}
+
+// NewServer returns a server for the given identity which uses the given cache strategy. Cached secrets never expire if age is not positive.
+func NewServer(fetch FetchFunc, identity Identity, strategy string, age time.Duration) *Server {
+	if strategy == "" {
+		strategy = cst.CacheStrategyNever
+	}
+	return &Server{
+		fetch:    fetch,
+		identity: identity,
+		strategy: strategy,
+		age:      age,
+		now:      time.Now,
+		cache:    make(map[string]cacheEntry),
+	}
+}
+
+// ServeHTTP satisfies http.Handler interface.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, fmt.Sprintf("method %s is not supported by the agent", r.Method))
+		return
+	}
+	if !strings.HasPrefix(r.URL.Path, SecretsPath) || len(r.URL.Path) == len(SecretsPath) {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("path %s is not served by the agent", r.URL.Path))
+		return
+	}
+	host, profile := r.Header.Get(HostHeader), r.Header.Get(ProfileHeader)
+	if !strings.EqualFold(host, s.identity.Host) || profile != s.identity.Profile {
+		writeError(w, http.StatusMisdirectedRequest, fmt.Sprintf(
+			"the agent serves %s with profile %q, the request is for %s with profile %q. Unset %s to read it directly",
+			s.identity.Host, s.identity.Profile, host, profile, cst.AgentSocketEnv))
+		return
+	}
+
+	path := strings.TrimPrefix(r.URL.EscapedPath(), "/"+cst.APIVersion+"/")
+	if r.URL.RawQuery != "" {
+		path = path + "?" + r.URL.RawQuery
+	}
+
+	data, apiErr := s.get(path)
+	if apiErr != nil {
+		// Errors returned by DSV are passed through as is.
+		if resp := apiErr.HttpResponse(); resp != nil {
+			w.WriteHeader(resp.StatusCode)
+			_, _ = w.Write([]byte(apiErr.Error()))
+			return
+		}
+		writeError(w, http.StatusBadGateway, apiErr.Error())
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(data)
+}
+
+// get retrieves a secret either from DSV or the cache depending on the cache strategy.
+func (s *Server) get(path string) ([]byte, *errors.ApiError) {
+	switch s.strategy {
+	case cst.CacheStrategyNever:
+		return s.fetch(path)
+
+	case cst.CacheStrategyServerThenCache:
+		data, apiErr := s.fetch(path)
+		if apiErr == nil {
+			s.put(path, data)
+			return data, nil
+		}
+		if cached, expired := s.lookup(path); cached != nil && !expired {
+			log.Printf("Returning %s from cache.", path)
+			return cached, nil
+		}
+		return nil, apiErr
+
+	case cst.CacheStrategyCacheThenServer, cst.CacheStrategyCacheThenServerThenExpired:
+		cached, expired := s.lookup(path)
+		if cached != nil && !expired {
+			return cached, nil
+		}
+		data, apiErr := s.fetch(path)
+		if apiErr == nil {
+			s.put(path, data)
+			return data, nil
+		}
+		if cached != nil && s.strategy == cst.CacheStrategyCacheThenServerThenExpired {
+			log.Printf("Cache expired but failed to retrieve %s from server so returning cached data.", path)
+			return cached, nil
+		}
+		return nil, apiErr
+
+	case cst.CacheStrategyRevalidate:
+		if strings.Contains(path, cst.SuffixDescription) {
+			return s.fetch(path)
+		}
+		if cached, expired := s.lookup(path); cached != nil {
+			// The description is much smaller than a secret with data, but has the same version.
+			desc, apiErr := s.fetch(descriptionPath(path))
+			switch {
+			case apiErr == nil && utils.GetVersion(desc) != "" && utils.GetVersion(desc) == utils.GetVersion(cached):
+				s.put(path, cached)
+				return cached, nil
+			case apiErr != nil && apiErr.HttpResponse() == nil && !expired:
+				log.Printf("Failed to revalidate %s so returning cached data.", path)
+				return cached, nil
+			case apiErr != nil && apiErr.HttpResponse() == nil:
+				return nil, apiErr
+			}
+		}
+		data, apiErr := s.fetch(path)
+		if apiErr == nil {
+			s.put(path, data)
+		}
+		return data, apiErr
+
+	default:
+		// In case of unknown cache strategy the agent acts as it is set to "server".
+		return s.fetch(path)
+	}
+}
+
+func (s *Server) lookup(path string) (data []byte, expired bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entry, ok := s.cache[path]
+	if !ok {
+		return nil, true
+	}
+	return entry.data, s.age > 0 && s.now().Sub(entry.date) > s.age
+}
+
+func (s *Server) put(path string, data []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.cache[path] = cacheEntry{data: data, date: s.now()}
+}
+
+// descriptionPath returns the path of the description of the secret at the path.
+func descriptionPath(path string) string {
+	p, query, ok := strings.Cut(path, "?")
+	if !ok {
+		return p + cst.SuffixDescription
+	}
+	return p + cst.SuffixDescription + "?" + query
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(map[string]string{"message": message})
+}
+
+// Listen creates a Unix socket at the given path which is accessible only by the current user.
+// A socket left behind by an agent which is no longer running is removed.
+func Listen(path string) (net.Listener, error) {
+	if _, err := os.Stat(path); err == nil {
+		if conn, err := net.Dial("unix", path); err == nil {
+			conn.Close()
+			return nil, fmt.Errorf("an agent is already listening on %s", path)
+		}
+		if err := os.Remove(path); err != nil {
+			return nil, err
+		}
+	}
+
+	ln, err := listenUnix(path)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Chmod(path, 0o600); err != nil {
+		ln.Close()
+		return nil, err
+	}
+	return ln, nil
+}
@@ -0,0 +1,207 @@
+package agent
+
+import (
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	cst "github.com/DelineaXPM/dsv-cli/constants"
+	"github.com/DelineaXPM/dsv-cli/errors"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeDSV returns secrets by path and counts requests. It fails if down is set.
+type fakeDSV struct {
+	secrets  map[string]string
+	requests []string
+	down     bool
+}
+
+func (f *fakeDSV) fetch(path string) ([]byte, *errors.ApiError) {
+	f.requests = append(f.requests, path)
+	if f.down {
+		return nil, errors.NewS("connection refused")
+	}
+	if s, ok := f.secrets[path]; ok {
+		return []byte(s), nil
+	}
+	return nil, errors.NewS(`{"message":"not found"}`).WithResponse(&http.Response{StatusCode: http.StatusNotFound})
+}
+
+var testIdentity = Identity{Host: "tenant.secretsvaultcloud.com", Profile: "default"}
+
+func newTestRequest(method string, target string, identity Identity) *http.Request {
+	r := httptest.NewRequest(method, target, nil)
+	r.RequestURI = ""
+	r.Header.Set(HostHeader, identity.Host)
+	r.Header.Set(ProfileHeader, identity.Profile)
+	return r
+}
+
+func TestServer_CacheStrategies(t *testing.T) {
+	const path = "secrets/db/prod?edit=false"
+
+	testCases := []struct {
+		strategy string
+		// Expected results of a read while DSV is down and a read of an expired secret while DSV is down.
+		down, expiredDown bool
+		// Expected number of requests to DSV for two reads when DSV is up again.
+		requests int
+	}{
+		{strategy: cst.CacheStrategyNever, down: false, expiredDown: false, requests: 2},
+		{strategy: cst.CacheStrategyServerThenCache, down: true, expiredDown: false, requests: 2},
+		{strategy: cst.CacheStrategyCacheThenServer, down: true, expiredDown: false, requests: 1},
+		{strategy: cst.CacheStrategyCacheThenServerThenExpired, down: true, expiredDown: true, requests: 1},
+		{strategy: cst.CacheStrategyRevalidate, down: true, expiredDown: false, requests: 2},
+	}
+	for _, tt := range testCases {
+		t.Run(tt.strategy, func(t *testing.T) {
+			dsv := &fakeDSV{secrets: map[string]string{
+				path: `{"version":"1","data":{"password":"p@ss"}}`,
+				"secrets/db/prod::description?edit=false": `{"version":"1"}`,
+			}}
+			now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+			s := NewServer(dsv.fetch, testIdentity, tt.strategy, time.Minute)
+			s.now = func() time.Time { return now }
+
+			data, err := s.get(path)
+			assert.Nil(t, err)
+			assert.Equal(t, `{"version":"1","data":{"password":"p@ss"}}`, string(data))
+
+			dsv.down = true
+			_, err = s.get(path)
+			assert.Equal(t, tt.down, err == nil, "read while DSV is down")
+
+			now = now.Add(2 * time.Minute)
+			_, err = s.get(path)
+			assert.Equal(t, tt.expiredDown, err == nil, "read of expired secret while DSV is down")
+
+			// Refresh the cache and read again.
+			dsv.down = false
+			dsv.requests = nil
+			_, _ = s.get(path)
+			_, err = s.get(path)
+			assert.Nil(t, err)
+			assert.Len(t, dsv.requests, tt.requests)
+		})
+	}
+}
+
+func TestServer_Revalidate(t *testing.T) {
+	const path = "secrets/db/prod?edit=false"
+	const descPath = "secrets/db/prod::description?edit=false"
+	dsv := &fakeDSV{secrets: map[string]string{
+		path:     `{"version":"1","data":{"certificate":"large"}}`,
+		descPath: `{"version":"1"}`,
+	}}
+	s := NewServer(dsv.fetch, testIdentity, cst.CacheStrategyRevalidate, time.Minute)
+
+	_, err := s.get(path)
+	assert.Nil(t, err)
+	data, err := s.get(path)
+	assert.Nil(t, err)
+	assert.Equal(t, `{"version":"1","data":{"certificate":"large"}}`, string(data))
+	assert.Equal(t, []string{path, descPath}, dsv.requests, "an unchanged secret must not be downloaded again")
+
+	dsv.requests = nil
+	dsv.secrets[path] = `{"version":"2","data":{"certificate":"renewed"}}`
+	dsv.secrets[descPath] = `{"version":"2"}`
+	data, err = s.get(path)
+	assert.Nil(t, err)
+	assert.Equal(t, `{"version":"2","data":{"certificate":"renewed"}}`, string(data))
+	assert.Equal(t, []string{descPath, path}, dsv.requests)
+
+	// A secret deleted in DSV is not returned from the cache.
+	delete(dsv.secrets, path)
+	delete(dsv.secrets, descPath)
+	_, err = s.get(path)
+	assert.NotNil(t, err)
+
+	assert.Equal(t, "secrets/a::description", descriptionPath("secrets/a"))
+}
+
+func TestServer_ServeHTTP(t *testing.T) {
+	dsv := &fakeDSV{secrets: map[string]string{"secrets/db/prod?edit=false": `{"data":{}}`}}
+	s := NewServer(dsv.fetch, testIdentity, "", 0)
+
+	testCases := []struct {
+		name   string
+		method string
+		target string
+		status int
+		body   string
+	}{
+		{"read", http.MethodGet, "/v1/secrets/db/prod?edit=false", http.StatusOK, `{"data":{}}`},
+		{"not found", http.MethodGet, "/v1/secrets/db/dev", http.StatusNotFound, `{"message":"not found"}`},
+		{"write", http.MethodPost, "/v1/secrets/db/prod", http.StatusMethodNotAllowed, "not supported by the agent"},
+		{"other resource", http.MethodGet, "/v1/roles/admin", http.StatusNotFound, "not served by the agent"},
+		{"no path", http.MethodGet, "/v1/secrets/", http.StatusNotFound, "not served by the agent"},
+	}
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			s.ServeHTTP(w, newTestRequest(tt.method, tt.target, testIdentity))
+			assert.Equal(t, tt.status, w.Code)
+			assert.Contains(t, w.Body.String(), tt.body)
+		})
+	}
+
+	// Reads for another tenant or profile are rejected.
+	for _, id := range []Identity{
+		{Host: "other.secretsvaultcloud.com", Profile: testIdentity.Profile},
+		{Host: testIdentity.Host, Profile: "other"},
+		{},
+	} {
+		w := httptest.NewRecorder()
+		s.ServeHTTP(w, newTestRequest(http.MethodGet, "/v1/secrets/db/prod?edit=false", id))
+		assert.Equal(t, http.StatusMisdirectedRequest, w.Code)
+		assert.Contains(t, w.Body.String(), "the agent serves tenant.secretsvaultcloud.com with profile")
+	}
+
+	dsv.down = true
+	w := httptest.NewRecorder()
+	s.ServeHTTP(w, newTestRequest(http.MethodGet, "/v1/secrets/db/prod", testIdentity))
+	assert.Equal(t, http.StatusBadGateway, w.Code)
+	assert.Contains(t, w.Body.String(), "connection refused")
+}
+
+func TestListen(t *testing.T) {
+	socket := filepath.Join(t.TempDir(), "agent.sock")
+
+	ln, err := Listen(socket)
+	if !assert.NoError(t, err) {
+		return
+	}
+	info, err := os.Stat(socket)
+	assert.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+
+	dsv := &fakeDSV{secrets: map[string]string{"secrets/a": `{"path":"a"}`}}
+	go func() { _ = http.Serve(ln, NewServer(dsv.fetch, testIdentity, "", 0)) }()
+
+	client := &http.Client{Transport: &http.Transport{
+		Dial: func(_, _ string) (net.Conn, error) { return net.Dial("unix", socket) },
+	}}
+	resp, err := client.Do(newTestRequest(http.MethodGet, "http://agent/v1/secrets/a", testIdentity))
+	if assert.NoError(t, err) {
+		b, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		assert.Equal(t, `{"path":"a"}`, string(b))
+	}
+
+	_, err = Listen(socket)
+	assert.ErrorContains(t, err, "already listening")
+	ln.Close()
+
+	// A socket file left behind is replaced.
+	assert.NoError(t, os.WriteFile(socket, nil, 0o600))
+	ln, err = Listen(socket)
+	assert.NoError(t, err)
+	ln.Close()
+}
@@ -0,0 +1,24 @@
+//go:build !windows
+// +build !windows
+
+package agent
+
+import (
+	"net"
+	"sync"
+
+	"golang.org/x/sys/unix"
+)
+
+// umaskMu serializes changes of the process wide umask.
+var umaskMu sync.Mutex // trunk-ignore(golangci-lint/gochecknoglobals)
+
+// listenUnix creates the socket with a umask which denies access to other users, so that there is
+// no window between creating the socket and restricting its permissions.
+func listenUnix(path string) (net.Listener, error) {
+	umaskMu.Lock()
+	defer umaskMu.Unlock()
+	old := unix.Umask(0o177)
+	defer unix.Umask(old)
+	return net.Listen("unix", path)
+}
@@ -0,0 +1,30 @@
+//go:build !windows
+// +build !windows
+
+package agent
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/sys/unix"
+)
+
+func TestListenUnixUmask(t *testing.T) {
+	old := unix.Umask(0o022)
+	defer unix.Umask(old)
+
+	socket := filepath.Join(t.TempDir(), "agent.sock")
+	ln, err := listenUnix(socket)
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer ln.Close()
+
+	info, err := os.Stat(socket)
+	assert.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o600), info.Mode().Perm(), "socket must be created without access for other users")
+	assert.Equal(t, 0o022, unix.Umask(0o022), "umask must be restored")
+}
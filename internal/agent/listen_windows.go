@@ -0,0 +1,10 @@
+//go:build windows
+// +build windows
+
+package agent
+
+import "net"
+
+func listenUnix(path string) (net.Listener, error) {
+	return net.Listen("unix", path)
+}
@@ -0,0 +1,56 @@
+package httpclient
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+
+	cst "github.com/DelineaXPM/dsv-cli/constants"
+	"github.com/DelineaXPM/dsv-cli/internal/agent"
+
+	"github.com/spf13/viper"
+)
+
+// agentTransport sends secret reads to a local agent listening on a Unix socket
+// and all other requests to the next transport.
+type agentTransport struct {
+	socket string
+	agent  http.RoundTripper
+	next   http.RoundTripper
+}
+
+func newAgentTransport(socket string, next http.RoundTripper) *agentTransport {
+	return &agentTransport{
+		socket: socket,
+		agent: &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				var d net.Dialer
+				return d.DialContext(ctx, "unix", socket)
+			},
+		},
+		next: next,
+	}
+}
+
+func (t *agentTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method != http.MethodGet || !strings.HasPrefix(req.URL.Path, agent.SecretsPath) {
+		return t.next.RoundTrip(req)
+	}
+
+	agentReq := req.Clone(req.Context())
+	agentReq.URL.Scheme = "http"
+	agentReq.URL.Host = "agent"
+	agentReq.Host = ""
+	// The agent uses its own token, so it serves only requests for its own tenant and profile.
+	agentReq.Header.Del("Authorization")
+	agentReq.Header.Set(agent.HostHeader, req.URL.Host)
+	agentReq.Header.Set(agent.ProfileHeader, viper.GetString(cst.Profile))
+
+	resp, err := t.agent.RoundTrip(agentReq)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reach agent at %s: %w", t.socket, err)
+	}
+	return resp, nil
+}
@@ -0,0 +1,68 @@
+package httpclient
+
+import (
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	cst "github.com/DelineaXPM/dsv-cli/constants"
+	"github.com/DelineaXPM/dsv-cli/internal/agent"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAgentTransport(t *testing.T) {
+	socket := filepath.Join(t.TempDir(), "agent.sock")
+	ln, err := net.Listen("unix", socket)
+	mustNoError(t, err)
+	agentServer := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("agent " + r.URL.RequestURI() + " " + r.Header.Get("Authorization") +
+			r.Header.Get(agent.ProfileHeader) + "@" + r.Header.Get(agent.HostHeader)))
+	})}
+	go func() { _ = agentServer.Serve(ln) }()
+	defer agentServer.Close()
+
+	dsv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("dsv " + r.Method + " " + r.URL.Path))
+	}))
+	defer dsv.Close()
+
+	viper.Reset()
+	defer viper.Reset()
+	viper.Set(cst.Profile, "ci")
+	t.Setenv(cst.AgentSocketEnv, socket)
+	client, err := New()
+	mustNoError(t, err)
+
+	do := func(method, path string) string {
+		req, _ := http.NewRequest(method, dsv.URL+path, nil)
+		req.Header.Set("Authorization", "token")
+		resp, err := client.Do(req)
+		if !assert.NoError(t, err) {
+			return ""
+		}
+		defer resp.Body.Close()
+		b, _ := io.ReadAll(resp.Body)
+		return string(b)
+	}
+
+	host := strings.TrimPrefix(dsv.URL, "http://")
+	assert.Equal(t, "agent /v1/secrets/db/prod?edit=false ci@"+host, do(http.MethodGet, "/v1/secrets/db/prod?edit=false"))
+	assert.Equal(t, "dsv PUT /v1/secrets/db/prod", do(http.MethodPut, "/v1/secrets/db/prod"))
+	assert.Equal(t, "dsv GET /v1/roles/admin", do(http.MethodGet, "/v1/roles/admin"))
+
+	client, err = NewExternal()
+	mustNoError(t, err)
+	assert.Equal(t, "dsv GET /v1/secrets/db/prod", do(http.MethodGet, "/v1/secrets/db/prod"), "external client must not use the agent")
+	client = NewDirect()
+	assert.Equal(t, "dsv GET /v1/secrets/db/prod", do(http.MethodGet, "/v1/secrets/db/prod"), "direct client must not use the agent")
+
+	client = &http.Client{Transport: newAgentTransport(filepath.Join(t.TempDir(), "missing.sock"), http.DefaultTransport)}
+	_, err = client.Get(dsv.URL + "/v1/secrets/db/prod")
+	assert.ErrorContains(t, err, "failed to reach agent")
+}
@@ -0,0 +1,176 @@
+// Package httpclient builds the HTTP client used for all outbound requests of the CLI.
+package httpclient
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sync"
+
+	cst "github.com/DelineaXPM/dsv-cli/constants"
+
+	"github.com/spf13/viper"
+	"golang.org/x/net/http/httpproxy"
+)
+
+// New returns an HTTP client configured with TLS, proxy, timeout and retry settings from the current profile.
+// If DSV_AGENT_SOCK is set, secret reads are sent to the local agent listening on that socket.
+// All outbound requests of the CLI should be sent with a client returned by this function.
+func New() (*http.Client, error) {
+	base, err := Transport()
+	if err != nil {
+		return nil, err
+	}
+	if socket := os.Getenv(cst.AgentSocketEnv); socket != "" {
+		base = newAgentTransport(socket, base)
+	}
+	return &http.Client{
+		Transport: NewRetryTransport(base, Timeout(), Retries()),
+	}, nil
+}
+
+// NewExternal returns an HTTP client for services other than DSV, e.g. cloud provider APIs. TLS, proxy,
+// timeout and retry settings apply, but requests are never sent to the agent.
+func NewExternal() (*http.Client, error) {
+	base, err := Transport()
+	if err != nil {
+		return nil, err
+	}
+	return &http.Client{
+		Transport: NewRetryTransport(base, Timeout(), Retries()),
+	}, nil
+}
+
+// NewDirect returns an HTTP client for link-local services such as cloud metadata servers.
+// Requests are neither proxied nor sent to the agent, only timeout and retry settings apply.
+func NewDirect() *http.Client {
+	base := http.DefaultTransport.(*http.Transport).Clone()
+	base.Proxy = nil
+	return &http.Client{
+		Transport: NewRetryTransport(base, Timeout(), Retries()),
+	}
+}
+
+// settings are TLS and proxy settings of the current profile.
+type settings struct {
+	caFile   string
+	certFile string
+	keyFile  string
+	insecure bool
+	proxy    string
+	noProxy  string
+}
+
+func currentSettings() settings {
+	return settings{
+		caFile:   viper.GetString(cst.TLSCAFile),
+		certFile: viper.GetString(cst.TLSCertFile),
+		keyFile:  viper.GetString(cst.TLSKeyFile),
+		insecure: viper.GetBool(cst.TLSInsecure),
+		proxy:    viper.GetString(cst.NetworkProxy),
+		noProxy:  viper.GetString(cst.NetworkNoProxy),
+	}
+}
+
+var (
+	transportsMu sync.Mutex
+	transports   = map[settings]*http.Transport{}
+
+	insecureWarning sync.Once
+	warningWriter   io.Writer = os.Stderr
+)
+
+// Transport returns the base transport with TLS and proxy settings from the current profile applied.
+// Transports are shared between clients with the same settings so that connections are reused.
+// If nothing is configured, the default transport is returned as is.
+func Transport() (http.RoundTripper, error) {
+	s := currentSettings()
+	if s == (settings{}) {
+		return http.DefaultTransport, nil
+	}
+	if s.insecure {
+		insecureWarning.Do(func() {
+			fmt.Fprintf(warningWriter, "WARNING: TLS certificate verification is disabled (%s is set). "+
+				"Connections are not protected against interception, do not use this setting in production.\n", cst.TLSInsecure)
+		})
+	}
+
+	transportsMu.Lock()
+	defer transportsMu.Unlock()
+	if t, ok := transports[s]; ok {
+		return t, nil
+	}
+
+	base, ok := http.DefaultTransport.(*http.Transport)
+	if !ok {
+		base = &http.Transport{Proxy: http.ProxyFromEnvironment}
+	}
+	t, err := newTransport(base, s)
+	if err != nil {
+		return nil, err
+	}
+	transports[s] = t
+	return t, nil
+}
+
+// newTransport returns a copy of the base transport with the given settings applied.
+func newTransport(base *http.Transport, s settings) (*http.Transport, error) {
+	t := base.Clone()
+	if t.TLSClientConfig == nil {
+		t.TLSClientConfig = &tls.Config{MinVersion: tls.VersionTLS12}
+	}
+
+	if s.caFile != "" {
+		pem, err := os.ReadFile(s.caFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA bundle set in %s: %w", cst.TLSCAFile, err)
+		}
+		// Private CAs are trusted in addition to the system ones.
+		pool, err := x509.SystemCertPool()
+		if err != nil || pool == nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no PEM encoded certificates found in %s", s.caFile)
+		}
+		t.TLSClientConfig.RootCAs = pool
+	}
+
+	if s.certFile != "" || s.keyFile != "" {
+		if s.certFile == "" || s.keyFile == "" {
+			return nil, fmt.Errorf("both %s and %s must be set to use a client certificate", cst.TLSCertFile, cst.TLSKeyFile)
+		}
+		cert, err := tls.LoadX509KeyPair(s.certFile, s.keyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client certificate: %w", err)
+		}
+		t.TLSClientConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	t.TLSClientConfig.InsecureSkipVerify = s.insecure
+
+	if s.proxy != "" || s.noProxy != "" {
+		// Settings from the profile take precedence over HTTP_PROXY, HTTPS_PROXY and NO_PROXY variables.
+		cfg := httpproxy.FromEnvironment()
+		if s.proxy != "" {
+			if _, err := url.Parse(s.proxy); err != nil {
+				return nil, fmt.Errorf("invalid value of %s: %w", cst.NetworkProxy, err)
+			}
+			cfg.HTTPProxy = s.proxy
+			cfg.HTTPSProxy = s.proxy
+		}
+		if s.noProxy != "" {
+			cfg.NoProxy = s.noProxy
+		}
+		proxyFunc := cfg.ProxyFunc()
+		t.Proxy = func(req *http.Request) (*url.URL, error) {
+			return proxyFunc(req.URL)
+		}
+	}
+
+	return t, nil
+}
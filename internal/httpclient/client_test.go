@@ -0,0 +1,188 @@
+package httpclient
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	cst "github.com/DelineaXPM/dsv-cli/constants"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+)
+
+func mustNoError(t *testing.T, err error) {
+	t.Helper()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+}
+
+func writePEM(t *testing.T, name, blockType string, der []byte) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	mustNoError(t, os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0o600))
+	return path
+}
+
+// newClientCert returns paths to a self-signed client certificate and its key.
+func newClientCert(t *testing.T) (*x509.Certificate, string, string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	mustNoError(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "dsv-cli"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	mustNoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	mustNoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	mustNoError(t, err)
+	return cert, writePEM(t, "client.crt", "CERTIFICATE", der), writePEM(t, "client.key", "EC PRIVATE KEY", keyDER)
+}
+
+func get(t *testing.T, rt http.RoundTripper, url string) (string, error) {
+	t.Helper()
+	resp, err := (&http.Client{Transport: rt}).Get(url)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	b, err := io.ReadAll(resp.Body)
+	return string(b), err
+}
+
+func TestNewTransport_TLS(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+	caFile := writePEM(t, "ca.pem", "CERTIFICATE", server.Certificate().Raw)
+	base := &http.Transport{}
+
+	_, err := get(t, base, server.URL)
+	assert.Error(t, err, "server certificate must not be trusted by default")
+
+	tr, err := newTransport(base, settings{caFile: caFile})
+	mustNoError(t, err)
+	body, err := get(t, tr, server.URL)
+	assert.NoError(t, err)
+	assert.Equal(t, "ok", body)
+	assert.Nil(t, base.TLSClientConfig.RootCAs, "base transport must not be modified")
+
+	tr, err = newTransport(base, settings{insecure: true})
+	mustNoError(t, err)
+	_, err = get(t, tr, server.URL)
+	assert.NoError(t, err)
+
+	_, err = newTransport(base, settings{caFile: filepath.Join(t.TempDir(), "missing.pem")})
+	assert.ErrorContains(t, err, cst.TLSCAFile)
+
+	empty := filepath.Join(t.TempDir(), "empty.pem")
+	mustNoError(t, os.WriteFile(empty, []byte("not a certificate"), 0o600))
+	_, err = newTransport(base, settings{caFile: empty})
+	assert.ErrorContains(t, err, "no PEM encoded certificates")
+}
+
+func TestNewTransport_ClientCertificate(t *testing.T) {
+	clientCert, certFile, keyFile := newClientCert(t)
+	pool := x509.NewCertPool()
+	pool.AddCert(clientCert)
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(r.TLS.PeerCertificates[0].Subject.CommonName))
+	}))
+	server.TLS = &tls.Config{ClientAuth: tls.RequireAndVerifyClientCert, ClientCAs: pool}
+	server.StartTLS()
+	defer server.Close()
+
+	tr, err := newTransport(&http.Transport{}, settings{insecure: true})
+	mustNoError(t, err)
+	_, err = get(t, tr, server.URL)
+	assert.Error(t, err, "server must require a client certificate")
+
+	tr, err = newTransport(&http.Transport{}, settings{insecure: true, certFile: certFile, keyFile: keyFile})
+	mustNoError(t, err)
+	body, err := get(t, tr, server.URL)
+	assert.NoError(t, err)
+	assert.Equal(t, "dsv-cli", body)
+
+	_, err = newTransport(&http.Transport{}, settings{certFile: certFile})
+	assert.ErrorContains(t, err, "both")
+	_, err = newTransport(&http.Transport{}, settings{certFile: keyFile, keyFile: keyFile})
+	assert.ErrorContains(t, err, "failed to load client certificate")
+}
+
+func TestNewTransport_Proxy(t *testing.T) {
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("proxied " + r.URL.Host))
+	}))
+	defer proxy.Close()
+
+	tr, err := newTransport(&http.Transport{}, settings{proxy: proxy.URL})
+	mustNoError(t, err)
+	body, err := get(t, tr, "http://dsv.example.invalid/v1/heartbeat")
+	assert.NoError(t, err)
+	assert.Equal(t, "proxied dsv.example.invalid", body)
+
+	tr, err = newTransport(&http.Transport{}, settings{proxy: proxy.URL, noProxy: "internal.invalid,.example.invalid"})
+	mustNoError(t, err)
+	_, err = get(t, tr, "http://dsv.example.invalid/v1/heartbeat")
+	assert.Error(t, err, "host in no proxy list must be reached directly")
+	body, err = get(t, tr, "http://dsv.other.invalid/v1/heartbeat")
+	assert.NoError(t, err)
+	assert.Equal(t, "proxied dsv.other.invalid", body)
+}
+
+func TestTransport(t *testing.T) {
+	viper.Reset()
+	defer viper.Reset()
+
+	tr, err := Transport()
+	assert.NoError(t, err)
+	assert.Equal(t, http.DefaultTransport, tr, "default transport must be used if nothing is configured")
+
+	var warning bytes.Buffer
+	warningWriter = &warning
+	defer func() { warningWriter = os.Stderr }()
+
+	viper.Set(cst.TLSInsecure, true)
+	tr1, err := Transport()
+	assert.NoError(t, err)
+	tr2, err := Transport()
+	assert.NoError(t, err)
+	assert.Same(t, tr1, tr2, "transports with the same settings must be shared")
+	assert.NotEqual(t, http.DefaultTransport, tr1)
+	assert.Contains(t, warning.String(), "WARNING")
+
+	viper.Set(cst.TLSCertFile, "client.crt")
+	_, err = Transport()
+	assert.Error(t, err)
+
+	viper.Reset()
+	client, err := New()
+	assert.NoError(t, err)
+	assert.IsType(t, &retryTransport{}, client.Transport)
+
+	viper.Set(cst.NetworkProxy, "http://proxy.corp:3128")
+	base := NewDirect().Transport.(*retryTransport).base.(*http.Transport)
+	assert.Nil(t, base.Proxy, "direct client must not use a proxy")
+}
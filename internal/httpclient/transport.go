@@ -0,0 +1,216 @@
+package httpclient
+
+import (
+	"context"
+	"io"
+	"log"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+
+	cst "github.com/DelineaXPM/dsv-cli/constants"
+
+	"github.com/spf13/viper"
+)
+
+const (
+	// DefaultTimeout is the default timeout of a single attempt of an HTTP request.
+	DefaultTimeout = 60 * time.Second
+	// DefaultRetries is the default number of retries of an idempotent HTTP request.
+	DefaultRetries = 3
+
+	retryBaseDelay = 500 * time.Millisecond
+	retryMaxDelay  = 30 * time.Second
+)
+
+type idempotentKey struct{}
+
+// WithIdempotent marks requests made with the context as safe to retry regardless of the HTTP method.
+func WithIdempotent(ctx context.Context) context.Context {
+	return context.WithValue(ctx, idempotentKey{}, true)
+}
+
+// Timeout returns the timeout of a single attempt of an HTTP request. The value can be set
+// in seconds or as a duration string, e.g. "90s" or "2m". Zero disables the timeout.
+func Timeout() time.Duration {
+	v := viper.GetString(cst.NetworkTimeout)
+	if v == "" {
+		return DefaultTimeout
+	}
+	if n, err := strconv.Atoi(v); err == nil && n >= 0 {
+		return time.Duration(n) * time.Second
+	}
+	if d, err := time.ParseDuration(v); err == nil && d >= 0 {
+		return d
+	}
+	log.Printf("Invalid value of %s: %q, using default %s", cst.NetworkTimeout, v, DefaultTimeout)
+	return DefaultTimeout
+}
+
+// Retries returns the number of times an idempotent HTTP request is retried.
+func Retries() int {
+	v := viper.GetString(cst.NetworkRetries)
+	if v == "" {
+		return DefaultRetries
+	}
+	if n, err := strconv.Atoi(v); err == nil && n >= 0 {
+		return n
+	}
+	log.Printf("Invalid value of %s: %q, using default %d", cst.NetworkRetries, v, DefaultRetries)
+	return DefaultRetries
+}
+
+// retryTransport retries idempotent requests on connection errors, 429 and 5xx responses
+// with exponential backoff and jitter. The Retry-After header is honored if present.
+type retryTransport struct {
+	base    http.RoundTripper
+	timeout time.Duration
+	retries int
+	sleep   func(ctx context.Context, d time.Duration) error
+}
+
+// NewRetryTransport wraps the base transport with per-attempt timeouts and retries.
+func NewRetryTransport(base http.RoundTripper, timeout time.Duration, retries int) http.RoundTripper {
+	return &retryTransport{base: base, timeout: timeout, retries: retries, sleep: sleepContext}
+}
+
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	attempts := 1
+	if isIdempotent(req) && (req.Body == nil || req.GetBody != nil) {
+		attempts += t.retries
+	}
+
+	for attempt := 1; ; attempt++ {
+		attemptReq, cancel, err := t.prepare(req, attempt)
+		if err != nil {
+			return nil, err
+		}
+
+		log.Printf("-> %s %s (attempt %d of %d)", req.Method, req.URL, attempt, attempts)
+		startTime := time.Now()
+		resp, err := t.base.RoundTrip(attemptReq)
+		if err == nil {
+			log.Printf("<- %s %s | %s (took: %s)", req.Method, req.URL, resp.Status, time.Since(startTime))
+		} else {
+			log.Printf("<- %s %s | %v (took: %s)", req.Method, req.URL, err, time.Since(startTime))
+		}
+
+		if attempt >= attempts || !shouldRetry(resp, err) || req.Context().Err() != nil {
+			if err != nil {
+				cancel()
+				return nil, err
+			}
+			resp.Body = &cancelOnClose{ReadCloser: resp.Body, cancel: cancel}
+			return resp, nil
+		}
+
+		delay := backoff(attempt)
+		if resp != nil {
+			if after, ok := retryAfter(resp.Header.Get("Retry-After"), time.Now()); ok {
+				delay = after
+			}
+			// Drain the body so that the connection can be reused.
+			_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+			resp.Body.Close()
+		}
+		cancel()
+
+		log.Printf("Retrying %s %s in %s", req.Method, req.URL, delay)
+		if err := t.sleep(req.Context(), delay); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// prepare returns a copy of the request for the given attempt with a fresh body and a timeout.
+func (t *retryTransport) prepare(req *http.Request, attempt int) (*http.Request, context.CancelFunc, error) {
+	ctx, cancel := req.Context(), context.CancelFunc(func() {})
+	if t.timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, t.timeout)
+	}
+	attemptReq := req.Clone(ctx)
+	if attempt > 1 && req.Body != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			cancel()
+			return nil, nil, err
+		}
+		attemptReq.Body = body
+	}
+	return attemptReq, cancel, nil
+}
+
+func isIdempotent(req *http.Request) bool {
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	idempotent, _ := req.Context().Value(idempotentKey{}).(bool)
+	return idempotent
+}
+
+func shouldRetry(resp *http.Response, err error) bool {
+	if err != nil {
+		return true
+	}
+	return resp.StatusCode == http.StatusTooManyRequests ||
+		(resp.StatusCode >= 500 && resp.StatusCode != http.StatusNotImplemented)
+}
+
+// backoff returns an exponentially growing delay with jitter for the given attempt.
+func backoff(attempt int) time.Duration {
+	delay := retryMaxDelay
+	if attempt < 16 {
+		if d := retryBaseDelay << (attempt - 1); d < retryMaxDelay {
+			delay = d
+		}
+	}
+	// Full delay is between a half and the whole exponential value.
+	return delay/2 + time.Duration(rand.Int63n(int64(delay/2)+1))
+}
+
+// retryAfter parses the value of the Retry-After header which is either a number of seconds or an HTTP date.
+func retryAfter(value string, now time.Time) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	var d time.Duration
+	if seconds, err := strconv.Atoi(value); err == nil {
+		d = time.Duration(seconds) * time.Second
+	} else if date, err := http.ParseTime(value); err == nil {
+		d = date.Sub(now)
+	} else {
+		return 0, false
+	}
+	if d < 0 {
+		d = 0
+	}
+	if d > retryMaxDelay {
+		d = retryMaxDelay
+	}
+	return d, true
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// cancelOnClose releases the context of an attempt once the response body is consumed.
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelOnClose) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
@@ -0,0 +1,159 @@
+package httpclient
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	cst "github.com/DelineaXPM/dsv-cli/constants"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestRetryTransport(timeout time.Duration, retries int, delays *[]time.Duration) *retryTransport {
+	return &retryTransport{
+		base:    http.DefaultTransport,
+		timeout: timeout,
+		retries: retries,
+		sleep: func(_ context.Context, d time.Duration) error {
+			*delays = append(*delays, d)
+			return nil
+		},
+	}
+}
+
+func TestRetryTransport(t *testing.T) {
+	var calls int32
+	var bodies []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&calls, 1)
+		b, _ := io.ReadAll(r.Body)
+		bodies = append(bodies, string(b))
+		switch {
+		case n == 1:
+			w.Header().Set("Retry-After", "2")
+			w.WriteHeader(http.StatusTooManyRequests)
+		case n == 2:
+			w.WriteHeader(http.StatusBadGateway)
+		default:
+			_, _ = w.Write([]byte("ok"))
+		}
+	}))
+	defer server.Close()
+
+	var delays []time.Duration
+	client := &http.Client{Transport: newTestRetryTransport(time.Second, 3, &delays)}
+
+	req, _ := http.NewRequest(http.MethodPut, server.URL, strings.NewReader("payload"))
+	resp, err := client.Do(req)
+	assert.NoError(t, err)
+	b, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	assert.Equal(t, "ok", string(b))
+	assert.Equal(t, int32(3), calls)
+	assert.Equal(t, []string{"payload", "payload", "payload"}, bodies, "body must be sent with every attempt")
+	if assert.Len(t, delays, 2) {
+		assert.Equal(t, 2*time.Second, delays[0], "Retry-After must be honored")
+		assert.True(t, delays[1] >= retryBaseDelay && delays[1] <= 2*retryBaseDelay)
+	}
+}
+
+func TestRetryTransport_NotIdempotent(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	var delays []time.Duration
+	client := &http.Client{Transport: newTestRetryTransport(time.Second, 3, &delays)}
+
+	resp, err := client.Post(server.URL, "application/json", strings.NewReader("{}"))
+	assert.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+	assert.Equal(t, int32(1), calls)
+
+	// POST requests marked as idempotent are retried.
+	calls = 0
+	req, _ := http.NewRequestWithContext(WithIdempotent(context.Background()), http.MethodPost, server.URL, strings.NewReader("{}"))
+	resp, err = client.Do(req)
+	assert.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, int32(4), calls)
+}
+
+func TestRetryTransport_Timeout(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			select {
+			case <-r.Context().Done():
+			case <-time.After(time.Second):
+			}
+			return
+		}
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	var delays []time.Duration
+	client := &http.Client{Transport: newTestRetryTransport(50*time.Millisecond, 1, &delays)}
+
+	resp, err := client.Get(server.URL)
+	assert.NoError(t, err)
+	b, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	assert.Equal(t, "ok", string(b))
+	assert.Equal(t, int32(2), calls)
+
+	calls = 0
+	client = &http.Client{Transport: newTestRetryTransport(50*time.Millisecond, 0, &delays)}
+	_, err = client.Get(server.URL)
+	assert.Error(t, err)
+}
+
+func TestRetryAfter(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	d, ok := retryAfter("5", now)
+	assert.True(t, ok)
+	assert.Equal(t, 5*time.Second, d)
+
+	d, ok = retryAfter(now.Add(10*time.Second).Format(http.TimeFormat), now)
+	assert.True(t, ok)
+	assert.Equal(t, 10*time.Second, d)
+
+	d, ok = retryAfter("3600", now)
+	assert.True(t, ok)
+	assert.Equal(t, retryMaxDelay, d)
+
+	_, ok = retryAfter("soon", now)
+	assert.False(t, ok)
+	_, ok = retryAfter("", now)
+	assert.False(t, ok)
+}
+
+func TestTimeoutAndRetries(t *testing.T) {
+	viper.Reset()
+	defer viper.Reset()
+	assert.Equal(t, DefaultTimeout, Timeout())
+	assert.Equal(t, DefaultRetries, Retries())
+
+	viper.Set(cst.NetworkTimeout, "15")
+	viper.Set(cst.NetworkRetries, "0")
+	assert.Equal(t, 15*time.Second, Timeout())
+	assert.Equal(t, 0, Retries())
+
+	viper.Set(cst.NetworkTimeout, "2m")
+	viper.Set(cst.NetworkRetries, "many")
+	assert.Equal(t, 2*time.Minute, Timeout())
+	assert.Equal(t, DefaultRetries, Retries())
+}
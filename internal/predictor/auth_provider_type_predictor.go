@@ -7,5 +7,5 @@ import (
 type AuthProviderTypePredictor struct{}
 
 func (p AuthProviderTypePredictor) Predict(a complete.Args) (prediction []string) {
-	return []string{"azure", "aws", "gcp", "thycoticone"}
+	return []string{"azure", "aws", "gcp", "thycoticone", "jwt"}
 }
@@ -16,5 +16,6 @@ func (p AuthTypePredictor) Predict(a complete.Args) (prediction []string) {
 		string(auth.FederatedAws),
 		string(auth.FederatedAzure),
 		string(auth.FederatedGcp),
+		string(auth.Jwt),
 	}
 }
@@ -9,5 +9,5 @@ import (
 type EncodingTypePredictor struct{}
 
 func (p EncodingTypePredictor) Predict(a complete.Args) (prediction []string) {
-	return []string{cst.Json, cst.YamlShort}
+	return []string{cst.Json, cst.YamlShort, cst.Dotenv, cst.Shell, cst.K8s, cst.DockerEnv, cst.NDJson, cst.Table, cst.CSV}
 }
@@ -82,6 +82,14 @@ func New(params *Params) *Wrapper {
 }
 
 func (f *FlagValue) Set(value string) error {
+	// A flag of type "list" can be repeated, values are joined with a comma.
+	if f.FlagType == "list" {
+		if f.Val != "" {
+			value = f.Val + "," + value
+		}
+		f.Val = value
+		return nil
+	}
 	if f.FlagType == "" || f.FlagType == "string" {
 		if len(value) > 1 && strings.HasPrefix(value, "@") {
 			f.FlagType = "file"
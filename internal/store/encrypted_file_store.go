@@ -0,0 +1,622 @@
+package store
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	cst "github.com/DelineaXPM/dsv-cli/constants"
+	"github.com/DelineaXPM/dsv-cli/utils"
+
+	homedir "github.com/mitchellh/go-homedir"
+	"github.com/spf13/viper"
+	"golang.org/x/crypto/scrypt"
+)
+
+// Supported sources of the key of the encrypted file store.
+const (
+	KeySourcePassphrase = "passphrase"
+	KeySourceFile       = "file"
+	KeySourceKeyring    = "keyring"
+)
+
+const (
+	// encryptedMagic starts every entry written by the encrypted file store. Entries without it
+	// were written by the plain file store and are encrypted when the store is opened.
+	encryptedMagic = "dsvenc1:"
+	saltSize       = 16
+
+	defaultKeyFileName = ".thy.key"
+	keyringEntry       = cst.StoreRoot + "-cache-key"
+	rekeyLockName      = "rekey"
+)
+
+// scryptN is the CPU/memory cost of the key derivation. Tests lower it.
+var scryptN = 1 << 15
+
+// encryptedFileStore is a file store which encrypts entries with AES-GCM. The encryption key
+// is derived with scrypt from the key material of a key source and a random salt stored in every entry.
+type encryptedFileStore struct {
+	*fileStore
+	source keySource
+
+	once    sync.Once
+	initErr error
+
+	mu       sync.Mutex
+	material []byte
+	salt     []byte
+	derived  map[string][]byte
+}
+
+// keySource provides key material for the encrypted file store.
+type keySource interface {
+	// load returns the key material. Sources which can hold a random key create it on first use.
+	load() ([]byte, error)
+	// replace saves new key material.
+	replace(material []byte) error
+	// random reports whether the source holds a random key rather than a user provided secret.
+	random() bool
+}
+
+// NewEncryptedFileStore returns a file store which encrypts data at rest. The key source is read
+// from store.key.source, store.key.file and store.passphrase on first use.
+func NewEncryptedFileStore(basePath string) Store {
+	return &encryptedFileStore{
+		fileStore: NewFileStore(basePath).(*fileStore),
+		source:    keySourceFromConfig(),
+		derived:   make(map[string][]byte),
+	}
+}
+
+// KeySource returns the configured key source of the encrypted file store. Without store.key.source
+// the passphrase is used if it is set and the key file otherwise.
+func KeySource() string {
+	if source := viper.GetString(cst.StoreKeySource); source != "" {
+		return source
+	}
+	if viper.GetString(cst.StorePassphrase) != "" {
+		return KeySourcePassphrase
+	}
+	return KeySourceFile
+}
+
+func keySourceFromConfig() keySource {
+	switch source := KeySource(); source {
+	case KeySourcePassphrase:
+		return passphraseKey{}
+	case KeySourceFile:
+		return fileKey{path: viper.GetString(cst.StoreKeyFile)}
+	case KeySourceKeyring:
+		return keyringKey{}
+	default:
+		return invalidKey{source: source}
+	}
+}
+
+func (s *encryptedFileStore) Store(key string, data any) error {
+	marshaled, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	return s.write(key, marshaled)
+}
+
+func (s *encryptedFileStore) StoreString(key string, data string) error {
+	return s.write(key, []byte(data))
+}
+
+func (s *encryptedFileStore) Get(key string, out any) error {
+	if !s.internalStore.Has(key) {
+		return nil
+	}
+	b, err := s.read(key)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, out)
+}
+
+// readString returns an entry written with StoreString. It fails with os.ErrNotExist if there is no such entry.
+func (s *encryptedFileStore) readString(key string) (string, error) {
+	if !s.internalStore.Has(key) {
+		return "", fmt.Errorf("read '%s': %w", key, os.ErrNotExist)
+	}
+	b, err := s.read(key)
+	return string(b), err
+}
+
+func (s *encryptedFileStore) write(key string, plaintext []byte) error {
+	if err := s.init(); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	material, salt := s.material, s.salt
+	s.mu.Unlock()
+	sealed, err := s.seal(material, salt, plaintext)
+	if err != nil {
+		return err
+	}
+	return s.fileStore.write(key, sealed)
+}
+
+func (s *encryptedFileStore) read(key string) ([]byte, error) {
+	if err := s.init(); err != nil {
+		return nil, err
+	}
+	b, err := s.fileStore.read(key)
+	if err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	material := s.material
+	s.mu.Unlock()
+	return s.open(material, b, key)
+}
+
+// init loads the key, finishes an interrupted rekey and encrypts entries left by the plain file store.
+func (s *encryptedFileStore) init() error {
+	s.once.Do(func() {
+		material, err := s.source.load()
+		if err != nil {
+			s.initErr = fmt.Errorf("failed to load the key of the encrypted file store: %w", err)
+			return
+		}
+		s.material = material
+		if err := s.recoverRekey(); err != nil {
+			s.initErr = err
+			return
+		}
+		s.initErr = s.migrate()
+	})
+	return s.initErr
+}
+
+// migrate encrypts plain entries. New entries reuse the salt of existing ones, so the key is derived only once.
+func (s *encryptedFileStore) migrate() error {
+	keys, err := s.List("")
+	if err != nil {
+		return err
+	}
+	var plain []string
+	for _, key := range keys {
+		b, err := s.internalStore.Read(key)
+		if err != nil {
+			return fmt.Errorf("failed to read '%s' for encryption: %w", key, err)
+		}
+		if !bytes.HasPrefix(b, []byte(encryptedMagic)) {
+			plain = append(plain, key)
+		} else if s.salt == nil && len(b) >= len(encryptedMagic)+saltSize {
+			s.salt = b[len(encryptedMagic) : len(encryptedMagic)+saltSize]
+		}
+	}
+	if s.salt == nil {
+		if s.salt, err = newSalt(); err != nil {
+			return err
+		}
+	}
+	for _, key := range plain {
+		if err := s.encryptPlain(key); err != nil {
+			return fmt.Errorf("failed to encrypt '%s': %w", key, err)
+		}
+	}
+	return nil
+}
+
+// encryptPlain encrypts a plain entry unless another process has done it meanwhile.
+func (s *encryptedFileStore) encryptPlain(key string) error {
+	unlock, err := s.lockEntries()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	b, err := s.internalStore.Read(key)
+	if err != nil || bytes.HasPrefix(b, []byte(encryptedMagic)) {
+		return err
+	}
+	sealed, err := s.seal(s.material, s.salt, b)
+	if err != nil {
+		return err
+	}
+	return s.internalStore.Write(key, sealed)
+}
+
+func (s *encryptedFileStore) seal(material []byte, salt []byte, plaintext []byte) ([]byte, error) {
+	gcm, err := s.cipher(material, salt)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+	out := make([]byte, 0, len(encryptedMagic)+len(salt)+len(nonce)+len(plaintext)+gcm.Overhead())
+	out = append(out, encryptedMagic...)
+	out = append(out, salt...)
+	out = append(out, nonce...)
+	return gcm.Seal(out, nonce, plaintext, []byte(encryptedMagic)), nil
+}
+
+func (s *encryptedFileStore) open(material []byte, data []byte, key string) ([]byte, error) {
+	if !bytes.HasPrefix(data, []byte(encryptedMagic)) {
+		// Written by another process which uses the plain file store.
+		return data, nil
+	}
+	data = data[len(encryptedMagic):]
+	if len(data) < saltSize {
+		return nil, fmt.Errorf("failed to decrypt '%s': data is truncated", key)
+	}
+	salt, data := data[:saltSize], data[saltSize:]
+	gcm, err := s.cipher(material, salt)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < gcm.NonceSize() {
+		return nil, fmt.Errorf("failed to decrypt '%s': data is truncated", key)
+	}
+	nonce, ciphertext := data[:gcm.NonceSize()], data[gcm.NonceSize():]
+	plaintext, err := gcm.Open(nil, nonce, ciphertext, []byte(encryptedMagic))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt '%s': wrong key or corrupted data", key)
+	}
+	return plaintext, nil
+}
+
+// cipher returns AES-GCM with the key derived from the material and the salt. Derived keys are
+// cached since scrypt is deliberately slow and most entries share the salt.
+func (s *encryptedFileStore) cipher(material []byte, salt []byte) (cipher.AEAD, error) {
+	s.mu.Lock()
+	id := string(material) + "\x00" + string(salt)
+	key, ok := s.derived[id]
+	s.mu.Unlock()
+	if !ok {
+		var err error
+		key, err = scrypt.Key(material, salt, scryptN, 8, 1, 32)
+		if err != nil {
+			return nil, err
+		}
+		s.mu.Lock()
+		s.derived[id] = key
+		s.mu.Unlock()
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// Rekey re-encrypts all entries of the encrypted file store with a new key and returns the number
+// of entries. For the passphrase key source newPassphrase is the new passphrase, which must then be
+// used instead of the old one. Other key sources get a new random key.
+func Rekey(s Store, newPassphrase string) (int, error) {
+	es, ok := s.(*encryptedFileStore)
+	if !ok {
+		return 0, fmt.Errorf("store type is not '%s'", FileEncrypted)
+	}
+	if err := es.init(); err != nil {
+		return 0, err
+	}
+
+	var material []byte
+	if es.source.random() {
+		if newPassphrase != "" {
+			return 0, fmt.Errorf("a new passphrase can be set only for the '%s' key source", KeySourcePassphrase)
+		}
+		var err error
+		if material, err = newRandomKey(); err != nil {
+			return 0, err
+		}
+	} else {
+		if newPassphrase == "" {
+			return 0, fmt.Errorf("new passphrase cannot be empty")
+		}
+		material = []byte(newPassphrase)
+	}
+
+	unlock, err := es.Lock(rekeyLockName)
+	if err != nil {
+		return 0, err
+	}
+	defer unlock()
+
+	keys, err := es.List("")
+	if err != nil {
+		return 0, err
+	}
+	// Decrypt everything before anything is changed, so a wrong key fails early.
+	plaintexts := make(map[string][]byte, len(keys))
+	for _, key := range keys {
+		b, err := es.read(key)
+		if err != nil {
+			return 0, err
+		}
+		plaintexts[key] = b
+	}
+
+	salt, err := newSalt()
+	if err != nil {
+		return 0, err
+	}
+	// The entries are staged before the key is replaced and moved into place after, so an interrupted
+	// rekey leaves either the old key with the old entries or the new key with entries init can finish moving.
+	if err := es.stageRekey(material, salt, plaintexts); err != nil {
+		return 0, fmt.Errorf("failed to re-encrypt the cache: %w", err)
+	}
+	if err := es.source.replace(material); err != nil {
+		_ = os.RemoveAll(es.rekeyDir())
+		return 0, fmt.Errorf("failed to save the new key: %w", err)
+	}
+	es.mu.Lock()
+	es.material, es.salt = material, salt
+	es.mu.Unlock()
+	if err := es.applyRekey(); err != nil {
+		return 0, err
+	}
+	return len(keys), nil
+}
+
+// rekeyDir returns the directory with the entries staged by Rekey. Like lock files it is kept next to
+// the store directory. It is written under the same name with a ".tmp" suffix and renamed when complete.
+func (s *encryptedFileStore) rekeyDir() string {
+	basePath, _ := homedir.Expand(s.internalStore.BasePath)
+	return strings.TrimRight(filepath.Clean(basePath), string(filepath.Separator)) + ".rekey"
+}
+
+// stageRekey writes the entries encrypted with the new key material and salt to the staging directory.
+func (s *encryptedFileStore) stageRekey(material []byte, salt []byte, plaintexts map[string][]byte) error {
+	dir := s.rekeyDir()
+	tmpDir := dir + ".tmp"
+	for _, d := range []string{dir, tmpDir} {
+		if err := os.RemoveAll(d); err != nil {
+			return err
+		}
+	}
+	if err := os.MkdirAll(tmpDir, 0o700); err != nil {
+		return err
+	}
+	for key, plaintext := range plaintexts {
+		sealed, err := s.seal(material, salt, plaintext)
+		if err != nil {
+			return err
+		}
+		if err := utils.WriteFileAtomic(filepath.Join(tmpDir, key), sealed, 0o600); err != nil {
+			_ = os.RemoveAll(tmpDir)
+			return err
+		}
+	}
+	return os.Rename(tmpDir, dir)
+}
+
+// applyRekey moves the staged entries over the entries of the store and removes the staging directory.
+func (s *encryptedFileStore) applyRekey() error {
+	dir := s.rekeyDir()
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	unlock, err := s.lockEntries()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	basePath, _ := homedir.Expand(s.internalStore.BasePath)
+	for _, entry := range entries {
+		key := entry.Name()
+		if err := os.Rename(filepath.Join(dir, key), filepath.Join(basePath, key)); err != nil {
+			return fmt.Errorf("failed to replace '%s' with the re-encrypted entry: %w", key, err)
+		}
+	}
+	return os.RemoveAll(dir)
+}
+
+// recoverRekey finishes or discards a rekey which was interrupted. Staged entries which decrypt with the
+// loaded key are moved into place. Otherwise a random key was never replaced and they are discarded,
+// while with a passphrase the new passphrase is required to finish the rekey.
+func (s *encryptedFileStore) recoverRekey() error {
+	dir := s.rekeyDir()
+	_ = os.RemoveAll(dir + ".tmp")
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	unlock, err := s.Lock(rekeyLockName)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	if entries, err = os.ReadDir(dir); errors.Is(err, os.ErrNotExist) {
+		// Finished by another process meanwhile.
+		return nil
+	} else if err != nil {
+		return err
+	}
+	if len(entries) == 0 {
+		return os.RemoveAll(dir)
+	}
+
+	key := entries[0].Name()
+	b, err := os.ReadFile(filepath.Join(dir, key))
+	if err != nil {
+		return err
+	}
+	if _, err := s.open(s.material, b, key); err != nil {
+		if s.source.random() {
+			return os.RemoveAll(dir)
+		}
+		return fmt.Errorf("an interrupted rekey left entries encrypted with a new passphrase in %s, "+
+			"set %s to the new passphrase to finish it", dir, cst.StorePassphrase)
+	}
+	return s.applyRekey()
+}
+
+// readEncryptedString returns an entry written with StoreString to the encrypted file store.
+func readEncryptedString(fileName string) (string, error) {
+	s, err := GetStore(FileEncrypted)
+	if err != nil {
+		return "", err
+	}
+	es, ok := s.(*encryptedFileStore)
+	if !ok {
+		return "", fmt.Errorf("store type is not '%s'", FileEncrypted)
+	}
+	return es.readString(fileName)
+}
+
+// passphraseKey uses the store.passphrase setting, usually set with the THY_STORE_PASSPHRASE variable.
+type passphraseKey struct{}
+
+func (passphraseKey) load() ([]byte, error) {
+	passphrase := viper.GetString(cst.StorePassphrase)
+	if passphrase == "" {
+		return nil, fmt.Errorf("%s is not set", cst.StorePassphrase)
+	}
+	return []byte(passphrase), nil
+}
+
+func (passphraseKey) replace([]byte) error { return nil }
+
+func (passphraseKey) random() bool { return false }
+
+// fileKey keeps a random key in a file, ~/.thy.key by default. The default file is in the same home
+// directory as the default store directory ~/.thy, so it protects copies of the store directory but not
+// a home directory which can be read by others. store.key.file can point to a separately protected location.
+type fileKey struct {
+	path string
+}
+
+func (k fileKey) filePath() (string, error) {
+	if k.path != "" {
+		return homedir.Expand(k.path)
+	}
+	home, err := homedir.Dir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, defaultKeyFileName), nil
+}
+
+func (k fileKey) load() ([]byte, error) {
+	p, err := k.filePath()
+	if err != nil {
+		return nil, err
+	}
+	b, err := os.ReadFile(p)
+	if errors.Is(err, os.ErrNotExist) {
+		material, err := newRandomKey()
+		if err != nil {
+			return nil, err
+		}
+		return material, k.replace(material)
+	}
+	if err != nil {
+		return nil, err
+	}
+	material := bytes.TrimSpace(b)
+	if len(material) == 0 {
+		return nil, fmt.Errorf("key file %s is empty", p)
+	}
+	return material, nil
+}
+
+func (k fileKey) replace(material []byte) error {
+	p, err := k.filePath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(p), 0o700); err != nil {
+		return err
+	}
+	return utils.WriteFileAtomic(p, append(material, '\n'), 0o600)
+}
+
+func (fileKey) random() bool { return true }
+
+// keyringKey keeps a random key in the OS keyring: pass on Linux and the Credential Manager on Windows.
+type keyringKey struct{}
+
+func (keyringKey) keyring() (Store, error) {
+	switch utils.GetEnvProviderFunc().GetOs() {
+	case "linux":
+		return NewPassStore(), nil
+	case "windows":
+		return NewWinStore(), nil
+	default:
+		return nil, fmt.Errorf("'%s' key source is supported on linux and windows only", KeySourceKeyring)
+	}
+}
+
+func (k keyringKey) load() ([]byte, error) {
+	s, err := k.keyring()
+	if err != nil {
+		return nil, err
+	}
+	var stored string
+	if err := s.Get(keyringEntry, &stored); err != nil {
+		return nil, err
+	}
+	if stored != "" {
+		return []byte(stored), nil
+	}
+	material, err := newRandomKey()
+	if err != nil {
+		return nil, err
+	}
+	return material, k.replace(material)
+}
+
+func (k keyringKey) replace(material []byte) error {
+	s, err := k.keyring()
+	if err != nil {
+		return err
+	}
+	return s.Store(keyringEntry, string(material))
+}
+
+func (keyringKey) random() bool { return true }
+
+func newSalt() ([]byte, error) {
+	salt := make([]byte, saltSize)
+	if _, err := io.ReadFull(rand.Reader, salt); err != nil {
+		return nil, err
+	}
+	return salt, nil
+}
+
+// newRandomKey returns random key material encoded as text, so it can be kept in a file or a keyring as is.
+func newRandomKey() ([]byte, error) {
+	b := make([]byte, 32)
+	if _, err := io.ReadFull(rand.Reader, b); err != nil {
+		return nil, err
+	}
+	return []byte(base64.StdEncoding.EncodeToString(b)), nil
+}
+
+type invalidKey struct {
+	source string
+}
+
+func (k invalidKey) load() ([]byte, error) {
+	return nil, fmt.Errorf("'%s' key source not supported. Please choose from: ['%s','%s','%s']",
+		k.source, KeySourcePassphrase, KeySourceFile, KeySourceKeyring)
+}
+
+func (k invalidKey) replace([]byte) error {
+	_, err := k.load()
+	return err
+}
+
+func (invalidKey) random() bool { return false }
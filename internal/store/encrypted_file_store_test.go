@@ -0,0 +1,193 @@
+package store
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	cst "github.com/DelineaXPM/dsv-cli/constants"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestEncryptedFileStore(t *testing.T, dir string) *encryptedFileStore {
+	t.Helper()
+	return NewEncryptedFileStore(dir).(*encryptedFileStore)
+}
+
+func TestEncryptedFileStore(t *testing.T) {
+	defer func(n int) { scryptN = n }(scryptN)
+	scryptN = 1 << 10
+	viper.Reset()
+	defer viper.Reset()
+
+	dir := t.TempDir()
+	keyFile := filepath.Join(dir, "key")
+	storeDir := filepath.Join(dir, "store")
+	viper.Set(cst.StoreKeyFile, keyFile)
+
+	// Entries written by the plain file store are encrypted when the encrypted store is opened.
+	plain := NewFileStore(storeDir)
+	assert.NoError(t, plain.Store("token", tokenData{Token: []byte("GIyZDY5O")}))
+
+	s := newTestEncryptedFileStore(t, storeDir)
+	assert.NoError(t, s.Store("secret", map[string]string{"password": "p@ssw0rd"}))
+	assert.NoError(t, s.StoreString("encryptionkey", "raw-key"))
+
+	for _, name := range []string{"token", "secret", "encryptionkey"} {
+		b, err := os.ReadFile(filepath.Join(storeDir, name))
+		assert.NoError(t, err)
+		assert.True(t, len(b) > len(encryptedMagic) && string(b[:len(encryptedMagic)]) == encryptedMagic, name)
+		assert.NotContains(t, string(b), "p@ssw0rd")
+		assert.NotContains(t, string(b), "raw-key")
+	}
+	info, err := os.Stat(keyFile)
+	assert.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+
+	// A new process reads entries with the key from the key file.
+	s = newTestEncryptedFileStore(t, storeDir)
+	var token tokenData
+	assert.NoError(t, s.Get("token", &token))
+	assert.Equal(t, []byte("GIyZDY5O"), token.Token)
+	raw, err := s.readString("encryptionkey")
+	assert.NoError(t, err)
+	assert.Equal(t, "raw-key", raw)
+	_, err = s.readString("missing")
+	assert.ErrorIs(t, err, os.ErrNotExist)
+
+	// Rekey replaces the key file and keeps the data readable.
+	oldKey, _ := os.ReadFile(keyFile)
+	n, err := Rekey(s, "")
+	assert.NoError(t, err)
+	assert.Equal(t, 3, n)
+	newKey, _ := os.ReadFile(keyFile)
+	assert.NotEqual(t, oldKey, newKey)
+
+	s = newTestEncryptedFileStore(t, storeDir)
+	secret := map[string]string{}
+	assert.NoError(t, s.Get("secret", &secret))
+	assert.Equal(t, "p@ssw0rd", secret["password"])
+
+	// The old key cannot decrypt the data anymore.
+	assert.NoError(t, os.WriteFile(keyFile, oldKey, 0o600))
+	s = newTestEncryptedFileStore(t, storeDir)
+	assert.ErrorContains(t, s.Get("secret", &secret), "wrong key or corrupted data")
+
+	_, err = Rekey(NewFileStore(storeDir), "")
+	assert.ErrorContains(t, err, "store type is not 'file_encrypted'")
+}
+
+func TestEncryptedFileStorePassphrase(t *testing.T) {
+	defer func(n int) { scryptN = n }(scryptN)
+	scryptN = 1 << 10
+	viper.Reset()
+	defer viper.Reset()
+
+	storeDir := t.TempDir()
+	viper.Set(cst.StoreKeySource, KeySourcePassphrase)
+	s := newTestEncryptedFileStore(t, storeDir)
+	assert.ErrorContains(t, s.Store("k", "v"), "store.passphrase is not set")
+
+	viper.Set(cst.StorePassphrase, "old")
+	viper.Set(cst.StoreKeySource, "")
+	assert.Equal(t, KeySourcePassphrase, KeySource())
+	s = newTestEncryptedFileStore(t, storeDir)
+	assert.NoError(t, s.Store("k", "v"))
+
+	_, err := Rekey(s, "")
+	assert.ErrorContains(t, err, "new passphrase cannot be empty")
+	n, err := Rekey(s, "new")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, n)
+
+	var v string
+	s = newTestEncryptedFileStore(t, storeDir)
+	assert.Error(t, s.Get("k", &v))
+
+	viper.Set(cst.StorePassphrase, "new")
+	s = newTestEncryptedFileStore(t, storeDir)
+	assert.NoError(t, s.Get("k", &v))
+	assert.Equal(t, "v", v)
+
+	viper.Set(cst.StoreKeySource, "unknown")
+	s = newTestEncryptedFileStore(t, storeDir)
+	assert.ErrorContains(t, s.Get("k", &v), "'unknown' key source not supported")
+}
+
+type failingKey struct {
+	keySource
+}
+
+func (failingKey) replace([]byte) error { return errors.New("keyring unavailable") }
+
+func TestRekeyInterrupted(t *testing.T) {
+	defer func(n int) { scryptN = n }(scryptN)
+	scryptN = 1 << 10
+	viper.Reset()
+	defer viper.Reset()
+
+	dir := t.TempDir()
+	keyFile := filepath.Join(dir, "key")
+	storeDir := filepath.Join(dir, "store")
+	viper.Set(cst.StoreKeyFile, keyFile)
+
+	s := newTestEncryptedFileStore(t, storeDir)
+	assert.NoError(t, s.StoreString("a", "value-a"))
+	assert.NoError(t, s.StoreString("b", "value-b"))
+	oldKey, _ := os.ReadFile(keyFile)
+
+	// The old key and entries are kept if the new key cannot be saved.
+	s = newTestEncryptedFileStore(t, storeDir)
+	s.source = failingKey{s.source}
+	_, err := Rekey(s, "")
+	assert.ErrorContains(t, err, "failed to save the new key: keyring unavailable")
+	key, _ := os.ReadFile(keyFile)
+	assert.Equal(t, oldKey, key)
+	assert.NoDirExists(t, s.rekeyDir())
+	s = newTestEncryptedFileStore(t, storeDir)
+	v, err := s.readString("a")
+	assert.NoError(t, err)
+	assert.Equal(t, "value-a", v)
+
+	// Staged entries are discarded if the process stops before the key is replaced.
+	newKey, err := newRandomKey()
+	assert.NoError(t, err)
+	salt, err := newSalt()
+	assert.NoError(t, err)
+	plaintexts := map[string][]byte{"a": []byte("value-a"), "b": []byte("value-b")}
+	assert.NoError(t, s.stageRekey(newKey, salt, plaintexts))
+	s = newTestEncryptedFileStore(t, storeDir)
+	v, err = s.readString("b")
+	assert.NoError(t, err)
+	assert.Equal(t, "value-b", v)
+	assert.NoDirExists(t, s.rekeyDir())
+
+	// Staged entries are moved into place if the process stops after the key is replaced.
+	assert.NoError(t, s.stageRekey(newKey, salt, plaintexts))
+	assert.NoError(t, s.source.replace(newKey))
+	s = newTestEncryptedFileStore(t, storeDir)
+	for name, want := range plaintexts {
+		v, err = s.readString(name)
+		assert.NoError(t, err)
+		assert.Equal(t, string(want), v)
+	}
+	assert.NoDirExists(t, s.rekeyDir())
+
+	// With a passphrase the new one is required once the entries are staged.
+	viper.Set(cst.StorePassphrase, "old")
+	s = newTestEncryptedFileStore(t, storeDir)
+	assert.NoError(t, s.StoreString("a", "value-a"))
+	assert.NoError(t, s.StoreString("b", "value-b"))
+	assert.NoError(t, s.stageRekey([]byte("new"), salt, plaintexts))
+	s = newTestEncryptedFileStore(t, storeDir)
+	_, err = s.readString("a")
+	assert.ErrorContains(t, err, "set store.passphrase to the new passphrase")
+	viper.Set(cst.StorePassphrase, "new")
+	s = newTestEncryptedFileStore(t, storeDir)
+	v, err = s.readString("a")
+	assert.NoError(t, err)
+	assert.Equal(t, "value-a", v)
+}
@@ -0,0 +1,89 @@
+package store
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	cst "github.com/DelineaXPM/dsv-cli/constants"
+
+	homedir "github.com/mitchellh/go-homedir"
+	"github.com/spf13/viper"
+)
+
+const (
+	defaultLockTimeout = 30 * time.Second
+	lockRetryInterval  = 50 * time.Millisecond
+	entriesLockName    = "entries"
+)
+
+var lockNameInvalidChars = regexp.MustCompile(`[^A-Za-z0-9._-]`)
+
+// Locker is implemented by stores which can hold locks shared by all CLI processes using the store.
+type Locker interface {
+	// Lock acquires the lock with the given name. It waits at most store.lock.timeout (30s by default)
+	// and returns a function which releases the lock. Locks are released by the operating system
+	// if a process exits without releasing them.
+	Lock(name string) (func(), error)
+}
+
+// Lock implements Locker for the file store with an advisory lock on a file next to the store directory.
+// Names never clash with the lock which guards reads and writes of store keys.
+func (s *fileStore) Lock(name string) (func(), error) {
+	return lockFile(lockPath(s.internalStore.BasePath, "lock-"+name))
+}
+
+// lockEntries acquires the lock which guards reads, writes and deletions of all store keys.
+// A single lock per store directory keeps the number of lock files fixed however many keys are stored.
+func (s *fileStore) lockEntries() (func(), error) {
+	return lockFile(lockPath(s.internalStore.BasePath, entriesLockName))
+}
+
+// lockPath returns the path of a lock file. Lock files are kept next to the store directory,
+// so they never show up as store keys.
+func lockPath(basePath string, name string) string {
+	basePath, _ = homedir.Expand(basePath)
+	dir := strings.TrimRight(filepath.Clean(basePath), string(filepath.Separator)) + ".locks"
+	return filepath.Join(dir, lockNameInvalidChars.ReplaceAllString(name, "_")+".lock")
+}
+
+func lockFile(path string) (func(), error) {
+	timeout := defaultLockTimeout
+	if s := viper.GetString(cst.StoreLockTimeout); s != "" {
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s %q: %w", cst.StoreLockTimeout, s, err)
+		}
+		timeout = d
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	deadline := time.Now().Add(timeout)
+	for {
+		locked, err := tryLockFile(f)
+		if err != nil {
+			f.Close()
+			return nil, fmt.Errorf("failed to lock %s: %w", path, err)
+		}
+		if locked {
+			return func() {
+				_ = unlockFile(f)
+				f.Close()
+			}, nil
+		}
+		if time.Now().After(deadline) {
+			f.Close()
+			return nil, fmt.Errorf("timed out after %s waiting for another process to release %s", timeout, path)
+		}
+		time.Sleep(lockRetryInterval)
+	}
+}
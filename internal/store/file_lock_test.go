@@ -0,0 +1,111 @@
+package store
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	cst "github.com/DelineaXPM/dsv-cli/constants"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLock(t *testing.T) {
+	viper.Reset()
+	defer viper.Reset()
+	viper.Set(cst.StoreLockTimeout, "200ms")
+
+	s := NewFileStore(t.TempDir())
+	locker := s.(Locker)
+
+	unlock, err := locker.Lock("token-a")
+	assert.NoError(t, err)
+
+	// Locks with other names and store keys are independent.
+	unlockB, err := locker.Lock("token-b")
+	assert.NoError(t, err)
+	unlockB()
+	assert.NoError(t, s.StoreString("token-a", "value"))
+
+	start := time.Now()
+	_, err = locker.Lock("token-a")
+	assert.ErrorContains(t, err, "timed out after 200ms waiting for another process")
+	assert.GreaterOrEqual(t, time.Since(start), 200*time.Millisecond)
+
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		unlock()
+	}()
+	unlock, err = locker.Lock("token-a")
+	assert.NoError(t, err)
+	unlock()
+
+	// Store keys are not affected by lock files.
+	keys, err := s.List("")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"token-a"}, keys)
+
+	viper.Set(cst.StoreLockTimeout, "soon")
+	_, err = locker.Lock("token-a")
+	assert.ErrorContains(t, err, `invalid store.lock.timeout "soon"`)
+}
+
+func TestEntriesLock(t *testing.T) {
+	viper.Reset()
+	defer viper.Reset()
+	viper.Set(cst.StoreLockTimeout, "200ms")
+
+	dir := filepath.Join(t.TempDir(), "store")
+	s := NewFileStore(dir).(*fileStore)
+	for _, key := range []string{"a", "b", "c"} {
+		assert.NoError(t, s.StoreString(key, "value"))
+	}
+
+	// All keys share a single lock file.
+	locks, err := os.ReadDir(dir + ".locks")
+	assert.NoError(t, err)
+	assert.Len(t, locks, 1)
+
+	// Deletions wait for the lock like reads and writes.
+	unlock, err := s.lockEntries()
+	assert.NoError(t, err)
+	assert.ErrorContains(t, s.Delete("a"), "timed out after 200ms")
+	assert.ErrorContains(t, s.Wipe(""), "timed out after 200ms")
+	unlock()
+
+	assert.NoError(t, s.Delete("a"))
+	assert.NoError(t, s.Wipe("b"))
+	keys, err := s.List("")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"c"}, keys)
+}
+
+func TestSecureStoreLock(t *testing.T) {
+	viper.Reset()
+	defer viper.Reset()
+	viper.Set(cst.StoreLockTimeout, "200ms")
+	dir := t.TempDir()
+	viper.Set(cst.StorePath, dir)
+
+	// Processes using the OS credential store wait for each other like with the file store.
+	locker := NewPassStore().(Locker)
+	unlock, err := locker.Lock("token-a")
+	assert.NoError(t, err)
+	_, err = NewPassStore().(Locker).Lock("token-a")
+	assert.ErrorContains(t, err, "timed out after 200ms")
+	unlock()
+
+	unlock, err = NewPassStore().(Locker).Lock("token-a")
+	assert.NoError(t, err)
+	unlock()
+
+	// The lock never clashes with locks of a file store in the same directory.
+	unlock, err = locker.Lock("token-a")
+	assert.NoError(t, err)
+	defer unlock()
+	unlockFile, err := NewFileStore(dir).(Locker).Lock("token-a")
+	assert.NoError(t, err)
+	unlockFile()
+}
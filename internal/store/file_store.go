@@ -3,6 +3,7 @@ package store
 import (
 	"encoding/json"
 	"fmt"
+	"io"
 	"os"
 	"path"
 	"strings"
@@ -43,25 +44,55 @@ func (s *fileStore) Store(key string, data any) error {
 	if err != nil {
 		return err
 	}
-	return s.internalStore.Write(key, marshaled)
+	return s.write(key, marshaled)
 }
 
 func (s *fileStore) StoreString(key string, data string) error {
-	return s.internalStore.Write(key, []byte(data))
+	return s.write(key, []byte(data))
 }
 
 func (s *fileStore) Get(key string, out any) error {
 	if !s.internalStore.Has(key) {
 		return nil
 	}
-	b, err := s.internalStore.Read(key)
+	b, err := s.read(key)
 	if err != nil {
 		return err
 	}
 	return json.Unmarshal(b, out)
 }
 
+// write stores data under the key holding the store lock, so concurrent processes never see a partial file.
+func (s *fileStore) write(key string, data []byte) error {
+	unlock, err := s.lockEntries()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	return s.internalStore.Write(key, data)
+}
+
+func (s *fileStore) read(key string) ([]byte, error) {
+	unlock, err := s.lockEntries()
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+	// Bypass the in-memory cache of diskv since another process can change the file.
+	rc, err := s.internalStore.ReadStream(key, true)
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	return io.ReadAll(rc)
+}
+
 func (s *fileStore) Delete(key string) error {
+	unlock, err := s.lockEntries()
+	if err != nil {
+		return err
+	}
+	defer unlock()
 	return s.internalStore.Erase(key)
 }
 
@@ -70,6 +101,11 @@ func (s *fileStore) Wipe(prefix string) error {
 	if err != nil {
 		return err
 	}
+	unlock, err := s.lockEntries()
+	if err != nil {
+		return err
+	}
+	defer unlock()
 	for _, k := range keys {
 		err = s.internalStore.Erase(k)
 		if err != nil {
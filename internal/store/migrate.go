@@ -0,0 +1,68 @@
+package store
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// MigrateResult lists the keys of entries copied and skipped by Migrate.
+type MigrateResult struct {
+	Migrated []string `json:"migrated"`
+	Skipped  []string `json:"skipped"`
+}
+
+// Migrate copies all entries from src to dst and reads them back from dst to verify them.
+// Entries for which skip returns true and entries which are not JSON, such as password encryption
+// key files of file stores, are skipped. If dryRun is true nothing is written.
+func Migrate(src Store, dst Store, skip func(key string) bool, dryRun bool) (*MigrateResult, error) {
+	keys, err := src.List("")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list entries: %w", err)
+	}
+	sort.Strings(keys)
+
+	res := &MigrateResult{Migrated: []string{}, Skipped: []string{}}
+	for _, key := range keys {
+		if skip != nil && skip(key) {
+			res.Skipped = append(res.Skipped, key)
+			continue
+		}
+		var data json.RawMessage
+		if err := src.Get(key, &data); err != nil {
+			var syntaxErr *json.SyntaxError
+			if errors.As(err, &syntaxErr) {
+				res.Skipped = append(res.Skipped, key)
+				continue
+			}
+			return res, fmt.Errorf("failed to read '%s': %w", key, err)
+		}
+		if len(data) == 0 {
+			continue
+		}
+		if !dryRun {
+			if err := dst.Store(key, data); err != nil {
+				return res, fmt.Errorf("failed to write '%s': %w", key, err)
+			}
+			if err := verifyEntry(dst, key, data); err != nil {
+				return res, err
+			}
+		}
+		res.Migrated = append(res.Migrated, key)
+	}
+	return res, nil
+}
+
+func verifyEntry(s Store, key string, want json.RawMessage) error {
+	var got json.RawMessage
+	if err := s.Get(key, &got); err != nil {
+		return fmt.Errorf("failed to verify '%s': %w", key, err)
+	}
+	var a, b bytes.Buffer
+	if json.Compact(&a, want) != nil || json.Compact(&b, got) != nil || !bytes.Equal(a.Bytes(), b.Bytes()) {
+		return fmt.Errorf("failed to verify '%s': data read back differs from the source", key)
+	}
+	return nil
+}
@@ -0,0 +1,41 @@
+package store
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMigrate(t *testing.T) {
+	src := NewFileStore(t.TempDir())
+	dst := NewFileStore(t.TempDir())
+	assert.NoError(t, src.Store("token-password-tenant-default", tokenData{Token: []byte("GIyZDY5O")}))
+	assert.NoError(t, src.Store("secret-abc", map[string]string{"password": "p@ssw0rd"}))
+	assert.NoError(t, src.Store("config-default-auth-password", "p@ssw0rd"))
+	assert.NoError(t, src.StoreString("encryptionkey-tenant-user", "raw-key"))
+
+	skip := func(key string) bool { return key == "config-default-auth-password" }
+
+	res, err := Migrate(src, dst, skip, true)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"secret-abc", "token-password-tenant-default"}, res.Migrated)
+	assert.Equal(t, []string{"config-default-auth-password", "encryptionkey-tenant-user"}, res.Skipped)
+	keys, err := dst.List("")
+	assert.NoError(t, err)
+	assert.Empty(t, keys)
+
+	res, err = Migrate(src, dst, skip, false)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"secret-abc", "token-password-tenant-default"}, res.Migrated)
+	var token tokenData
+	assert.NoError(t, dst.Get("token-password-tenant-default", &token))
+	assert.Equal(t, []byte("GIyZDY5O"), token.Token)
+	keys, err = dst.List("")
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []string{"secret-abc", "token-password-tenant-default"}, keys)
+
+	// Source entries are left intact.
+	keys, err = src.List("")
+	assert.NoError(t, err)
+	assert.Len(t, keys, 4)
+}
@@ -5,7 +5,10 @@ import (
 	"fmt"
 	"strings"
 
+	cst "github.com/DelineaXPM/dsv-cli/constants"
 	ch "github.com/DelineaXPM/dsv-cli/internal/store/credential-helpers"
+
+	"github.com/spf13/viper"
 )
 
 // storeHelper is the interface a credentials store helper must implement.
@@ -25,6 +28,19 @@ func NewSecureStore(helper storeHelper) Store {
 	return &secureStore{internalStore: helper}
 }
 
+// Lock implements Locker for the OS credential stores, which have no locks of their own, with an advisory
+// lock on a file next to the store directory (store.path or ~/.thy).
+func (s *secureStore) Lock(name string) (func(), error) {
+	dir := viper.GetString(cst.StorePath)
+	if dir == "" {
+		var err error
+		if dir, err = GetDefaultPath(); err != nil {
+			return nil, err
+		}
+	}
+	return lockFile(lockPath(dir, "lock-"+s.internalStore.GetName()+"-"+name))
+}
+
 func (s *secureStore) Store(key string, data any) error {
 	marshaled, err := json.Marshal(data)
 	if err != nil {
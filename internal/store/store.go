@@ -33,6 +33,8 @@ const (
 	PassLinux = "pass_linux"
 	WinCred   = "wincred"
 	File      = "file"
+	// FileEncrypted is the file store which encrypts data at rest.
+	FileEncrypted = "file_encrypted"
 )
 
 //nolint:gochecknoglobals // these vars are used to ensure a proper initialization.
@@ -53,21 +55,47 @@ func GetStore(st string) (Store, error) {
 
 	once.Do(func() {
 		storeType = st
-		switch storeType {
-		case None:
-			store = &NoneStore{}
-		case PassLinux:
-			store = NewPassStore()
-		case WinCred:
-			store = NewWinStore()
-		default:
-			store = NewFileStore(viper.GetString(cst.StorePath))
-		}
+		store = newStore(st)
 	})
 
 	return store, nil
 }
 
+// New returns a new store of the given type. Unlike GetStore it can be called for different store types
+// during the same execution, e.g. to move data from one store to another.
+func New(st string) (Store, error) {
+	if err := ValidateStoreType(st); err != nil {
+		return nil, err
+	}
+	return newStore(st), nil
+}
+
+func newStore(st string) Store {
+	switch st {
+	case None:
+		return &NoneStore{}
+	case PassLinux:
+		return NewPassStore()
+	case WinCred:
+		return NewWinStore()
+	case FileEncrypted:
+		return NewEncryptedFileStore(viper.GetString(cst.StorePath))
+	default:
+		return NewFileStore(viper.GetString(cst.StorePath))
+	}
+}
+
+// IsSecure reports whether the store type keeps secure settings, such as passwords, as store entries
+// instead of the configuration file.
+func IsSecure(st string) bool {
+	return st == PassLinux || st == WinCred
+}
+
+// SecureSettingKey returns the store key of a secure setting, e.g. "default.auth.password".
+func SecureSettingKey(key string) string {
+	return cst.CliConfigRoot + "-" + strings.ReplaceAll(key, ".", "-")
+}
+
 func ValidateStoreType(storeType string) error {
 	// TODO : support osxkeychain, secretservice
 	switch storeType {
@@ -81,11 +109,11 @@ func ValidateStoreType(storeType string) error {
 			return fmt.Errorf("'wincred' option for store.type is supported on windows only")
 		}
 		return nil
-	case File, None, Unset:
+	case File, FileEncrypted, None, Unset:
 		return nil
 	default:
 		return fmt.Errorf(
-			"'%s' key store not supported. Please choose from: ['pass_linux','wincred','file', and 'none']",
+			"'%s' key store not supported. Please choose from: ['pass_linux','wincred','file','file_encrypted', and 'none']",
 			storeType,
 		)
 	}
@@ -96,7 +124,7 @@ func StoreSecureSetting(key string, val string, sType string) error {
 		return fmt.Errorf("neither key nor value can be empty")
 	}
 
-	if sType != PassLinux && sType != WinCred {
+	if !IsSecure(sType) {
 		return fmt.Errorf("store.type is not secure store")
 	}
 
@@ -104,8 +132,7 @@ func StoreSecureSetting(key string, val string, sType string) error {
 	if err != nil {
 		return err
 	}
-	keyFull := cst.CliConfigRoot + "-" + strings.ReplaceAll(key, ".", "-")
-	return s.Store(keyFull, val)
+	return s.Store(SecureSettingKey(key), val)
 }
 
 func getSecureSettingForProfile(key string, profile string) (string, error) {
@@ -126,9 +153,8 @@ func getSecureSettingForProfile(key string, profile string) (string, error) {
 	if err != nil {
 		return "", fmt.Errorf("failed to fetch store: %w", err)
 	}
-	keyFull := cst.CliConfigRoot + "-" + strings.ReplaceAll(keyProfile, ".", "-")
 	var res string
-	err = s.Get(keyFull, &res)
+	err = s.Get(SecureSettingKey(keyProfile), &res)
 	return res, err
 }
 
@@ -159,6 +185,9 @@ func GetDefaultPath() (string, error) {
 // ReadFileInDefaultPath attempts to read a file in a store path. If the store path is not found,
 // then the default thy directory is searched for a given file.
 func ReadFileInDefaultPath(fileName string) (string, error) {
+	if viper.GetString(cst.StoreType) == FileEncrypted {
+		return readEncryptedString(fileName)
+	}
 	storePath := viper.GetString(cst.StorePath)
 	if storePath == "" {
 		defaultPath, err := GetDefaultPath()
@@ -45,6 +45,7 @@ func testStore(t *testing.T, storeType string) {
 		// Setup for file store to not use local user default path.
 		viper.Set(cst.StorePath, "./testing-store-asb5a23afs3")
 		defer os.Remove("./testing-store-asb5a23afs3")
+		defer os.RemoveAll("./testing-store-asb5a23afs3.locks")
 	}
 
 	// arrange
@@ -53,9 +53,16 @@ func runCLI(args []string) (exitStatus int, err error) {
 		"secret restore":                cmd.GetSecretRestoreCmd,
 		"secret create":                 cmd.GetSecretCreateCmd,
 		"secret update":                 cmd.GetSecretUpdateCmd,
+		"secret history":                cmd.GetSecretHistoryCmd,
+		"secret diff":                   cmd.GetSecretDiffCmd,
+		"secret copy":                   cmd.GetSecretCopyCmd,
+		"secret move":                   cmd.GetSecretMoveCmd,
 		"secret rollback":               cmd.GetSecretRollbackCmd,
 		"secret edit":                   cmd.GetSecretEditCmd,
 		"secret bustcache":              cmd.GetSecretBustCacheCmd,
+		"secret export":                 cmd.GetSecretExportCmd,
+		"secret import":                 cmd.GetSecretImportCmd,
+		"secret sync":                   cmd.GetSecretSyncCmd,
 		"policy":                        cmd.GetPolicyCmd,
 		"policy read":                   cmd.GetPolicyReadCmd,
 		"policy search":                 cmd.GetPolicySearchCmd,
@@ -64,6 +71,10 @@ func runCLI(args []string) (exitStatus int, err error) {
 		"policy create":                 cmd.GetPolicyCreateCmd,
 		"policy edit":                   cmd.GetPolicyEditCmd,
 		"policy update":                 cmd.GetPolicyUpdateCmd,
+		"policy history":                cmd.GetPolicyHistoryCmd,
+		"policy diff":                   cmd.GetPolicyDiffCmd,
+		"policy lint":                   cmd.GetPolicyLintCmd,
+		"policy test":                   cmd.GetPolicyTestCmd,
 		"policy rollback":               cmd.GetPolicyRollbackCmd,
 		"auth":                          cmd.GetAuthCmd,
 		"auth clear":                    cmd.GetAuthClearCmd,
@@ -143,6 +154,8 @@ func runCLI(args []string) (exitStatus int, err error) {
 		"home search":                   cmd.GetHomeSearchCmd,
 		"home describe":                 cmd.GetHomeDescribeCmd,
 		"home edit":                     cmd.GetHomeEditCmd,
+		"home history":                  cmd.GetHomeHistoryCmd,
+		"home diff":                     cmd.GetHomeDiffCmd,
 		"home rollback":                 cmd.GetHomeRollbackCmd,
 		"home restore":                  cmd.GetHomeRestoreCmd,
 		"pool":                          cmd.GetPoolCmd,
@@ -189,6 +202,22 @@ func runCLI(args []string) (exitStatus int, err error) {
 		"breakglass apply":              cmd.GetBreakGlassApplyCmd,
 		"byok":                          cmd.GetBYOKCmd,
 		"byok update":                   cmd.GetBYOKUpdateCmd,
+		"apply":                         cmd.GetApplyCmd,
+		"plan":                          cmd.GetPlanCmd,
+		"run":                           cmd.GetRunCmd,
+		"template":                      cmd.GetTemplateCmd,
+		"template render":               cmd.GetTemplateRenderCmd,
+		"agent":                         cmd.GetAgentCmd,
+		"agent start":                   cmd.GetAgentStartCmd,
+		"cache":                         cmd.GetCacheCmd,
+		"cache inspect":                 cmd.GetCacheInspectCmd,
+		"cache list":                    cmd.GetCacheListCmd,
+		"cache prefetch":                cmd.GetCachePrefetchCmd,
+		"cache purge":                   cmd.GetCachePurgeCmd,
+		"cache rekey":                   cmd.GetCacheRekeyCmd,
+		"cache stats":                   cmd.GetCacheStatsCmd,
+		"store":                         cmd.GetStoreCmd,
+		"store migrate":                 cmd.GetStoreMigrateCmd,
 	}
 
 	c.Autocomplete = true
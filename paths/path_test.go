@@ -146,6 +146,17 @@ func TestCreateURI(t *testing.T) {
 	}
 }
 
+func TestCreateURIWithNetworkSettings(t *testing.T) {
+	viper.Reset()
+	defer viper.Reset()
+	viper.Set(cst.HTTPSchemeKey, "http")
+	viper.Set(cst.Tenant, "www")
+	viper.Set(cst.NetworkTimeout, "30")
+	viper.Set(cst.NetworkRetries, "1")
+
+	assert.Equal(t, "http://www.secretsvaultcloud.com/v1/path", CreateURI("path", nil))
+}
+
 func TestGetURIPathFromInternalPath(t *testing.T) {
 	tests := []struct {
 		internalPath string
@@ -6,6 +6,7 @@ import (
 	cst "github.com/DelineaXPM/dsv-cli/constants"
 	"github.com/DelineaXPM/dsv-cli/errors"
 	"github.com/DelineaXPM/dsv-cli/format"
+	"github.com/DelineaXPM/dsv-cli/internal/httpclient"
 
 	"github.com/spf13/viper"
 	"golang.org/x/oauth2"
@@ -31,9 +32,15 @@ func (c *graphClient) DoRequest(uri string, query interface{}, variables map[str
 			AccessToken: viper.GetString(cst.NounToken),
 		},
 	)
-	httpClient := oauth2.NewClient(context.Background(), src)
+	stdClient, err := httpclient.New()
+	if err != nil {
+		return nil, errors.New(err).Grow("Failed to configure HTTP client")
+	}
+	// Queries do not change anything, so they are safe to retry.
+	ctx := httpclient.WithIdempotent(context.WithValue(context.Background(), oauth2.HTTPClient, stdClient))
+	httpClient := oauth2.NewClient(ctx, src)
 	client := graphql.NewClient(uri, httpClient)
-	if err := client.Query(context.Background(), query, variables); err != nil {
+	if err := client.Query(ctx, query, variables); err != nil {
 		return nil, errors.NewS(err.Error())
 	}
 	resp, err := format.JsonMarshal(&query)
@@ -5,13 +5,12 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
-	"log"
 	"net/http"
 	"runtime"
-	"time"
 
 	cst "github.com/DelineaXPM/dsv-cli/constants"
 	"github.com/DelineaXPM/dsv-cli/errors"
+	"github.com/DelineaXPM/dsv-cli/internal/httpclient"
 	"github.com/DelineaXPM/dsv-cli/version"
 
 	"github.com/spf13/viper"
@@ -87,19 +86,19 @@ func (c *httpClient) buildRequest(method string, uri string, body interface{}) (
 }
 
 func (c *httpClient) do(req *http.Request) ([]byte, *errors.ApiError) {
-	log.Printf("-> %s %s", req.Method, req.URL)
-
-	startTime := time.Now()
-	resp, err := http.DefaultClient.Do(req)
+	client, err := httpclient.New()
+	if err != nil {
+		return nil, errors.New(err).Grow("Failed to configure HTTP client")
+	}
+	// Attempts are logged by the transport.
+	resp, err := client.Do(req)
 	if err != nil {
 		return nil, errors.New(err).Grow("Failed to send API request")
 	}
-
-	log.Printf("<- %s %s | %s (took: %s)", req.Method, req.URL, resp.Status, time.Since(startTime))
 	defer resp.Body.Close()
 
 	bodyBytes, rerr := io.ReadAll(resp.Body)
-	if err != nil {
+	if rerr != nil {
 		return nil, errors.New(rerr).Grow("Malformed API response")
 	}
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
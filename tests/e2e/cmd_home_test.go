@@ -55,5 +55,17 @@ func TestHome(t *testing.T) {
 	requireContains(t, output, `"description": "zero-description-0"`)
 	requireContains(t, output, fmt.Sprintf(`"path": "users:%s:%s"`, username, path))
 
+	output = runWithProfile(t, fmt.Sprintf("home read --path %s --at-version 2", path))
+	requireContains(t, output, `"description": "two-description-2"`)
+	requireContains(t, output, `"version": "2"`)
+
+	output = runWithProfile(t, fmt.Sprintf("home history %s", path))
+	requireContains(t, output, `"version": "3"`)
+	requireContains(t, output, `"version": "0"`)
+
+	output = runWithProfile(t, fmt.Sprintf("home diff %s --from 0 --to 2", path))
+	requireContains(t, output, `"from": "0"`)
+	requireContains(t, output, `"to": "2"`)
+
 	runWithProfile(t, fmt.Sprintf("home delete %s --force", path))
 }
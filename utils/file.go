@@ -0,0 +1,45 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// WriteFileAtomic writes data to a temporary file in the same directory and renames it to the target path,
+// so readers never observe a partially written file. The file gets the given permissions even if it existed before.
+func WriteFileAtomic(path string, data []byte, perm os.FileMode) error {
+	return WriteFileAtomicOwned(path, data, perm, -1, -1)
+}
+
+// WriteFileAtomicOwned is like WriteFileAtomic but also changes the owner of the file before it is renamed.
+// A uid or gid of -1 means not to change that value.
+func WriteFileAtomicOwned(path string, data []byte, perm os.FileMode, uid, gid int) error {
+	dir := filepath.Dir(path)
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	tmpName := tmp.Name()
+	defer os.Remove(tmpName)
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmpName, perm); err != nil {
+		return err
+	}
+	if uid != -1 || gid != -1 {
+		if err := os.Chown(tmpName, uid, gid); err != nil {
+			return err
+		}
+	}
+	return os.Rename(tmpName, path)
+}
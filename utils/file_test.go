@@ -0,0 +1,59 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriteFileAtomic(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "out.conf")
+
+	err := WriteFileAtomic(path, []byte("first"), 0o600)
+	assert.NoError(t, err)
+
+	err = os.Chmod(path, 0o644)
+	assert.NoError(t, err)
+
+	err = WriteFileAtomic(path, []byte("second"), 0o600)
+	assert.NoError(t, err)
+
+	b, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	assert.Equal(t, "second", string(b))
+
+	if runtime.GOOS != "windows" {
+		info, err := os.Stat(path)
+		assert.NoError(t, err)
+		assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+	}
+
+	entries, err := os.ReadDir(dir)
+	assert.NoError(t, err)
+	assert.Len(t, entries, 1, "temporary file must be removed")
+}
+
+func TestWriteFileAtomicOwned(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("changing owner is not supported on windows")
+	}
+	path := filepath.Join(t.TempDir(), "out.conf")
+
+	err := WriteFileAtomicOwned(path, []byte("data"), 0o640, os.Getuid(), os.Getgid())
+	assert.NoError(t, err)
+	b, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	assert.Equal(t, "data", string(b))
+
+	// Changing the owner to another user requires privileges.
+	if os.Getuid() != 0 {
+		err = WriteFileAtomicOwned(path, []byte("other"), 0o640, 0, 0)
+		assert.Error(t, err)
+		b, _ = os.ReadFile(path)
+		assert.Equal(t, "data", string(b), "file must not be replaced if owner cannot be changed")
+	}
+}
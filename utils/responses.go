@@ -4,8 +4,21 @@ import (
 	"encoding/json"
 	"errors"
 	"strconv"
+	"strings"
 )
 
+// GetVersion returns the version property of a JSON response, e.g. a secret or its description.
+// The version can be either a string or a number. An empty string is returned if there is no version.
+func GetVersion(resp []byte) string {
+	var v struct {
+		Version json.RawMessage `json:"version"`
+	}
+	if err := json.Unmarshal(resp, &v); err != nil || len(v.Version) == 0 {
+		return ""
+	}
+	return strings.Trim(string(v.Version), `"`)
+}
+
 // GetPreviousVersion tries to extract the version property in a JSON response and
 // return the previous version that must be a non-negative integer.
 func GetPreviousVersion(resp []byte) (string, error) {
@@ -0,0 +1,18 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetVersion(t *testing.T) {
+	f := func(t *testing.T, in string, expected string) {
+		t.Helper()
+		assert.Equal(t, expected, GetVersion([]byte(in)))
+	}
+	f(t, `{"version":"3"}`, "3")
+	f(t, `{"version":3}`, "3")
+	f(t, `{"path":"a"}`, "")
+	f(t, `not json`, "")
+}
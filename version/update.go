@@ -6,7 +6,6 @@ import (
 	"fmt"
 	"io"
 	"log"
-	"net/http"
 	"os"
 	"path/filepath"
 	"runtime"
@@ -14,6 +13,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/DelineaXPM/dsv-cli/internal/httpclient"
 	"github.com/DelineaXPM/dsv-cli/internal/store"
 )
 
@@ -128,7 +128,11 @@ func updateCache(cacheFilePath string, content []byte) {
 // fetchContent retrieves content of the URL
 func fetchContent(urlToFetch string) ([]byte, error) {
 	log.Println("Attempting to query the download server for a CLI update.")
-	resp, err := http.Get(urlToFetch)
+	client, err := httpclient.NewExternal()
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.Get(urlToFetch)
 	if err != nil {
 		return nil, err
 	}